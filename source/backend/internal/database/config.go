@@ -14,6 +14,11 @@ import (
 
 var DB *gorm.DB
 
+// System is the pool for work that acts across businesses on purpose. With
+// row-level security enabled its sessions set app.bypass_rls; otherwise it is DB.
+// It is only reached through Conn on a context from SystemContext.
+var System *gorm.DB
+
 func Init(config utils.Config) {
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
@@ -39,6 +44,17 @@ func Init(config utils.Config) {
 	}
 
 	DB = conn
+	System = conn
+
+	if config.Row_Level_Security {
+		system, err := gorm.Open(postgres.Open(dsn+" options='-c app.bypass_rls=on'"), &gorm.Config{
+			Logger: gormLogger,
+		})
+		if err != nil {
+			log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+		}
+		System = system
+	}
 
 	log.Printf("Connected to PostgreSQL at %s:%s as %s", config.Host, config.Port, config.User)
 }
@@ -49,7 +65,14 @@ func Migrate(models ...any) {
 }
 
 func Close() {
-	sqlDB, err := DB.DB()
+	if System != DB {
+		closePool(System)
+	}
+	closePool(DB)
+}
+
+func closePool(pool *gorm.DB) {
+	sqlDB, err := pool.DB()
 	if err != nil {
 		log.Printf("Error retrieving sql.DB from gorm.DB: %v\n", err)
 		return
@@ -1,10 +1,18 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
+const (
+	BusinessStatusActive    = "active"
+	BusinessStatusSuspended = "suspended"
+	BusinessStatusDeleted   = "deleted"
+)
+
 type Business struct {
 	ID              uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	BusinessName    string         `json:"businessName" validate:"required,min=2,max=64"`
@@ -15,5 +23,15 @@ type Business struct {
 	CompanySize     string         `gorm:"size:64" json:"companySize"`
 	Country         string         `gorm:"size:64" json:"country"`
 	UserCanRegister bool           `json:"userCanRegister" gorm:"default:true"`
+	ListInDirectory bool           `json:"listInDirectory" gorm:"default:true"`
 	LoginMethods    pq.StringArray `gorm:"type:text[]" json:"loginMethods" validate:"dive,oneof=password magic_link oauth"`
+
+	// Lifecycle. A deleted business is kept until PurgeAfter so it can be
+	// restored; suspension survives a delete/restore round trip.
+	Status           string     `gorm:"type:text;not null;default:'active';index;check:status IN ('active','suspended','deleted')" json:"status"`
+	SuspendedAt      *time.Time `json:"suspendedAt,omitempty"`
+	SuspensionReason string     `gorm:"type:text" json:"suspensionReason,omitempty"`
+	DeletedAt        *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy        *uuid.UUID `gorm:"type:uuid" json:"deletedBy,omitempty"`
+	PurgeAfter       *time.Time `gorm:"index" json:"purgeAfter,omitempty"`
 }
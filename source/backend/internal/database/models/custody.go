@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Condition of equipment when it is checked back in.
+const (
+	ReturnConditionGood    = "good"
+	ReturnConditionWorn    = "worn"
+	ReturnConditionDamaged = "damaged"
+)
+
+// EquipmentCheckout is one period in which a user had custody of a piece of
+// equipment. It is open until CheckedInAt is set, and a piece of equipment can
+// have only one open checkout at a time.
+type EquipmentCheckout struct {
+	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentID       uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_open_checkout,where:checked_in_at IS NULL" json:"equipmentId"`
+	CustodianID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"custodianId"`
+	CheckedOutBy      *uuid.UUID `gorm:"type:uuid" json:"checkedOutBy,omitempty"`
+	CheckedOutAt      time.Time  `gorm:"not null" json:"checkedOutAt"`
+	DueAt             *time.Time `gorm:"index" json:"dueAt"`
+	CheckoutNote      string     `gorm:"type:text" json:"checkoutNote,omitempty"`
+	CheckedInAt       *time.Time `json:"checkedInAt"`
+	CheckedInBy       *uuid.UUID `gorm:"type:uuid" json:"checkedInBy,omitempty"`
+	ReturnCondition   string     `gorm:"type:varchar(16);check:chk_return_condition,return_condition = '' OR return_condition IN ('good','worn','damaged')" json:"returnCondition,omitempty"`
+	ReturnNote        string     `gorm:"type:text" json:"returnNote,omitempty"`
+	OverdueNotifiedAt *time.Time `json:"-"`
+
+	Equipment Equipment `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+	Custodian User      `gorm:"foreignKey:CustodianID;constraint:OnDelete:RESTRICT" json:"custodian"`
+}
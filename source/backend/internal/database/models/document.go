@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Categories of equipment documents.
+const (
+	DocumentCategoryManual        = "manual"
+	DocumentCategoryWiringDiagram = "wiring diagram"
+	DocumentCategorySOP           = "sop"
+	DocumentCategoryCertificate   = "certificate"
+	DocumentCategoryOther         = "other"
+)
+
+// EquipmentDocument is a file such as a manual or SOP kept in object storage.
+// It belongs to one piece of equipment or to every piece of an equipment type;
+// ObjectKey lies under the business's key prefix.
+type EquipmentDocument struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentID     *uuid.UUID `gorm:"type:uuid;index" json:"equipmentId"`
+	EquipmentTypeID *uuid.UUID `gorm:"type:uuid;index" json:"equipmentTypeId"`
+	Title           string     `gorm:"type:varchar(256);not null" json:"title"`
+	Category        string     `gorm:"type:varchar(32);not null;check:chk_document_category,category IN ('manual','wiring diagram','sop','certificate','other')" json:"category"`
+	Version         string     `gorm:"type:varchar(32)" json:"version"`
+	ObjectKey       string     `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	FileName        string     `gorm:"type:varchar(255);not null" json:"fileName"`
+	ContentType     string     `gorm:"type:varchar(128);not null" json:"contentType"`
+	Size            int64      `gorm:"not null" json:"size"`
+	UploadedBy      *uuid.UUID `gorm:"type:uuid" json:"uploadedBy,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Equipment     *Equipment     `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+	EquipmentType *EquipmentType `gorm:"foreignKey:EquipmentTypeID;constraint:OnDelete:CASCADE" json:"-"`
+}
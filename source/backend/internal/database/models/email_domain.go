@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DomainRoleMember = "member"
+	DomainRoleAdmin  = "admin"
+
+	DomainModeAutoApprove    = "auto_approve"
+	DomainModePendingRequest = "pending_request"
+)
+
+// BusinessEmailDomain lets users with a verified address on Domain join the
+// business automatically. A domain only takes effect once VerifiedAt is set,
+// and at most one business can hold a verified claim on a domain.
+type BusinessEmailDomain struct {
+	ID                uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	Domain            string     `gorm:"type:varchar(253);not null;index;uniqueIndex:idx_verified_email_domain,where:verified_at IS NOT NULL" json:"domain"`
+	DefaultRole       string     `gorm:"type:text;not null;default:'member';check:default_role IN ('member','admin')" json:"defaultRole"`
+	Mode              string     `gorm:"type:text;not null;default:'pending_request';check:mode IN ('auto_approve','pending_request')" json:"mode"`
+	VerificationToken string     `gorm:"type:varchar(64);not null" json:"verificationToken"`
+	VerifiedAt        *time.Time `json:"verifiedAt,omitempty"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	Business          Business   `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
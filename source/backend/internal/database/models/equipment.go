@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 )
@@ -8,9 +10,31 @@ import (
 type Equipment struct {
 	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	BusinessID uuid.UUID      `gorm:"type:uuid;not null;index" json:"businessId"`
-	Status     string         `gorm:"type:text;not null;check:status IN ('in service','not in service')" json:"status"`
+	Status     string         `gorm:"type:text;not null;check:chk_equipment_status,status IN ('commissioning','in service','degraded','out of service','under repair','quarantined','retired')" json:"status"`
 	Type       string         `gorm:"type:text;not null" json:"type"`
+	TypeID     *uuid.UUID     `gorm:"type:uuid;index" json:"typeId"`
 	Location   string         `gorm:"type:text" json:"location"`
+	LocationID *uuid.UUID     `gorm:"type:uuid;index" json:"locationId"`
 	MoreFields datatypes.JSON `gorm:"type:jsonb" json:"moreFields"`
-	Business   Business       `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"business"`
+
+	// ParentID is the assembly this equipment is a component of, e.g. the press
+	// line a motor belongs to. Components keep their own label and issues.
+	ParentID *uuid.UUID `gorm:"type:uuid;index" json:"parentId"`
+
+	// ResponsibleTeamID is the team new issues on this equipment are routed to.
+	ResponsibleTeamID *uuid.UUID `gorm:"type:uuid;index" json:"responsibleTeamId"`
+
+	// Retired equipment is hidden from lists by default but keeps its issues and history.
+	RetiredAt        *time.Time `gorm:"index" json:"retiredAt,omitempty"`
+	RetiredBy        *uuid.UUID `gorm:"type:uuid" json:"retiredBy,omitempty"`
+	RetirementReason string     `gorm:"type:text" json:"retirementReason,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Business        Business       `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"business"`
+	TypeEntry       *EquipmentType `gorm:"foreignKey:TypeID;constraint:OnDelete:SET NULL" json:"typeEntry,omitempty"`
+	Parent          *Equipment     `gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL" json:"-"`
+	LocationNode    *Location      `gorm:"foreignKey:LocationID;constraint:OnDelete:SET NULL" json:"locationNode,omitempty"`
+	ResponsibleTeam *Team          `gorm:"foreignKey:ResponsibleTeamID;constraint:OnDelete:SET NULL" json:"responsibleTeam,omitempty"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EquipmentMoveEvent records equipment being attached to, detached from or moved
+// between assemblies. A nil FromParentID or ToParentID means standalone.
+type EquipmentMoveEvent struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EquipmentID  uuid.UUID  `gorm:"type:uuid;not null;index:idx_move_event_equipment" json:"equipmentId"`
+	BusinessID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	FromParentID *uuid.UUID `gorm:"type:uuid" json:"fromParentId"`
+	ToParentID   *uuid.UUID `gorm:"type:uuid" json:"toParentId"`
+	Reason       string     `gorm:"type:text" json:"reason,omitempty"`
+	ActorID      *uuid.UUID `gorm:"type:uuid" json:"actorId,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime;index:idx_move_event_equipment" json:"createdAt"`
+	Equipment    Equipment  `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+	Actor        *User      `gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL" json:"actor,omitempty"`
+}
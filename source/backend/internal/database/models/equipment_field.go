@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+)
+
+const (
+	FieldKindText    = "text"
+	FieldKindNumber  = "number"
+	FieldKindDate    = "date"
+	FieldKindEnum    = "enum"
+	FieldKindBoolean = "boolean"
+)
+
+// EquipmentField defines one entry of Equipment.MoreFields for equipment of a
+// given type within a business.
+type EquipmentField struct {
+	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID    uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_equipment_field_key" json:"businessId"`
+	EquipmentType string         `gorm:"type:text;not null;uniqueIndex:idx_equipment_field_key" json:"equipmentType"`
+	Key           string         `gorm:"size:64;not null;uniqueIndex:idx_equipment_field_key" json:"key"`
+	Label         string         `gorm:"size:128;not null" json:"label"`
+	Kind          string         `gorm:"type:text;not null;check:kind IN ('text','number','date','enum','boolean')" json:"kind"`
+	Unit          string         `gorm:"size:32" json:"unit,omitempty"` // numbers only, e.g. "kg" or "h"
+	Options       pq.StringArray `gorm:"type:text[]" json:"options,omitempty"`
+	Required      bool           `gorm:"not null;default:false" json:"required"`
+	Default       datatypes.JSON `gorm:"type:jsonb" json:"default,omitempty"`
+	Position      int            `gorm:"not null;default:0" json:"position"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updatedAt"`
+	Business      Business       `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
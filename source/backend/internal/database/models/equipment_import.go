@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+)
+
+const (
+	ImportStatusRunning   = "running"
+	ImportStatusFailed    = "failed"
+	ImportStatusCompleted = "completed"
+)
+
+// EquipmentImport tracks a chunked import so it can be resumed after a failure.
+// Rows holds the validated rows still to be created; ProcessedRows counts how
+// many of them have been committed.
+type EquipmentImport struct {
+	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"businessId"`
+	CreatedBy     uuid.UUID      `gorm:"type:uuid;not null" json:"createdBy"`
+	Filename      string         `gorm:"type:text" json:"filename"`
+	Status        string         `gorm:"type:text;not null;check:status IN ('running','failed','completed')" json:"status"`
+	ChunkSize     int            `gorm:"not null" json:"chunkSize"`
+	TotalRows     int            `gorm:"not null" json:"totalRows"`
+	ProcessedRows int            `gorm:"not null;default:0" json:"processedRows"`
+	CreatedIDs    pq.StringArray `gorm:"type:text[]" json:"createdIds"`
+	Rows          datatypes.JSON `gorm:"type:jsonb" json:"-"`
+	Report        datatypes.JSON `gorm:"type:jsonb" json:"report"`
+	Error         string         `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updatedAt"`
+	CompletedAt   *time.Time     `json:"completedAt,omitempty"`
+	Business      Business       `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
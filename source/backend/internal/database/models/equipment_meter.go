@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Where a meter reading came from.
+const (
+	MeterSourceManual = "manual"
+	MeterSourceQR     = "qr"
+)
+
+// EquipmentMeter is a counter or gauge on a piece of equipment, such as engine
+// hours, an odometer or a cycle count. Readings of a monotonic meter may never go
+// down except through a reset, e.g. when the meter itself is replaced.
+type EquipmentMeter struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentID uuid.UUID  `gorm:"type:uuid;not null;index" json:"equipmentId"`
+	Name        string     `gorm:"type:varchar(64);not null" json:"name"`
+	Unit        string     `gorm:"type:varchar(16);not null" json:"unit"`
+	Monotonic   bool       `gorm:"not null;default:true" json:"monotonic"`
+	LastValue   *float64   `json:"lastValue"`
+	LastReadAt  *time.Time `json:"lastReadAt"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+	Equipment   Equipment  `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// MeterReading is one value of a meter at a point in time. A reset reading starts
+// a new baseline: it is not checked against earlier values and does not count
+// towards usage.
+type MeterReading struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	MeterID     uuid.UUID      `gorm:"type:uuid;not null;index:idx_meter_reading_time" json:"meterId"`
+	EquipmentID uuid.UUID      `gorm:"type:uuid;not null;index" json:"equipmentId"`
+	BusinessID  uuid.UUID      `gorm:"type:uuid;not null;index" json:"businessId"`
+	Value       float64        `gorm:"not null" json:"value"`
+	ReadAt      time.Time      `gorm:"not null;index:idx_meter_reading_time" json:"readAt"`
+	Reset       bool           `gorm:"not null;default:false" json:"reset"`
+	Source      string         `gorm:"type:varchar(16);not null;default:'manual'" json:"source"`
+	Note        string         `gorm:"type:text" json:"note,omitempty"`
+	ActorID     *uuid.UUID     `gorm:"type:uuid" json:"actorId,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"createdAt"`
+	Meter       EquipmentMeter `gorm:"foreignKey:MeterID;constraint:OnDelete:CASCADE" json:"-"`
+	Actor       *User          `gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL" json:"actor,omitempty"`
+}
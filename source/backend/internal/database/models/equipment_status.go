@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Equipment lifecycle states. Which changes between them are allowed is
+// configured per business with EquipmentStatusTransition.
+const (
+	EquipmentStatusCommissioning = "commissioning"
+	EquipmentStatusInService     = "in service"
+	EquipmentStatusDegraded      = "degraded"
+	EquipmentStatusOutOfService  = "out of service"
+	EquipmentStatusUnderRepair   = "under repair"
+	EquipmentStatusQuarantined   = "quarantined"
+	EquipmentStatusRetired       = "retired"
+)
+
+// EquipmentStatuses lists every lifecycle state in display order.
+var EquipmentStatuses = []string{
+	EquipmentStatusCommissioning,
+	EquipmentStatusInService,
+	EquipmentStatusDegraded,
+	EquipmentStatusOutOfService,
+	EquipmentStatusUnderRepair,
+	EquipmentStatusQuarantined,
+	EquipmentStatusRetired,
+}
+
+// EquipmentStatusTransition allows equipment of a business to move from one
+// status to another. Businesses without any rows use the default transitions.
+type EquipmentStatusTransition struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_status_transition" json:"businessId"`
+	FromStatus string    `gorm:"type:text;not null;uniqueIndex:idx_status_transition" json:"from"`
+	ToStatus   string    `gorm:"type:text;not null;uniqueIndex:idx_status_transition" json:"to"`
+	Business   Business  `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// EquipmentStatusEvent records one status change of a piece of equipment. The
+// first event of newly created equipment has an empty FromStatus.
+type EquipmentStatusEvent struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EquipmentID uuid.UUID  `gorm:"type:uuid;not null;index:idx_status_event_equipment" json:"equipmentId"`
+	BusinessID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	FromStatus  string     `gorm:"type:text" json:"from"`
+	ToStatus    string     `gorm:"type:text;not null" json:"to"`
+	Reason      string     `gorm:"type:text" json:"reason,omitempty"`
+	ActorID     *uuid.UUID `gorm:"type:uuid" json:"actorId,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index:idx_status_event_equipment" json:"createdAt"`
+	Equipment   Equipment  `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+	Actor       *User      `gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL" json:"actor,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// IssueTemplate pre-fills the issue form for equipment of a type.
+type IssueTemplate struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// EquipmentType is an entry in a business's equipment type catalog. Equipment
+// keeps the type name in Equipment.Type for display and filtering; TypeID links
+// it to the catalog entry.
+type EquipmentType struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID uuid.UUID `gorm:"type:uuid;not null;index" json:"businessId"`
+	Name       string    `gorm:"size:64;not null" json:"name"`
+	Icon       string    `gorm:"size:64" json:"icon"`
+
+	// DefaultFields are MoreFields values given to new equipment of this type.
+	DefaultFields datatypes.JSON `gorm:"type:jsonb" json:"defaultFields"`
+	// DefaultMaintenancePlan is copied into a maintenance plan for new equipment.
+	DefaultMaintenancePlan datatypes.JSON                     `gorm:"type:jsonb" json:"defaultMaintenancePlan"`
+	DefaultIssueTemplates  datatypes.JSONSlice[IssueTemplate] `gorm:"type:jsonb" json:"defaultIssueTemplates"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+	Business  Business  `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
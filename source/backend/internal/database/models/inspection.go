@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Kinds of checklist item.
+const (
+	InspectionItemPassFail = "pass_fail"
+	InspectionItemNumeric  = "numeric"
+	InspectionItemText     = "text"
+	InspectionItemPhoto    = "photo"
+)
+
+// Where an inspection was started from.
+const (
+	InspectionSourceManual = "manual"
+	InspectionSourceQR     = "qr"
+)
+
+// States of an inspection run.
+const (
+	InspectionInProgress = "in progress"
+	InspectionPassed     = "passed"
+	InspectionFailed     = "failed"
+)
+
+// InspectionItem is one check on a checklist. Numeric items fail outside
+// [Min, Max]; photo items need a photo to be answered. When CreatesIssue is set
+// a failed answer opens an issue on the equipment.
+type InspectionItem struct {
+	Key          string   `json:"key"`
+	Label        string   `json:"label"`
+	Kind         string   `json:"kind"`
+	Required     bool     `json:"required"`
+	Min          *float64 `json:"min,omitempty"`
+	Max          *float64 `json:"max,omitempty"`
+	Unit         string   `json:"unit,omitempty"`
+	CreatesIssue bool     `json:"createsIssue"`
+}
+
+// InspectionTemplate is a checklist for equipment of one type, such as a daily
+// pre-use check. Version goes up whenever the items change; runs keep a copy of
+// the items they were started with.
+type InspectionTemplate struct {
+	ID              uuid.UUID                           `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID      uuid.UUID                           `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentTypeID uuid.UUID                           `gorm:"type:uuid;not null;index" json:"equipmentTypeId"`
+	Name            string                              `gorm:"type:varchar(128);not null" json:"name"`
+	Description     string                              `gorm:"type:text" json:"description"`
+	Items           datatypes.JSONSlice[InspectionItem] `gorm:"type:jsonb;not null" json:"items"`
+	Version         int                                 `gorm:"not null;default:1" json:"version"`
+	Active          bool                                `gorm:"not null;default:true" json:"active"`
+	CreatedAt       time.Time                           `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt       time.Time                           `gorm:"autoUpdateTime" json:"updatedAt"`
+	EquipmentType   EquipmentType                       `gorm:"foreignKey:EquipmentTypeID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// InspectionRun is one pass through a checklist on a piece of equipment,
+// usually started by scanning its QR code. It fails when any item fails.
+type InspectionRun struct {
+	ID              uuid.UUID          `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID      uuid.UUID          `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentID     uuid.UUID          `gorm:"type:uuid;not null;index:idx_inspection_run_equipment" json:"equipmentId"`
+	TemplateID      *uuid.UUID         `gorm:"type:uuid;index" json:"templateId"`
+	TemplateName    string             `gorm:"type:varchar(128);not null" json:"templateName"`
+	TemplateVersion int                `gorm:"not null" json:"templateVersion"`
+	Status          string             `gorm:"type:text;not null;default:'in progress';check:chk_inspection_status,status IN ('in progress','passed','failed')" json:"status"`
+	Source          string             `gorm:"type:varchar(16);not null;default:'manual'" json:"source"`
+	InspectorID     *uuid.UUID         `gorm:"type:uuid" json:"inspectorId"`
+	Note            string             `gorm:"type:text" json:"note,omitempty"`
+	StartedAt       time.Time          `gorm:"not null;index:idx_inspection_run_equipment" json:"startedAt"`
+	CompletedAt     *time.Time         `json:"completedAt"`
+	Results         []InspectionResult `gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE" json:"results,omitempty"`
+
+	Equipment Equipment           `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+	Template  *InspectionTemplate `gorm:"foreignKey:TemplateID;constraint:OnDelete:SET NULL" json:"-"`
+	Inspector *User               `gorm:"foreignKey:InspectorID;constraint:OnDelete:SET NULL" json:"inspector,omitempty"`
+}
+
+// InspectionResult is the answer to one checklist item in a run. The item's
+// label, kind and limits are copied from the template so history reads the same
+// after the template changes.
+type InspectionResult struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RunID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_inspection_result_item" json:"runId"`
+	BusinessID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	ItemKey      string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_inspection_result_item" json:"itemKey"`
+	Position     int        `gorm:"not null" json:"position"`
+	Label        string     `gorm:"type:text;not null" json:"label"`
+	Kind         string     `gorm:"type:varchar(16);not null" json:"kind"`
+	Required     bool       `gorm:"not null" json:"required"`
+	Min          *float64   `json:"min,omitempty"`
+	Max          *float64   `json:"max,omitempty"`
+	Unit         string     `gorm:"type:varchar(16)" json:"unit,omitempty"`
+	CreatesIssue bool       `gorm:"not null" json:"createsIssue"`
+	Answered     bool       `gorm:"not null;default:false" json:"answered"`
+	Passed       *bool      `json:"passed"`
+	Value        *float64   `json:"value,omitempty"`
+	Text         string     `gorm:"type:text" json:"text,omitempty"`
+	PhotoKey     string     `gorm:"type:text" json:"photoKey,omitempty"`
+	Note         string     `gorm:"type:text" json:"note,omitempty"`
+	Failed       bool       `gorm:"not null;default:false" json:"failed"`
+	IssueID      *uuid.UUID `gorm:"type:uuid" json:"issueId,omitempty"`
+	AnsweredAt   *time.Time `json:"answeredAt,omitempty"`
+
+	Issue *Issue `gorm:"foreignKey:IssueID;constraint:OnDelete:SET NULL" json:"-"`
+}
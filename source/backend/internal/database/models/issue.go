@@ -6,6 +6,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// Issue progress values, as shown and set by the frontend.
+const (
+	IssueProgressNew        = "new"
+	IssueProgressInProgress = "in progress"
+	IssueProgressResolved   = "resolved"
+)
+
 type Issue struct {
 	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	Title         string     `gorm:"type:varchar(128);not null" json:"title"`
@@ -13,8 +20,11 @@ type Issue struct {
 	Description   string     `gorm:"type:text;not null" json:"description"`
 	Progress      string     `gorm:"type:text;not null" json:"progress"`
 	AssigneeID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"assignee_id"`
+	TeamID        *uuid.UUID `gorm:"type:uuid;index" json:"team_id,omitempty"`
+	ClaimedAt     *time.Time `gorm:"default:null" json:"claimed_at,omitempty"`
 	DateSubmitted time.Time  `gorm:"not null" json:"date_submitted"`
 	DateCompleted *time.Time `gorm:"default:null" json:"date_completed,omitempty"`
-	Equipment     Equipment  `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"equipment"`
+	Equipment     Equipment  `gorm:"foreignKey:EquipmentID;constraint:OnDelete:RESTRICT" json:"equipment"`
 	Assignee      User       `gorm:"foreignKey:AssigneeID;constraint:OnDelete:RESTRICT" json:"assignee"`
+	Team          *Team      `gorm:"foreignKey:TeamID;constraint:OnDelete:SET NULL" json:"team,omitempty"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Location is a node in a business's site → building → area tree.
+// Nodes may be nested to any depth; Kind is a free label for display.
+type Location struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	ParentID   *uuid.UUID `gorm:"type:uuid;index" json:"parentId"`
+	Name       string     `gorm:"type:varchar(128);not null" json:"name"`
+	Kind       string     `gorm:"type:varchar(32);not null;default:'area'" json:"kind"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	Business   Business   `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+	Parent     *Location  `gorm:"foreignKey:ParentID;constraint:OnDelete:RESTRICT" json:"-"`
+}
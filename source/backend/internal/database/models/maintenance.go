@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// What makes a maintenance plan come due.
+const (
+	MaintenanceTriggerCalendar = "calendar"
+	MaintenanceTriggerMeter    = "meter"
+)
+
+// States of a maintenance occurrence.
+const (
+	MaintenanceOpen      = "open"
+	MaintenanceCompleted = "completed"
+	MaintenanceSkipped   = "skipped"
+)
+
+// Compliance outcomes of a closed maintenance occurrence.
+const (
+	MaintenanceOutcomeOnTime  = "on time"
+	MaintenanceOutcomeLate    = "late"
+	MaintenanceOutcomeSkipped = "skipped"
+)
+
+// MaintenancePlan is recurring preventive maintenance for one piece of equipment
+// or for every piece of an equipment type. A calendar plan comes due every
+// IntervalDays; a meter plan every MeterInterval units on the meter called
+// MeterName. Work is opened LeadDays (or LeadUsage units) before it is due.
+type MaintenancePlan struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentID     *uuid.UUID `gorm:"type:uuid;index" json:"equipmentId"`
+	EquipmentTypeID *uuid.UUID `gorm:"type:uuid;index" json:"equipmentTypeId"`
+	Name            string     `gorm:"type:varchar(128);not null" json:"name"`
+	Description     string     `gorm:"type:text" json:"description"`
+	Trigger         string     `gorm:"type:text;not null;check:chk_maintenance_plan_trigger,trigger IN ('calendar','meter')" json:"trigger"`
+
+	IntervalDays int        `gorm:"not null;default:0" json:"intervalDays"`
+	FirstDueAt   *time.Time `json:"firstDueAt"`
+	LeadDays     int        `gorm:"not null;default:0" json:"leadDays"`
+	GraceDays    int        `gorm:"not null;default:0" json:"graceDays"`
+
+	MeterName     string  `gorm:"type:varchar(64)" json:"meterName"`
+	MeterInterval float64 `gorm:"not null;default:0" json:"meterInterval"`
+	LeadUsage     float64 `gorm:"not null;default:0" json:"leadUsage"`
+	GraceUsage    float64 `gorm:"not null;default:0" json:"graceUsage"`
+
+	// FixedSchedule keeps due dates on the original cadence; otherwise the next
+	// due date counts from when the work was actually done.
+	FixedSchedule bool       `gorm:"not null;default:false" json:"fixedSchedule"`
+	Active        bool       `gorm:"not null;default:true" json:"active"`
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"createdBy,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Business      Business       `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+	Equipment     *Equipment     `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+	EquipmentType *EquipmentType `gorm:"foreignKey:EquipmentTypeID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// MaintenanceSchedule tracks when a plan is next due on one piece of equipment.
+// Type-wide plans have one schedule per piece of equipment of the type.
+type MaintenanceSchedule struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	PlanID             uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_maintenance_schedule" json:"planId"`
+	EquipmentID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_maintenance_schedule;index" json:"equipmentId"`
+	NextDueAt          *time.Time `json:"nextDueAt"`
+	NextDueMeter       *float64   `json:"nextDueMeter"`
+	LastCompletedAt    *time.Time `json:"lastCompletedAt"`
+	LastCompletedMeter *float64   `json:"lastCompletedMeter"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Plan      MaintenancePlan `gorm:"foreignKey:PlanID;constraint:OnDelete:CASCADE" json:"plan"`
+	Equipment Equipment       `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// MaintenanceOccurrence is one round of planned work, opened as an issue ahead
+// of its due date and closed as completed or skipped.
+type MaintenanceOccurrence struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	PlanID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"planId"`
+	ScheduleID     uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_open_maintenance,where:status = 'open'" json:"scheduleId"`
+	EquipmentID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"equipmentId"`
+	IssueID        *uuid.UUID `gorm:"type:uuid;index" json:"issueId"`
+	DueAt          *time.Time `gorm:"index" json:"dueAt"`
+	DueMeter       *float64   `json:"dueMeter"`
+	Status         string     `gorm:"type:text;not null;default:'open';check:chk_maintenance_status,status IN ('open','completed','skipped')" json:"status"`
+	Outcome        string     `gorm:"type:text;check:chk_maintenance_outcome,outcome = '' OR outcome IN ('on time','late','skipped')" json:"outcome,omitempty"`
+	ClosedAt       *time.Time `json:"closedAt,omitempty"`
+	ClosedBy       *uuid.UUID `gorm:"type:uuid" json:"closedBy,omitempty"`
+	CompletedMeter *float64   `json:"completedMeter,omitempty"`
+	Note           string     `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+
+	Plan     MaintenancePlan     `gorm:"foreignKey:PlanID;constraint:OnDelete:CASCADE" json:"plan"`
+	Schedule MaintenanceSchedule `gorm:"foreignKey:ScheduleID;constraint:OnDelete:CASCADE" json:"-"`
+	Issue    *Issue              `gorm:"foreignKey:IssueID;constraint:OnDelete:SET NULL" json:"-"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of stock movement.
+const (
+	PartMovementReceipt     = "receipt"
+	PartMovementAdjustment  = "adjustment"
+	PartMovementConsumption = "consumption"
+)
+
+// Part is an entry in a business's spare parts catalogue.
+type Part struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_part_number" json:"businessId"`
+	PartNumber  string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_part_number" json:"partNumber"`
+	Name        string     `gorm:"type:varchar(128);not null" json:"name"`
+	Description string     `gorm:"type:text" json:"description"`
+	Unit        string     `gorm:"type:varchar(16);not null;default:'each'" json:"unit"`
+	UnitCost    *float64   `gorm:"type:numeric(14,2)" json:"unitCost"`
+	VendorID    *uuid.UUID `gorm:"type:uuid;index" json:"vendorId"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Business Business `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+	Vendor   *Vendor  `gorm:"foreignKey:VendorID;constraint:OnDelete:SET NULL" json:"vendor,omitempty"`
+}
+
+// PartStock is how much of a part is on hand at a location. Stock is low once
+// Quantity falls below MinQuantity; a MinQuantity of zero disables the check.
+type PartStock struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID `gorm:"type:uuid;not null;index" json:"businessId"`
+	PartID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_part_stock" json:"partId"`
+	LocationID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_part_stock;index" json:"locationId"`
+	Quantity    float64   `gorm:"type:numeric(14,3);not null;default:0;check:chk_part_stock_quantity,quantity >= 0" json:"quantity"`
+	MinQuantity float64   `gorm:"type:numeric(14,3);not null;default:0;check:chk_part_stock_min,min_quantity >= 0" json:"minQuantity"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Part     *Part     `gorm:"foreignKey:PartID;constraint:OnDelete:CASCADE" json:"part,omitempty"`
+	Location *Location `gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE" json:"location,omitempty"`
+}
+
+// PartFitment records that a part fits a piece of equipment, and how many of
+// it the equipment takes.
+type PartFitment struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID `gorm:"type:uuid;not null;index" json:"businessId"`
+	PartID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_part_fitment" json:"partId"`
+	EquipmentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_part_fitment;index" json:"equipmentId"`
+	Quantity    float64   `gorm:"not null;default:1" json:"quantity"`
+	Note        string    `gorm:"type:text" json:"note"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+
+	Part      *Part      `gorm:"foreignKey:PartID;constraint:OnDelete:CASCADE" json:"part,omitempty"`
+	Equipment *Equipment `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// PartMovement is one change to a stock level: a delivery, a correction after a
+// count, or parts used on an issue. Quantity is signed; consumption is negative.
+// Movements are history, so parts and locations that have them cannot be deleted.
+type PartMovement struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	PartID      uuid.UUID  `gorm:"type:uuid;not null;index:idx_part_movement_time" json:"partId"`
+	LocationID  uuid.UUID  `gorm:"type:uuid;not null" json:"locationId"`
+	Kind        string     `gorm:"type:varchar(16);not null;check:chk_part_movement_kind,kind IN ('receipt','adjustment','consumption')" json:"kind"`
+	Quantity    float64    `gorm:"type:numeric(14,3);not null" json:"quantity"`
+	IssueID     *uuid.UUID `gorm:"type:uuid;index" json:"issueId,omitempty"`
+	EquipmentID *uuid.UUID `gorm:"type:uuid;index" json:"equipmentId,omitempty"`
+	ActorID     *uuid.UUID `gorm:"type:uuid" json:"actorId,omitempty"`
+	Note        string     `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index:idx_part_movement_time" json:"createdAt"`
+
+	Part     *Part     `gorm:"foreignKey:PartID;constraint:OnDelete:RESTRICT" json:"part,omitempty"`
+	Location *Location `gorm:"foreignKey:LocationID;constraint:OnDelete:RESTRICT" json:"location,omitempty"`
+	Issue    *Issue    `gorm:"foreignKey:IssueID;constraint:OnDelete:SET NULL" json:"-"`
+	Actor    *User     `gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL" json:"actor,omitempty"`
+}
@@ -6,9 +6,36 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	JoinStatusPending  = "pending"
+	JoinStatusApproved = "approved"
+	JoinStatusDenied   = "denied"
+	JoinStatusExpired  = "expired"
+)
+
 type PendingJoinRequest struct {
-	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	UserID     uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	BusinessID uuid.UUID `gorm:"type:uuid;not null" json:"business_id"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	BusinessID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"business_id"`
+	Status         string     `gorm:"type:text;not null;default:'pending';index;check:status IN ('pending','approved','denied','expired')" json:"status"`
+	Message        string     `gorm:"type:text" json:"message,omitempty"`
+	DecisionReason string     `gorm:"type:text" json:"decision_reason,omitempty"`
+	DecidedBy      *uuid.UUID `gorm:"type:uuid" json:"decided_by,omitempty"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	User           User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user"`
+	Business       Business   `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// JoinRequestEvent records every status change of a join request so decisions
+// are kept instead of being deleted.
+type JoinRequestEvent struct {
+	ID        uuid.UUID          `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	RequestID uuid.UUID          `gorm:"type:uuid;not null;index" json:"request_id"`
+	Status    string             `gorm:"type:text;not null" json:"status"`
+	ActorID   *uuid.UUID         `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Reason    string             `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	Request   PendingJoinRequest `gorm:"foreignKey:RequestID;constraint:OnDelete:CASCADE" json:"-"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EquipmentPhoto is a picture of equipment. Uploads are re-encoded as JPEG, so
+// the stored variants are upright and carry no EXIF metadata. The full-size and
+// web-size variants live in object storage under the business's key prefix;
+// the thumbnail is kept in the row so equipment lists can return it inline.
+// Each piece of equipment has at most one primary photo.
+type EquipmentPhoto struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentID uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_primary_photo,where:is_primary" json:"equipmentId"`
+	IsPrimary   bool       `gorm:"not null;default:false" json:"isPrimary"`
+	Caption     string     `gorm:"type:varchar(256)" json:"caption"`
+	FullKey     string     `gorm:"type:text;not null" json:"-"`
+	WebKey      string     `gorm:"type:text;not null" json:"-"`
+	Thumbnail   []byte     `gorm:"type:bytea;not null" json:"-"`
+	Width       int        `gorm:"not null" json:"width"`
+	Height      int        `gorm:"not null" json:"height"`
+	UploadedBy  *uuid.UUID `gorm:"type:uuid" json:"uploadedBy,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+
+	Equipment *Equipment `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+}
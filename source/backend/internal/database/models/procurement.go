@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of vendor.
+const (
+	VendorKindManufacturer = "manufacturer"
+	VendorKindSupplier     = "supplier"
+	VendorKindService      = "service"
+	VendorKindOther        = "other"
+)
+
+// Vendor is a company a business buys equipment from, whose equipment it runs,
+// or that services it.
+type Vendor struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID `gorm:"type:uuid;not null;index" json:"businessId"`
+	Name        string    `gorm:"type:varchar(128);not null" json:"name"`
+	Kind        string    `gorm:"type:varchar(16);not null;check:chk_vendor_kind,kind IN ('manufacturer','supplier','service','other')" json:"kind"`
+	ContactName string    `gorm:"type:varchar(128)" json:"contactName"`
+	Email       string    `gorm:"type:varchar(255)" json:"email"`
+	Phone       string    `gorm:"type:varchar(32)" json:"phone"`
+	Website     string    `gorm:"type:varchar(255)" json:"website"`
+	Notes       string    `gorm:"type:text" json:"notes"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Business Business `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// EquipmentProcurement records how a piece of equipment was bought and what
+// warranty came with it. Equipment has at most one record.
+type EquipmentProcurement struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	EquipmentID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"equipmentId"`
+	ManufacturerID *uuid.UUID `gorm:"type:uuid;index" json:"manufacturerId"`
+	SupplierID     *uuid.UUID `gorm:"type:uuid;index" json:"supplierId"`
+	PurchaseOrder  string     `gorm:"type:varchar(64)" json:"purchaseOrder"`
+	PurchaseDate   *time.Time `gorm:"type:date" json:"purchaseDate"`
+	PurchaseCost   *float64   `gorm:"type:numeric(14,2)" json:"purchaseCost"`
+	Currency       string     `gorm:"type:varchar(3)" json:"currency"`
+	WarrantyStart  *time.Time `gorm:"type:date" json:"warrantyStart"`
+	WarrantyEnd    *time.Time `gorm:"type:date;index" json:"warrantyEnd"`
+	WarrantyTerms  string     `gorm:"type:text" json:"warrantyTerms"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Equipment    *Equipment `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"equipment,omitempty"`
+	Manufacturer *Vendor    `gorm:"foreignKey:ManufacturerID;constraint:OnDelete:SET NULL" json:"manufacturer,omitempty"`
+	Supplier     *Vendor    `gorm:"foreignKey:SupplierID;constraint:OnDelete:SET NULL" json:"supplier,omitempty"`
+}
+
+// ServiceContract is a maintenance or support agreement with a vendor that
+// covers one or more pieces of equipment between StartsOn and EndsOn.
+type ServiceContract struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID   uuid.UUID `gorm:"type:uuid;not null;index" json:"businessId"`
+	VendorID     uuid.UUID `gorm:"type:uuid;not null;index" json:"vendorId"`
+	Title        string    `gorm:"type:varchar(128);not null" json:"title"`
+	Reference    string    `gorm:"type:varchar(64)" json:"reference"`
+	StartsOn     time.Time `gorm:"type:date;not null" json:"startsOn"`
+	EndsOn       time.Time `gorm:"type:date;not null;index" json:"endsOn"`
+	Terms        string    `gorm:"type:text" json:"terms"`
+	ResponseTime string    `gorm:"type:varchar(64)" json:"responseTime"`
+	Cost         *float64  `gorm:"type:numeric(14,2)" json:"cost"`
+	Currency     string    `gorm:"type:varchar(3)" json:"currency"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Business Business `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+	Vendor   *Vendor  `gorm:"foreignKey:VendorID;constraint:OnDelete:RESTRICT" json:"vendor,omitempty"`
+}
+
+// ServiceContractEquipment links a service contract to equipment it covers.
+type ServiceContractEquipment struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID `gorm:"type:uuid;not null;index" json:"businessId"`
+	ContractID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_contract_equipment" json:"contractId"`
+	EquipmentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_contract_equipment;index" json:"equipmentId"`
+
+	Contract  ServiceContract `gorm:"foreignKey:ContractID;constraint:OnDelete:CASCADE" json:"-"`
+	Equipment Equipment       `gorm:"foreignKey:EquipmentID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SCIMToken is a business-scoped bearer token used by an identity provider to
+// provision users and groups. Only a SHA-256 hash of the token is stored.
+type SCIMToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID uuid.UUID  `gorm:"type:uuid;not null;index" json:"businessId"`
+	Name       string     `gorm:"type:varchar(64);not null" json:"name"`
+	TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	CreatedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"createdBy"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Business   Business   `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
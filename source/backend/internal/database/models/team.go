@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Team groups members of a business (e.g. an electrical or fleet crew) so issues
+// can be routed to and claimed by the right people.
+type Team struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	BusinessID  uuid.UUID `gorm:"type:uuid;not null;index" json:"businessId"`
+	Name        string    `gorm:"type:varchar(64);not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	ExternalID  string    `gorm:"type:varchar(255)" json:"externalId,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	Business    Business  `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type TeamMember struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TeamID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_team_member" json:"teamId"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_team_member;index" json:"userId"`
+	JoinedAt time.Time `gorm:"autoCreateTime" json:"joinedAt"`
+	Team     Team      `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE" json:"-"`
+	User     User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user"`
+}
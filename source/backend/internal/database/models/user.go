@@ -1,17 +1,20 @@
 package models
 
 import (
+	"time"
+
 	"github.com/duo-labs/webauthn/webauthn"
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID          uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Username    string       `gorm:"uniqueIndex;size:64;not null" json:"username"`
-	Email       string       `gorm:"size:100;not null" json:"email"`
-	Password    string       `gorm:"size:256;not null" json:"-"`
-	IsActive    bool         `gorm:"default:true" json:"is_active"`
-	Credentials []Credential `gorm:"foreignKey:UserID"`
+	ID              uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Username        string       `gorm:"uniqueIndex;size:64;not null" json:"username"`
+	Email           string       `gorm:"size:100;not null" json:"email"`
+	Password        string       `gorm:"size:256;not null" json:"-"`
+	IsActive        bool         `gorm:"default:true" json:"is_active"`
+	EmailVerifiedAt *time.Time   `json:"email_verified_at,omitempty"`
+	Credentials     []Credential `gorm:"foreignKey:UserID"`
 }
 
 func (u *User) WebAuthnID() []byte {
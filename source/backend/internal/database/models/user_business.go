@@ -1,12 +1,28 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// UserBusiness is a user's membership of a business. A user has at most one per
+// business; the unique index is created by repositories.MigrateMembershipUniqueness
+// once older duplicates are removed.
 type UserBusiness struct {
 	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
 	BusinessID uuid.UUID `gorm:"type:uuid;not null;index" json:"business_id"`
 	IsAdmin    bool      `gorm:"default:false" json:"is_admin"`
-	User       User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user"`
-	Business   Business  `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"business"`
+
+	// ExternalID is the identifier the business's directory (SCIM) uses for this member.
+	ExternalID string `gorm:"type:varchar(255)" json:"external_id,omitempty"`
+	// UserName is the userName the business's directory knows the member by. It
+	// never renames the account, which other businesses may share.
+	UserName string `gorm:"type:varchar(255)" json:"user_name,omitempty"`
+	// DeactivatedAt is set when the member has been deprovisioned; the row is kept for history.
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+
+	User     User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user"`
+	Business Business `gorm:"foreignKey:BusinessID;constraint:OnDelete:CASCADE" json:"business"`
 }
@@ -0,0 +1,361 @@
+package database
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type tenantKey struct{}
+
+type systemKey struct{}
+
+type afterCommitKey struct{}
+
+// tenantTables are protected by the tenant_isolation policy when row-level
+// security is enabled.
+var tenantTables = []string{"business_email_domains", "equipment", "equipment_checkouts", "equipment_documents", "equipment_fields", "equipment_imports", "equipment_meters", "equipment_move_events", "equipment_photos", "equipment_procurements", "equipment_status_events", "equipment_status_transitions", "equipment_types", "inspection_results", "inspection_runs", "inspection_templates", "issues", "join_request_events", "locations", "maintenance_occurrences", "maintenance_plans", "maintenance_schedules", "meter_readings", "part_fitments", "part_movements", "part_stocks", "parts", "pending_join_requests", "scim_tokens", "service_contract_equipment", "service_contracts", "team_members", "teams", "user_businesses", "vendors"}
+
+// Conn returns the handle queries for ctx should use: the request's tenant-scoped
+// transaction when one was opened by WithTenantScope, the System pool for a
+// context from SystemContext, otherwise the shared pool. With row-level security
+// enabled the shared pool sees no tenant rows.
+func Conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(tenantKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	if ctx.Value(systemKey{}) != nil {
+		return System.WithContext(ctx)
+	}
+	return DB.WithContext(ctx)
+}
+
+// Transaction runs fn in a transaction, nested in the one ctx already carries if
+// any, and passes fn a context whose Conn is that transaction. Repository calls
+// made with it commit or roll back together.
+func Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, tenantKey{}, tx))
+	})
+}
+
+// SystemContext marks ctx for work that is not done for a tenant: start-up
+// migrations, background jobs and sign-in. Queries made through Conn on it
+// bypass the row-level security policies.
+func SystemContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+// BypassTenantScope lifts the row-level security scope opened by WithTenantScope
+// for the rest of the transaction, for platform admin requests. It is a no-op
+// when ctx carries no tenant scope.
+func BypassTenantScope(ctx context.Context) error {
+	tx, ok := ctx.Value(tenantKey{}).(*gorm.DB)
+	if !ok {
+		return nil
+	}
+	return tx.Exec("SELECT set_config('app.bypass_rls', 'on', true)").Error
+}
+
+// WithTenantScope runs fn inside a transaction whose app.user_id setting is the
+// given user. Queries made through Conn on the context passed to fn are then
+// subject to the row-level security policies. A uuid.Nil user leaves the setting
+// empty, e.g. for SCIM clients that act for a business rather than a user.
+func WithTenantScope(ctx context.Context, userID uuid.UUID, fn func(ctx context.Context) error) error {
+	var committed []func()
+	ctx = context.WithValue(ctx, afterCommitKey{}, &committed)
+
+	err := DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('app.user_id', ?, true)", tenantSetting(userID)).Error; err != nil {
+			return err
+		}
+		return fn(context.WithValue(ctx, tenantKey{}, tx))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range committed {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction opened by WithTenantScope has been
+// committed, or straight away when ctx carries none. It is meant for side effects
+// outside the database, such as removing stored files, that must not happen for
+// a request that is rolled back.
+func AfterCommit(ctx context.Context, fn func()) {
+	if committed, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		*committed = append(*committed, fn)
+		return
+	}
+	fn()
+}
+
+// SetTenantBusiness narrows the scope opened by WithTenantScope to a single business.
+// It is a no-op when ctx carries no tenant scope.
+func SetTenantBusiness(ctx context.Context, businessID uuid.UUID) error {
+	tx, ok := ctx.Value(tenantKey{}).(*gorm.DB)
+	if !ok {
+		return nil
+	}
+	return tx.Exec("SELECT set_config('app.business_id', ?, true)", tenantSetting(businessID)).Error
+}
+
+func tenantSetting(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
+
+// rowLevelSecuritySQL installs the helper functions and policies. Visibility is:
+//   - app.bypass_rls on: everything, for the System pool and platform admins;
+//   - app.business_id set: only rows of that business (plus the user's own
+//     memberships and join requests);
+//   - only app.user_id set: rows of businesses the user is an active member of;
+//   - none set: nothing.
+const rowLevelSecuritySQL = `
+CREATE OR REPLACE FUNCTION app_current_business() RETURNS uuid LANGUAGE sql STABLE AS $$
+	SELECT NULLIF(current_setting('app.business_id', true), '')::uuid
+$$;
+
+CREATE OR REPLACE FUNCTION app_current_user() RETURNS uuid LANGUAGE sql STABLE AS $$
+	SELECT NULLIF(current_setting('app.user_id', true), '')::uuid
+$$;
+
+CREATE OR REPLACE FUNCTION app_rls_bypass() RETURNS boolean LANGUAGE sql STABLE AS $$
+	SELECT COALESCE(current_setting('app.bypass_rls', true), '') = 'on'
+$$;
+
+CREATE OR REPLACE FUNCTION app_tenant_visible(row_business uuid) RETURNS boolean LANGUAGE sql STABLE AS $$
+	SELECT CASE
+		WHEN app_rls_bypass() THEN true
+		WHEN app_current_business() IS NOT NULL THEN row_business = app_current_business()
+		WHEN app_current_user() IS NOT NULL THEN row_business IN (
+			SELECT business_id FROM user_businesses
+			WHERE user_id = app_current_user() AND deactivated_at IS NULL
+		)
+		ELSE false
+	END
+$$;
+
+DROP POLICY IF EXISTS tenant_isolation ON business_email_domains;
+CREATE POLICY tenant_isolation ON business_email_domains
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment;
+CREATE POLICY tenant_isolation ON equipment
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_checkouts;
+CREATE POLICY tenant_isolation ON equipment_checkouts
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_documents;
+CREATE POLICY tenant_isolation ON equipment_documents
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_fields;
+CREATE POLICY tenant_isolation ON equipment_fields
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_imports;
+CREATE POLICY tenant_isolation ON equipment_imports
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_meters;
+CREATE POLICY tenant_isolation ON equipment_meters
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_move_events;
+CREATE POLICY tenant_isolation ON equipment_move_events
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_photos;
+CREATE POLICY tenant_isolation ON equipment_photos
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_procurements;
+CREATE POLICY tenant_isolation ON equipment_procurements
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_status_events;
+CREATE POLICY tenant_isolation ON equipment_status_events
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_status_transitions;
+CREATE POLICY tenant_isolation ON equipment_status_transitions
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON equipment_types;
+CREATE POLICY tenant_isolation ON equipment_types
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON inspection_results;
+CREATE POLICY tenant_isolation ON inspection_results
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON inspection_runs;
+CREATE POLICY tenant_isolation ON inspection_runs
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON inspection_templates;
+CREATE POLICY tenant_isolation ON inspection_templates
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON issues;
+CREATE POLICY tenant_isolation ON issues
+	USING (app_tenant_visible((SELECT e.business_id FROM equipment e WHERE e.id = issues.equipment_id)))
+	WITH CHECK (app_tenant_visible((SELECT e.business_id FROM equipment e WHERE e.id = issues.equipment_id)));
+
+-- Join request events are visible wherever their request is.
+DROP POLICY IF EXISTS tenant_isolation ON join_request_events;
+CREATE POLICY tenant_isolation ON join_request_events
+	USING (EXISTS (SELECT 1 FROM pending_join_requests r WHERE r.id = join_request_events.request_id))
+	WITH CHECK (EXISTS (SELECT 1 FROM pending_join_requests r WHERE r.id = join_request_events.request_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON locations;
+CREATE POLICY tenant_isolation ON locations
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON maintenance_occurrences;
+CREATE POLICY tenant_isolation ON maintenance_occurrences
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON maintenance_plans;
+CREATE POLICY tenant_isolation ON maintenance_plans
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON maintenance_schedules;
+CREATE POLICY tenant_isolation ON maintenance_schedules
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON meter_readings;
+CREATE POLICY tenant_isolation ON meter_readings
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON part_fitments;
+CREATE POLICY tenant_isolation ON part_fitments
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON part_movements;
+CREATE POLICY tenant_isolation ON part_movements
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON part_stocks;
+CREATE POLICY tenant_isolation ON part_stocks
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON parts;
+CREATE POLICY tenant_isolation ON parts
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON pending_join_requests;
+CREATE POLICY tenant_isolation ON pending_join_requests
+	USING (user_id = app_current_user() OR app_tenant_visible(business_id))
+	WITH CHECK (user_id = app_current_user() OR app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON scim_tokens;
+CREATE POLICY tenant_isolation ON scim_tokens
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON service_contract_equipment;
+CREATE POLICY tenant_isolation ON service_contract_equipment
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON service_contracts;
+CREATE POLICY tenant_isolation ON service_contracts
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+DROP POLICY IF EXISTS tenant_isolation ON team_members;
+CREATE POLICY tenant_isolation ON team_members
+	USING (app_tenant_visible((SELECT t.business_id FROM teams t WHERE t.id = team_members.team_id)))
+	WITH CHECK (app_tenant_visible((SELECT t.business_id FROM teams t WHERE t.id = team_members.team_id)));
+
+DROP POLICY IF EXISTS tenant_isolation ON teams;
+CREATE POLICY tenant_isolation ON teams
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+
+-- Memberships cannot use app_tenant_visible, which reads this table itself.
+DROP POLICY IF EXISTS tenant_isolation ON user_businesses;
+CREATE POLICY tenant_isolation ON user_businesses
+	USING (
+		app_rls_bypass()
+		OR user_id = app_current_user()
+		OR business_id = app_current_business()
+	)
+	WITH CHECK (
+		app_rls_bypass()
+		OR user_id = app_current_user()
+		OR business_id = app_current_business()
+	);
+
+DROP POLICY IF EXISTS tenant_isolation ON vendors;
+CREATE POLICY tenant_isolation ON vendors
+	USING (app_tenant_visible(business_id))
+	WITH CHECK (app_tenant_visible(business_id));
+`
+
+// ApplyRowLevelSecurity installs the tenant isolation policies and enables or
+// disables enforcement on the tenant tables. FORCE is used so the policies also
+// apply when the application connects as the tables' owner.
+func ApplyRowLevelSecurity(enabled bool) error {
+	if enabled {
+		if err := DB.Exec(rowLevelSecuritySQL).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, table := range tenantTables {
+		statement := "ALTER TABLE " + table + " ENABLE ROW LEVEL SECURITY, FORCE ROW LEVEL SECURITY"
+		if !enabled {
+			statement = "ALTER TABLE " + table + " DISABLE ROW LEVEL SECURITY, NO FORCE ROW LEVEL SECURITY"
+		}
+		if err := DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	if enabled {
+		var bypass bool
+		DB.Raw("SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass)
+		if bypass {
+			log.Println("⚠️  Row-level security is enabled but the database user bypasses it (superuser or BYPASSRLS); connect as a regular role for it to take effect")
+		}
+	}
+
+	return nil
+}
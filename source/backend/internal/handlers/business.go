@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/middleware"
@@ -13,15 +14,25 @@ import (
 
 func RegisterBusinessRoutes(app *fiber.App) {
 	app.Get("/api/business/:id", middleware.RequireUser, getBusinessByID)
-	app.Get("/api/businesses", middleware.RequireUser, listBusinessesPaginated)
+	app.Get("/api/businesses", middleware.RequireUser, searchBusinessDirectory)
+	app.Patch("/api/business/:id/directory", middleware.RequireUser, utils.ValidateBody[utils.UpdateDirectoryListingRequest](), updateDirectoryListing)
 	app.Post("/api/business", middleware.RequireUser, utils.ValidateBody[utils.CreateBusinessRequest](), createBusiness)
+	app.Delete("/api/business/:id", middleware.RequireUser, utils.ValidateBody[utils.DeleteBusinessRequest](), deleteBusiness)
+	app.Post("/api/business/:id/restore", middleware.RequireUser, restoreBusiness)
+
+	admin := app.Group("/api/admin/businesses", middleware.RequireUser, middleware.RequirePlatformAdmin)
+	admin.Get("/", listBusinessesForAdmin)
+	admin.Post("/:id/suspend", utils.ValidateBody[utils.SuspendBusinessRequest](), suspendBusiness)
+	admin.Post("/:id/unsuspend", unsuspendBusiness)
+	admin.Delete("/:id", adminDeleteBusiness)
+	admin.Post("/:id/restore", adminRestoreBusiness)
 }
 
 func getBusinessByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	business, err := repositories.GetBusinessByID(id)
-	if err != nil {
+	business, err := repositories.GetBusinessByID(c.UserContext(), id)
+	if err != nil || business.Status == models.BusinessStatusDeleted {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "business not found",
 		})
@@ -40,11 +51,15 @@ func getBusinessByID(c *fiber.Ctx) error {
 		"businessType":    business.Type,
 		"userCanRegister": business.UserCanRegister,
 		"loginMethods":    business.LoginMethods,
+		"status":          business.Status,
 		"memberCount":     memberCount,
 	})
 }
 
-func listBusinessesPaginated(c *fiber.Ctx) error {
+// maxDirectoryPageSize caps how many businesses a single directory page can return.
+const maxDirectoryPageSize = 50
+
+func searchBusinessDirectory(c *fiber.Ctx) error {
 	pageParam := c.Query("page", "1")
 	limitParam := c.Query("limit", "10")
 
@@ -61,17 +76,57 @@ func listBusinessesPaginated(c *fiber.Ctx) error {
 			"error": "invalid limit number",
 		})
 	}
+	if limit > maxDirectoryPageSize {
+		limit = maxDirectoryPageSize
+	}
 
-	offset := (page - 1) * limit
+	filter := repositories.BusinessDirectoryFilter{
+		Query:   strings.TrimSpace(c.Query("q")),
+		Type:    c.Query("type"),
+		Country: strings.TrimSpace(c.Query("country")),
+		Limit:   limit,
+		Offset:  (page - 1) * limit,
+	}
 
-	businesses, err := repositories.GetBusinessesPaginated(limit, offset)
+	businesses, total, err := repositories.SearchBusinessDirectory(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to fetch businesses",
 		})
 	}
 
-	return c.JSON(businesses)
+	return c.JSON(fiber.Map{
+		"businesses": businesses,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+	})
+}
+
+func updateDirectoryListing(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateDirectoryListingRequest)
+
+	businessID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid business ID",
+		})
+	}
+
+	if err := ensureBusinessAdmin(c, businessID); err != nil {
+		return middleware.RespondFiberError(c, err)
+	}
+
+	if err := repositories.SetBusinessDirectoryListing(c.UserContext(), businessID, *req.Listed); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update directory listing",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"id":              businessID,
+		"listInDirectory": *req.Listed,
+	})
 }
 
 func createBusiness(c *fiber.Ctx) error {
@@ -82,7 +137,7 @@ func createBusiness(c *fiber.Ctx) error {
 		BusinessName: req.BusinessName,
 	}
 
-	if err := repositories.CreateBusiness(&business); err != nil {
+	if err := repositories.CreateBusiness(c.UserContext(), &business); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "could not create business",
 		})
@@ -90,3 +145,57 @@ func createBusiness(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusCreated).JSON(business)
 }
+
+// businessIDForRequest picks the business a list or create request applies to.
+// An explicitly requested business must be one the user belongs to; otherwise
+// the active business resolved by middleware.ResolveBusiness is used.
+func businessIDForRequest(c *fiber.Ctx, requested string) (uuid.UUID, error) {
+	if requested == "" {
+		businessID, ok := middleware.ActiveBusinessID(c)
+		if !ok {
+			return uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "no active business selected")
+		}
+		return businessID, nil
+	}
+
+	businessID, err := uuid.Parse(requested)
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "invalid business ID")
+	}
+
+	if active, ok := middleware.ActiveBusinessID(c); ok && active == businessID {
+		return businessID, nil
+	}
+
+	user := c.Locals("user").(*models.User)
+	if _, err := repositories.GetUserBusinessMembership(c.UserContext(), user.ID, businessID); err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusForbidden, "not a member of this business")
+	}
+
+	if err := middleware.CheckBusinessStatus(c, businessID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := middleware.ScopeBusiness(c, businessID); err != nil {
+		return uuid.Nil, err
+	}
+
+	return businessID, nil
+}
+
+// ensureBusinessAdmin checks that the current user is an admin of the given
+// business and scopes the rest of the request to it.
+func ensureBusinessAdmin(c *fiber.Ctx, businessID uuid.UUID) error {
+	user := c.Locals("user").(*models.User)
+
+	membership, err := repositories.GetUserBusinessMembership(c.UserContext(), user.ID, businessID)
+	if err != nil || !membership.IsAdmin {
+		return fiber.NewError(fiber.StatusForbidden, "business admin access required")
+	}
+
+	if err := middleware.CheckBusinessStatus(c, businessID); err != nil {
+		return err
+	}
+
+	return middleware.ScopeBusiness(c, businessID)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// deleteBusiness lets a business admin schedule their business for deletion.
+// The business disappears for every member immediately but is only purged
+// after the configured grace period.
+func deleteBusiness(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.DeleteBusinessRequest)
+	user := c.Locals("user").(*models.User)
+
+	business, err := lifecycleBusinessForAdmin(c)
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "failed to fetch business")
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(req.ConfirmName), business.BusinessName) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "confirmation does not match the business name",
+		})
+	}
+
+	business, err = repositories.SoftDeleteBusiness(c.UserContext(), business.ID, &user.ID, deletionGracePeriod())
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "could not delete business")
+	}
+
+	return c.JSON(business)
+}
+
+// restoreBusiness lets an admin of a deleted business bring it back during the grace period.
+func restoreBusiness(c *fiber.Ctx) error {
+	business, err := lifecycleBusinessForAdmin(c)
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "failed to fetch business")
+	}
+
+	business, err = repositories.RestoreBusiness(c.UserContext(), business.ID)
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "could not restore business")
+	}
+
+	return c.JSON(business)
+}
+
+func listBusinessesForAdmin(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "25"))
+	if err != nil || limit < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+	if limit > maxDirectoryPageSize {
+		limit = maxDirectoryPageSize
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.BusinessStatusActive, models.BusinessStatusSuspended, models.BusinessStatusDeleted:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid status",
+		})
+	}
+
+	businesses, total, err := repositories.ListBusinessesForAdmin(c.UserContext(), status, limit, (page-1)*limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch businesses",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"businesses": businesses,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+	})
+}
+
+func suspendBusiness(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.SuspendBusinessRequest)
+
+	businessID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return respondBusinessLifecycleError(c, repositories.ErrBusinessNotFound, "")
+	}
+
+	business, err := repositories.SuspendBusiness(c.UserContext(), businessID, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "could not suspend business")
+	}
+
+	return c.JSON(business)
+}
+
+func unsuspendBusiness(c *fiber.Ctx) error {
+	businessID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return respondBusinessLifecycleError(c, repositories.ErrBusinessNotFound, "")
+	}
+
+	business, err := repositories.UnsuspendBusiness(c.UserContext(), businessID)
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "could not unsuspend business")
+	}
+
+	return c.JSON(business)
+}
+
+func adminDeleteBusiness(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+
+	businessID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return respondBusinessLifecycleError(c, repositories.ErrBusinessNotFound, "")
+	}
+
+	business, err := repositories.SoftDeleteBusiness(c.UserContext(), businessID, &user.ID, deletionGracePeriod())
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "could not delete business")
+	}
+
+	return c.JSON(business)
+}
+
+func adminRestoreBusiness(c *fiber.Ctx) error {
+	businessID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return respondBusinessLifecycleError(c, repositories.ErrBusinessNotFound, "")
+	}
+
+	business, err := repositories.RestoreBusiness(c.UserContext(), businessID)
+	if err != nil {
+		return respondBusinessLifecycleError(c, err, "could not restore business")
+	}
+
+	return c.JSON(business)
+}
+
+// lifecycleBusinessForAdmin loads the business in the route for one of its admins.
+// Unlike ensureBusinessAdmin it does not reject deleted or suspended businesses.
+func lifecycleBusinessForAdmin(c *fiber.Ctx) (*models.Business, error) {
+	user := c.Locals("user").(*models.User)
+
+	businessID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrBusinessNotFound
+	}
+
+	membership, err := repositories.GetUserBusinessMembership(c.UserContext(), user.ID, businessID)
+	if err != nil || !membership.IsAdmin {
+		return nil, fiber.NewError(fiber.StatusForbidden, "business admin access required")
+	}
+
+	return repositories.GetBusinessForLifecycle(c.UserContext(), businessID)
+}
+
+func deletionGracePeriod() time.Duration {
+	return time.Duration(utils.AppConfig.Business_Deletion_Grace_Days) * 24 * time.Hour
+}
+
+func respondBusinessLifecycleError(c *fiber.Ctx, err error, fallback string) error {
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &fiberErr):
+		return middleware.RespondFiberError(c, err)
+	case errors.Is(err, repositories.ErrBusinessNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrBusinessDeleted),
+		errors.Is(err, repositories.ErrBusinessNotDeleted),
+		errors.Is(err, repositories.ErrBusinessNotSuspended):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrBusinessRestoreClosed):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
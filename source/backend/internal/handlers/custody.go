@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterCustodyRoutes(app *fiber.App) {
+	app.Get("/api/custody", middleware.RequireUser, middleware.RequireBusiness, listCheckouts)
+	app.Get("/api/custody/utilisation", middleware.RequireUser, middleware.RequireBusiness, getCustodyUtilisation)
+	app.Get("/api/equipment/:id/custody", middleware.RequireUser, middleware.RequireBusiness, getEquipmentCustody)
+	app.Get("/api/equipment/:id/custody/utilisation", middleware.RequireUser, middleware.RequireBusiness, getEquipmentUtilisation)
+	app.Post("/api/equipment/:id/checkout", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.CheckOutEquipmentRequest](), checkOutEquipment)
+	app.Post("/api/equipment/:id/checkin", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.CheckInEquipmentRequest](), checkInEquipment)
+}
+
+// listCheckouts is the current-custodian view: everything checked out in the
+// business, most overdue first. custodian_id (or "me") and overdue=true narrow
+// the list.
+func listCheckouts(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+	user := c.Locals("user").(*models.User)
+
+	filter := repositories.CheckoutFilter{OverdueOnly: c.QueryBool("overdue")}
+	if c.Query("custodian_id") == "me" {
+		filter.CustodianID = &user.ID
+	} else {
+		custodianID, err := uuidQuery(c, "custodian_id")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		filter.CustodianID = custodianID
+	}
+
+	checkouts, err := repositories.ListCheckouts(c.UserContext(), businessID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch checkouts",
+		})
+	}
+
+	return c.JSON(checkouts)
+}
+
+// getEquipmentCustody returns who has the equipment now, if anyone, and one
+// page of its checkout history.
+func getEquipmentCustody(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+
+	current, err := repositories.GetCurrentCheckout(c.UserContext(), eq.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch custody",
+		})
+	}
+
+	history, total, err := repositories.GetCustodyHistory(c.UserContext(), eq.ID, limit, (page-1)*limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch custody history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"current": current,
+		"overdue": current != nil && current.DueAt != nil && current.DueAt.Before(time.Now()),
+		"history": history,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// checkOutEquipment hands the scanned equipment to the signed-in user, or, for
+// admins, to another member of the business.
+func checkOutEquipment(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CheckOutEquipmentRequest)
+	user := c.Locals("user").(*models.User)
+	membership, _ := c.Locals("membership").(*models.UserBusiness)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	custodianID := user.ID
+	if req.CustodianID != "" {
+		custodianID = uuid.MustParse(req.CustodianID)
+	}
+	if custodianID != user.ID && (membership == nil || !membership.IsAdmin) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only business admins can check equipment out to someone else",
+		})
+	}
+
+	checkout, err := repositories.CheckOutEquipment(c.UserContext(), eq, custodianID, user.ID, req.DueAt, req.Note)
+	if err != nil {
+		return respondCustodyError(c, err, "could not check out equipment")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(checkout)
+}
+
+func checkInEquipment(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CheckInEquipmentRequest)
+	user := c.Locals("user").(*models.User)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	checkout, err := repositories.CheckInEquipment(c.UserContext(), eq, user.ID, req.Condition, req.Note)
+	if err != nil {
+		return respondCustodyError(c, err, "could not check in equipment")
+	}
+
+	return c.JSON(checkout)
+}
+
+// getCustodyUtilisation reports how much each piece of equipment was checked out
+// between from and to, busiest first. It covers the last 30 days by default.
+func getCustodyUtilisation(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	from, to, err := custodyPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	stats, err := repositories.GetCustodyUtilisation(c.UserContext(), businessID, nil, from, to)
+	if err != nil {
+		return respondCustodyError(c, err, "failed to fetch utilisation")
+	}
+
+	return c.JSON(fiber.Map{
+		"from":        from,
+		"to":          to,
+		"utilisation": stats,
+	})
+}
+
+func getEquipmentUtilisation(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	from, to, err := custodyPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	stats, err := repositories.GetCustodyUtilisation(c.UserContext(), eq.BusinessID, &eq.ID, from, to)
+	if err != nil {
+		return respondCustodyError(c, err, "failed to fetch utilisation")
+	}
+
+	utilisation := repositories.CustodyUtilisation{EquipmentID: eq.ID}
+	if len(stats) > 0 {
+		utilisation = stats[0]
+	}
+
+	return c.JSON(fiber.Map{
+		"from":        from,
+		"to":          to,
+		"utilisation": utilisation,
+	})
+}
+
+func custodyPeriod(c *fiber.Ctx) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	if value, err := timeQuery(c, "to"); err != nil {
+		return time.Time{}, time.Time{}, err
+	} else if value != nil {
+		to = *value
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if value, err := timeQuery(c, "from"); err != nil {
+		return time.Time{}, time.Time{}, err
+	} else if value != nil {
+		from = *value
+	}
+	return from, to, nil
+}
+
+func respondCustodyError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrAlreadyCheckedOut),
+		errors.Is(err, repositories.ErrNotCheckedOut),
+		errors.Is(err, repositories.ErrCheckoutUnavailable):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrCustodianNotMember),
+		errors.Is(err, repositories.ErrCheckoutDueInPast),
+		errors.Is(err, repositories.ErrInvalidCustodyPeriod):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/s3"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+func RegisterDocumentRoutes(app *fiber.App) {
+	documents := app.Group("/api/documents", middleware.RequireUser, middleware.RequireBusiness)
+
+	documents.Get("/", listDocuments)
+	documents.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UploadDocumentRequest](), uploadDocument)
+	documents.Get("/:id", getDocument)
+	documents.Get("/:id/file", getDocumentFile)
+	documents.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateDocumentRequest](), updateDocument)
+	documents.Delete("/:id", middleware.RequireBusinessAdmin, deleteDocument)
+
+	app.Get("/api/equipment/:id/documents", middleware.RequireUser, middleware.RequireBusiness, getEquipmentDocuments)
+}
+
+// listDocuments returns the business's documents, filtered by equipment_id,
+// type_id or category.
+func listDocuments(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	filter := repositories.DocumentFilter{Category: c.Query("category")}
+	var err error
+	if filter.EquipmentID, err = uuidQuery(c, "equipment_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.EquipmentTypeID, err = uuidQuery(c, "type_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	documents, err := repositories.ListEquipmentDocuments(c.UserContext(), businessID, filter)
+	if err != nil {
+		return respondDocumentError(c, err, "failed to fetch documents")
+	}
+
+	return c.JSON(documents)
+}
+
+// getEquipmentDocuments returns the documents for scanned equipment: its own and
+// those of its type, manuals first.
+func getEquipmentDocuments(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	documents, err := repositories.GetDocumentsForEquipment(c.UserContext(), eq, c.Query("category"))
+	if err != nil {
+		return respondDocumentError(c, err, "failed to fetch documents")
+	}
+
+	return c.JSON(documents)
+}
+
+// uploadDocument stores the "file" form field in object storage under the
+// business's prefix and records it with its title, category and version.
+func uploadDocument(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UploadDocumentRequest)
+	user := c.Locals("user").(*models.User)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file not provided",
+		})
+	}
+
+	document := models.EquipmentDocument{
+		ID:          uuid.New(),
+		BusinessID:  businessID,
+		Title:       req.Title,
+		Category:    req.Category,
+		Version:     req.Version,
+		FileName:    safeObjectName(fileHeader.Filename),
+		ContentType: fileHeader.Header.Get("Content-Type"),
+		Size:        fileHeader.Size,
+		UploadedBy:  &user.ID,
+	}
+	if document.ContentType == "" {
+		document.ContentType = "application/octet-stream"
+	}
+	if req.EquipmentID != "" {
+		id := uuid.MustParse(req.EquipmentID)
+		document.EquipmentID = &id
+	}
+	if req.EquipmentTypeID != "" {
+		id := uuid.MustParse(req.EquipmentTypeID)
+		document.EquipmentTypeID = &id
+	}
+	document.ObjectKey = s3.BusinessPrefix(businessID) + "documents/" + document.ID.String() + "/" + document.FileName
+
+	if err := repositories.CheckDocumentTarget(c.UserContext(), &document); err != nil {
+		return respondDocumentError(c, err, "could not attach document")
+	}
+
+	if err := putUploadedFile(document.ObjectKey, fileHeader); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "upload failed",
+		})
+	}
+
+	if err := repositories.CreateEquipmentDocument(c.UserContext(), &document); err != nil {
+		removeDocumentFile(&document)
+		return respondDocumentError(c, err, "could not save document")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(document)
+}
+
+func getDocument(c *fiber.Ctx) error {
+	document, err := documentFromParams(c)
+	if err != nil {
+		return respondDocumentError(c, err, "failed to fetch document")
+	}
+
+	return c.JSON(document)
+}
+
+// getDocumentFile streams a document's file, inline unless download=true.
+func getDocumentFile(c *fiber.Ctx) error {
+	document, err := documentFromParams(c)
+	if err != nil {
+		return respondDocumentError(c, err, "failed to fetch document")
+	}
+
+	return sendObject(c, document.ObjectKey, document.FileName, c.QueryBool("download"))
+}
+
+func updateDocument(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateDocumentRequest)
+
+	document, err := documentFromParams(c)
+	if err != nil {
+		return respondDocumentError(c, err, "failed to fetch document")
+	}
+
+	if req.Title != nil {
+		document.Title = *req.Title
+	}
+	if req.Category != nil {
+		document.Category = *req.Category
+	}
+	if req.Version != nil {
+		document.Version = *req.Version
+	}
+
+	if err := repositories.UpdateEquipmentDocument(c.UserContext(), document); err != nil {
+		return respondDocumentError(c, err, "could not update document")
+	}
+
+	return c.JSON(document)
+}
+
+func deleteDocument(c *fiber.Ctx) error {
+	document, err := documentFromParams(c)
+	if err != nil {
+		return respondDocumentError(c, err, "failed to fetch document")
+	}
+
+	if err := repositories.DeleteEquipmentDocument(c.UserContext(), document); err != nil {
+		return respondDocumentError(c, err, "could not delete document")
+	}
+	database.AfterCommit(c.UserContext(), func() { removeDocumentFile(document) })
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// removeDocumentFile deletes a document's stored file. A failure only leaves an
+// orphaned object behind, which is removed with the business, so it is logged.
+func removeDocumentFile(document *models.EquipmentDocument) {
+	err := s3.Client.RemoveObject(context.Background(), config.MinioBucket, document.ObjectKey, minio.RemoveObjectOptions{})
+	if err != nil {
+		log.Printf("document %s: could not remove %s: %v", document.ID, document.ObjectKey, err)
+	}
+}
+
+func documentFromParams(c *fiber.Ctx) (*models.EquipmentDocument, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrDocumentNotFound
+	}
+
+	return repositories.GetEquipmentDocument(c.UserContext(), businessID, id)
+}
+
+func respondDocumentError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrDocumentNotFound),
+		errors.Is(err, repositories.ErrEquipmentTypeNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrDocumentTarget),
+		errors.Is(err, repositories.ErrInvalidDocumentCategory):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
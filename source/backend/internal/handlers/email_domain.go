@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterEmailDomainRoutes(app *fiber.App) {
+	domains := app.Group("/api/business/domains", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin)
+
+	domains.Get("/", listEmailDomains)
+	domains.Post("/", utils.ValidateBody[utils.CreateEmailDomainRequest](), createEmailDomain)
+	domains.Patch("/:id", utils.ValidateBody[utils.UpdateEmailDomainRequest](), updateEmailDomain)
+	domains.Post("/:id/verify", verifyEmailDomain)
+	domains.Delete("/:id", deleteEmailDomain)
+}
+
+func listEmailDomains(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	domains, err := repositories.GetEmailDomainsForBusiness(c.UserContext(), businessID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch email domains",
+		})
+	}
+
+	return c.JSON(domains)
+}
+
+func createEmailDomain(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateEmailDomainRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	domain := models.BusinessEmailDomain{
+		BusinessID:  businessID,
+		Domain:      req.Domain,
+		DefaultRole: req.DefaultRole,
+		Mode:        req.Mode,
+	}
+	if domain.DefaultRole == "" {
+		domain.DefaultRole = models.DomainRoleMember
+	}
+	if domain.Mode == "" {
+		domain.Mode = models.DomainModePendingRequest
+	}
+
+	if err := repositories.CreateEmailDomain(c.UserContext(), &domain); err != nil {
+		return respondEmailDomainError(c, err, "could not add email domain")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(emailDomainResponse(&domain))
+}
+
+func updateEmailDomain(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateEmailDomainRequest)
+
+	domain, err := emailDomainFromParams(c)
+	if err != nil {
+		return respondEmailDomainError(c, err, "failed to fetch email domain")
+	}
+
+	if req.DefaultRole != "" {
+		domain.DefaultRole = req.DefaultRole
+	}
+	if req.Mode != "" {
+		domain.Mode = req.Mode
+	}
+
+	if err := repositories.UpdateEmailDomainRule(c.UserContext(), domain); err != nil {
+		return respondEmailDomainError(c, err, "could not update email domain")
+	}
+
+	return c.JSON(emailDomainResponse(domain))
+}
+
+func verifyEmailDomain(c *fiber.Ctx) error {
+	domain, err := emailDomainFromParams(c)
+	if err != nil {
+		return respondEmailDomainError(c, err, "failed to fetch email domain")
+	}
+
+	if err := repositories.VerifyEmailDomain(c.UserContext(), domain); err != nil {
+		return respondEmailDomainError(c, err, "could not verify email domain")
+	}
+
+	return c.JSON(emailDomainResponse(domain))
+}
+
+func deleteEmailDomain(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	domainID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return respondEmailDomainError(c, repositories.ErrDomainNotFound, "")
+	}
+
+	if err := repositories.DeleteEmailDomain(c.UserContext(), businessID, domainID); err != nil {
+		return respondEmailDomainError(c, err, "could not delete email domain")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func emailDomainFromParams(c *fiber.Ctx) (*models.BusinessEmailDomain, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	domainID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrDomainNotFound
+	}
+
+	return repositories.GetEmailDomainByID(c.UserContext(), businessID, domainID)
+}
+
+func emailDomainResponse(domain *models.BusinessEmailDomain) fiber.Map {
+	recordName, recordValue := repositories.DomainVerificationRecord(domain)
+	return fiber.Map{
+		"domain": domain,
+		"verificationRecord": fiber.Map{
+			"type":  "TXT",
+			"name":  recordName,
+			"value": recordValue,
+		},
+	}
+}
+
+func respondEmailDomainError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrDomainNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrDomainInvalid),
+		errors.Is(err, repositories.ErrDomainPublic):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrDomainTaken),
+		errors.Is(err, repositories.ErrDomainUnverified):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
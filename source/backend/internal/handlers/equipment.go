@@ -1,21 +1,50 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/middleware"
 	"github.com/EquipQR/equipqr/backend/internal/repositories"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
+// maxEquipmentPageSize caps how much equipment a single list page can return.
+const maxEquipmentPageSize = 100
+
 func RegisterEquipmentRoutes(app *fiber.App) {
-	app.Get("/api/equipment/:id/issues", middleware.RequireUser, getEquipmentIssues)
-	app.Get("/api/equipment/:id", middleware.RequireUser, getEquipmentByID)
-	app.Post("/api/equipment", middleware.RequireUser, utils.ValidateBody[utils.CreateEquipmentRequest](), createEquipment)
+	app.Get("/api/equipment", middleware.RequireUser, middleware.RequireBusiness, listEquipment)
+	app.Get("/api/equipment/:id/issues", middleware.RequireUser, middleware.RequireBusiness, getEquipmentIssues)
+	app.Get("/api/equipment/:id", middleware.RequireUser, middleware.RequireBusiness, getEquipmentByID)
+	app.Put("/api/equipment/:id/team", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.SetResponsibleTeamRequest](), setEquipmentResponsibleTeam)
+	app.Post("/api/equipment", middleware.RequireUser, middleware.ResolveBusiness, utils.ValidateBody[utils.CreateEquipmentRequest](), createEquipment)
+	app.Patch("/api/equipment/:id", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.UpdateEquipmentRequest](), updateEquipment)
+	app.Post("/api/equipment/:id/retire", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.RetireEquipmentRequest](), retireEquipment)
+	app.Post("/api/equipment/:id/reinstate", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, reinstateEquipment)
+	app.Delete("/api/equipment/:id", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, deleteEquipment)
 }
 
+// getEquipmentIssues returns the issues of equipment together with those of its
+// components, so a fault on a motor also shows on the press line it belongs to.
+// components=false returns only the equipment's own issues.
 func getEquipmentIssues(c *fiber.Ctx) error {
-	equipmentID := c.Params("id")
-	issues, err := repositories.GetIssuesByEquipmentID(equipmentID)
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	var issues []models.Issue
+	if c.Query("components") == "false" {
+		issues, err = repositories.GetIssuesByEquipmentID(c.UserContext(), eq.ID.String())
+	} else {
+		issues, err = repositories.GetAssemblyIssues(c.UserContext(), eq.BusinessID, eq.ID)
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to fetch issues",
@@ -25,20 +54,29 @@ func getEquipmentIssues(c *fiber.Ctx) error {
 }
 
 func getEquipmentByID(c *fiber.Ctx) error {
-	id := c.Params("id")
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
 
-	eq, err := repositories.GetEquipmentByID(id)
+	items, err := repositories.EquipmentListItems(c.UserContext(), []models.Equipment{*eq})
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "equipment not found",
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch equipment",
 		})
 	}
 
-	return c.JSON(eq)
+	return c.JSON(items[0])
 }
 
 func createEquipment(c *fiber.Ctx) error {
 	req := c.Locals("body").(utils.CreateEquipmentRequest)
+	user := c.Locals("user").(*models.User)
+
+	businessID, err := businessIDForRequest(c, req.BusinessID)
+	if err != nil {
+		return middleware.RespondFiberError(c, err)
+	}
 
 	moreFields, ok := req.MoreFields.(map[string]any)
 	if !ok {
@@ -47,14 +85,61 @@ func createEquipment(c *fiber.Ctx) error {
 		})
 	}
 
-	equipment, err := repositories.CreateEquipmentEntry(
-		req.BusinessID,
-		req.Status,
-		req.Type,
-		req.Location,
-		moreFields,
-	)
+	var locationID *uuid.UUID
+	if req.LocationID != "" {
+		id := uuid.MustParse(req.LocationID)
+		locationID = &id
+	}
 
+	// Check the assembly up front so a bad parent is reported before anything else.
+	var parent *models.Equipment
+	if req.ParentID != "" {
+		parent, err = repositories.GetEquipmentInBusiness(c.UserContext(), businessID, uuid.MustParse(req.ParentID))
+		if errors.Is(err, repositories.ErrEquipmentNotFound) {
+			err = repositories.ErrAssemblyParentNotFound
+		} else if err == nil && parent.RetiredAt != nil {
+			err = repositories.ErrEquipmentRetired
+		}
+		if err != nil {
+			return respondEquipmentError(c, err, "failed to fetch parent equipment")
+		}
+	}
+
+	equipmentType, err := equipmentTypeForRequest(c, businessID, req.TypeID, req.Type)
+	if err != nil {
+		return respondEquipmentTypeError(c, err, "failed to resolve equipment type")
+	}
+
+	// The equipment and its place in the assembly are saved together, so a
+	// failed attach does not leave new equipment behind.
+	var equipment *models.Equipment
+	attached := false
+	err = database.Transaction(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		equipment, err = repositories.CreateEquipmentEntry(
+			ctx,
+			businessID.String(),
+			req.Status,
+			equipmentType,
+			req.Location,
+			locationID,
+			moreFields,
+			&user.ID,
+		)
+		if err != nil || parent == nil {
+			return err
+		}
+		attached = true
+		return repositories.SetEquipmentParent(ctx, equipment, &parent.ID, &user.ID, "created")
+	})
+	if err != nil && attached {
+		return respondEquipmentError(c, err, "could not attach equipment to its assembly")
+	}
+
+	var fieldErr *repositories.FieldValidationError
+	if errors.As(err, &fieldErr) {
+		return respondEquipmentError(c, err, "invalid more_fields")
+	}
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -63,3 +148,337 @@ func createEquipment(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusCreated).JSON(equipment)
 }
+
+// listEquipment returns the active business's equipment. Custom fields are
+// filtered with field.<name>=<value> query parameters.
+func listEquipment(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "25"))
+	if err != nil || limit < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+	if limit > maxEquipmentPageSize {
+		limit = maxEquipmentPageSize
+	}
+
+	filter, err := equipmentFilterFromQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	equipment, total, err := repositories.ListEquipment(c.UserContext(), businessID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch equipment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"equipment": equipment,
+		"page":      page,
+		"limit":     limit,
+		"total":     total,
+	})
+}
+
+// equipmentFilterFromQuery reads the filters shared by the list and export
+// endpoints: status, type, type_id, location_id, parent_id, retired, sort and
+// field.<key>. parent_id=none selects equipment that is not a component.
+func equipmentFilterFromQuery(c *fiber.Ctx) (repositories.EquipmentFilter, error) {
+	filter := repositories.EquipmentFilter{
+		Status: c.Query("status"),
+		Type:   c.Query("type"),
+		Sort:   c.Query("sort"),
+		Fields: map[string]string{},
+	}
+
+	if !repositories.ValidEquipmentSort(filter.Sort) {
+		return filter, errors.New("invalid sort")
+	}
+
+	switch c.Query("retired") {
+	case "", "false":
+		filter.Retired = repositories.RetiredExclude
+	case "true":
+		filter.Retired = repositories.RetiredOnly
+	case "all":
+		filter.Retired = repositories.RetiredInclude
+	default:
+		return filter, errors.New("retired must be true, false or all")
+	}
+
+	if typeParam := c.Query("type_id"); typeParam != "" {
+		typeID, err := uuid.Parse(typeParam)
+		if err != nil {
+			return filter, errors.New("invalid type ID")
+		}
+		filter.TypeID = &typeID
+	}
+
+	if locationParam := c.Query("location_id"); locationParam != "" {
+		locationID, err := uuid.Parse(locationParam)
+		if err != nil {
+			return filter, errors.New("invalid location ID")
+		}
+		filter.LocationID = &locationID
+	}
+
+	switch parentParam := c.Query("parent_id"); parentParam {
+	case "":
+	case "none":
+		filter.TopLevel = true
+	default:
+		parentID, err := uuid.Parse(parentParam)
+		if err != nil {
+			return filter, errors.New("invalid parent ID")
+		}
+		filter.ParentID = &parentID
+	}
+
+	for key, value := range c.Queries() {
+		if name, ok := strings.CutPrefix(key, "field."); ok && name != "" {
+			filter.Fields[name] = value
+		}
+	}
+	return filter, nil
+}
+
+func updateEquipment(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateEquipmentRequest)
+	user := c.Locals("user").(*models.User)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	statusChange := req.Status != nil && *req.Status != eq.Status
+	if statusChange {
+		if err := requireAdminForRetirement(c, eq.Status, *req.Status); err != nil {
+			return middleware.RespondFiberError(c, err)
+		}
+	}
+	attributesChanged := req.Type != nil || req.TypeID != nil || req.Location != nil || req.LocationID != nil || req.MoreFields != nil
+	typeChanged := req.TypeID != nil || req.Type != nil
+	if typeChanged {
+		typeID, typeName := "", ""
+		if req.TypeID != nil {
+			typeID = *req.TypeID
+		}
+		if req.Type != nil {
+			typeName = *req.Type
+		}
+		if strings.TrimSpace(typeID) == "" && strings.TrimSpace(typeName) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "type cannot be empty",
+			})
+		}
+		equipmentType, err := equipmentTypeForRequest(c, businessID, typeID, typeName)
+		if err != nil {
+			return respondEquipmentTypeError(c, err, "failed to resolve equipment type")
+		}
+		eq.Type = equipmentType.Name
+		eq.TypeID = &equipmentType.ID
+	}
+	if req.Location != nil {
+		eq.Location = *req.Location
+		// New text without a node is filed again when the update is saved.
+		if req.LocationID == nil {
+			eq.LocationID = nil
+			eq.LocationNode = nil
+		}
+	}
+	if req.LocationID != nil {
+		if *req.LocationID == "" {
+			eq.LocationID = nil
+			eq.LocationNode = nil
+		} else {
+			locationID, err := uuid.Parse(*req.LocationID)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "invalid location ID",
+				})
+			}
+			label, err := repositories.GetLocationPathLabel(c.UserContext(), businessID, locationID)
+			if err != nil {
+				return respondLocationError(c, err, "failed to fetch location")
+			}
+			eq.LocationID = &locationID
+			eq.Location = label
+		}
+	}
+	if req.MoreFields != nil {
+		merged, err := repositories.MergeMoreFields(eq.MoreFields, req.MoreFields)
+		if err != nil {
+			return respondEquipmentError(c, err, "invalid more_fields")
+		}
+		eq.MoreFields = merged
+	}
+	// Existing values are only checked against the schema when the fields or the
+	// type change, so equipment that predates a schema can still be edited.
+	if req.MoreFields != nil || typeChanged {
+		checked, err := repositories.ApplyFieldSchemaJSON(c.UserContext(), businessID, eq.Type, eq.MoreFields)
+		if err != nil {
+			return respondEquipmentError(c, err, "invalid more_fields")
+		}
+		eq.MoreFields = checked
+	}
+
+	// The status and the attributes are saved together, so a rejected transition
+	// leaves nothing half saved. Retired equipment is read-only, so leaving
+	// "retired" happens before the update and entering it afterwards.
+	leavingRetired := statusChange && eq.Status == models.EquipmentStatusRetired
+	err = database.Transaction(c.UserContext(), func(ctx context.Context) error {
+		if leavingRetired {
+			if err := repositories.ChangeEquipmentStatus(ctx, eq, *req.Status, &user.ID, req.StatusReason, true); err != nil {
+				return err
+			}
+		}
+		if attributesChanged {
+			if err := repositories.UpdateEquipment(ctx, eq); err != nil {
+				return err
+			}
+		}
+		if statusChange && !leavingRetired {
+			return repositories.ChangeEquipmentStatus(ctx, eq, *req.Status, &user.ID, req.StatusReason, true)
+		}
+		return nil
+	})
+	if err != nil {
+		return respondEquipmentError(c, err, "could not update equipment")
+	}
+
+	updated, err := repositories.GetEquipmentInBusiness(c.UserContext(), businessID, eq.ID)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	return c.JSON(updated)
+}
+
+func retireEquipment(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.RetireEquipmentRequest)
+	return retireEquipmentWithReason(c, strings.TrimSpace(req.Reason))
+}
+
+// deleteEquipment retires the equipment instead of removing it, so issues and
+// history are kept. Retired equipment can be reinstated.
+func deleteEquipment(c *fiber.Ctx) error {
+	return retireEquipmentWithReason(c, strings.TrimSpace(c.Query("reason")))
+}
+
+func retireEquipmentWithReason(c *fiber.Ctx, reason string) error {
+	user := c.Locals("user").(*models.User)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	if err := repositories.RetireEquipment(c.UserContext(), eq, user.ID, reason); err != nil {
+		return respondEquipmentError(c, err, "could not retire equipment")
+	}
+
+	return c.JSON(eq)
+}
+
+func reinstateEquipment(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	if err := repositories.ReinstateEquipment(c.UserContext(), eq, user.ID); err != nil {
+		return respondEquipmentError(c, err, "could not reinstate equipment")
+	}
+
+	return c.JSON(eq)
+}
+
+func equipmentFromParams(c *fiber.Ctx) (*models.Equipment, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	equipmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrEquipmentNotFound
+	}
+
+	return repositories.GetEquipmentInBusiness(c.UserContext(), businessID, equipmentID)
+}
+
+func respondEquipmentError(c *fiber.Ctx, err error, fallback string) error {
+	var fieldErr *repositories.FieldValidationError
+	if errors.As(err, &fieldErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  "more_fields do not match the schema for this equipment type",
+			"fields": fieldErr.Errors,
+		})
+	}
+
+	switch {
+	case errors.Is(err, repositories.ErrEquipmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrEquipmentRetired),
+		errors.Is(err, repositories.ErrEquipmentNotRetired):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrStatusTransitionForbidden),
+		errors.Is(err, repositories.ErrStatusUnchanged),
+		errors.Is(err, repositories.ErrStatusConflict),
+		errors.Is(err, repositories.ErrAssemblyCycle),
+		errors.Is(err, repositories.ErrAssemblyUnchanged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvalidMoreFields),
+		errors.Is(err, repositories.ErrInvalidStatus),
+		errors.Is(err, repositories.ErrAssemblyParentNotFound):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
+
+func setEquipmentResponsibleTeam(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.SetResponsibleTeamRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	eq, err := repositories.GetEquipmentByID(c.UserContext(), c.Params("id"))
+	if err != nil || eq.BusinessID != businessID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "equipment not found",
+		})
+	}
+
+	var teamID *uuid.UUID
+	if req.TeamID != "" {
+		team, err := repositories.GetTeamByID(c.UserContext(), businessID, uuid.MustParse(req.TeamID))
+		if err != nil {
+			return respondTeamError(c, err, "failed to fetch team")
+		}
+		teamID = &team.ID
+	}
+
+	if err := repositories.SetEquipmentResponsibleTeam(c.UserContext(), eq.ID, teamID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not update responsible team",
+		})
+	}
+
+	eq.ResponsibleTeamID = teamID
+	return c.JSON(eq)
+}
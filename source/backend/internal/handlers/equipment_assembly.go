@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterEquipmentAssemblyRoutes(app *fiber.App) {
+	app.Get("/api/equipment/:id/tree", middleware.RequireUser, middleware.RequireBusiness, getEquipmentTree)
+	app.Put("/api/equipment/:id/parent", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.SetEquipmentParentRequest](), setEquipmentParent)
+	app.Get("/api/equipment/:id/moves", middleware.RequireUser, middleware.RequireBusiness, getEquipmentMoves)
+}
+
+// getEquipmentTree returns the assemblies equipment belongs to, outermost first,
+// and the equipment with all of its components nested beneath it.
+func getEquipmentTree(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	ancestors, err := repositories.GetEquipmentAncestors(c.UserContext(), eq.BusinessID, eq.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch assemblies",
+		})
+	}
+
+	tree, err := repositories.GetEquipmentTree(c.UserContext(), eq.BusinessID, eq.ID)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch components")
+	}
+
+	return c.JSON(fiber.Map{
+		"ancestors": ancestors,
+		"tree":      tree,
+	})
+}
+
+// setEquipmentParent moves equipment into another assembly, or out of its
+// current one when parent_id is null. The move is kept in the equipment's history.
+func setEquipmentParent(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.SetEquipmentParentRequest)
+	user := c.Locals("user").(*models.User)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	var parentID *uuid.UUID
+	if req.ParentID != nil && *req.ParentID != "" {
+		id := uuid.MustParse(*req.ParentID)
+		parentID = &id
+	}
+
+	if err := repositories.SetEquipmentParent(c.UserContext(), eq, parentID, &user.ID, req.Reason); err != nil {
+		return respondEquipmentError(c, err, "could not move equipment")
+	}
+
+	return c.JSON(eq)
+}
+
+// getEquipmentMoves returns the assembly moves of equipment, oldest first.
+func getEquipmentMoves(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	moves, err := repositories.GetEquipmentMoveHistory(c.UserContext(), eq.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch move history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"equipmentId": eq.ID,
+		"parentId":    eq.ParentID,
+		"moves":       moves,
+	})
+}
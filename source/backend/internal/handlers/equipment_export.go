@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var exportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": fiber.MIMEApplicationJSONCharsetUTF8,
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func RegisterEquipmentExportRoutes(app *fiber.App) {
+	app.Get("/api/equipment/export", middleware.RequireUser, middleware.RequireBusiness, exportEquipment)
+}
+
+// exportEquipment streams a business's equipment as CSV, JSON or XLSX. It takes
+// the list endpoint's filters and sort, plus:
+//   - format: csv (default), json or xlsx
+//   - include: comma-separated extra columns, open_issues and/or last_inspection
+//
+// The columns match what the import endpoint reads, so an export can be edited
+// and imported again.
+func exportEquipment(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	format := strings.ToLower(c.Query("format", "csv"))
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be csv, json or xlsx",
+		})
+	}
+
+	filter, err := equipmentFilterFromQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	options := repositories.ExportOptions{Filter: filter}
+
+	if include := c.Query("include"); include != "" {
+		for _, column := range strings.Split(include, ",") {
+			switch strings.TrimSpace(column) {
+			case "open_issues":
+				options.OpenIssues = true
+			case "last_inspection":
+				options.LastInspection = true
+			default:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "include must list open_issues or last_inspection",
+				})
+			}
+		}
+	}
+
+	columns, err := repositories.EquipmentExportColumns(c.UserContext(), businessID, options)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to export equipment",
+		})
+	}
+
+	filename := fmt.Sprintf("equipment-%s.%s", time.Now().UTC().Format("2006-01-02"), format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	// The body is written after the handler returns, outside the request's
+	// tenant transaction, so the rows are read in a scope of their own.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := database.WithTenantScope(context.Background(), user.ID, func(ctx context.Context) error {
+			if err := database.SetTenantBusiness(ctx, businessID); err != nil {
+				return err
+			}
+			return writeEquipmentExport(ctx, w, format, businessID, options, columns)
+		})
+		if err != nil {
+			log.Printf("⚠️  Equipment export for business %s failed: %v", businessID, err)
+		}
+		if err := w.Flush(); err != nil {
+			log.Printf("⚠️  Equipment export for business %s could not be sent: %v", businessID, err)
+		}
+	})
+	return nil
+}
+
+func writeEquipmentExport(ctx context.Context, w *bufio.Writer, format string, businessID uuid.UUID, options repositories.ExportOptions, columns []string) error {
+	export := func(write func(row []any) error) error {
+		return repositories.ExportEquipment(ctx, businessID, options, columns, write)
+	}
+
+	switch format {
+	case "json":
+		if _, err := w.WriteString("["); err != nil {
+			return err
+		}
+		first := true
+		err := export(func(row []any) error {
+			record := make(map[string]any, len(columns))
+			for i, column := range columns {
+				record[column] = row[i]
+			}
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := w.WriteString(","); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err = w.Write(data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = w.WriteString("]")
+		return err
+
+	case "xlsx":
+		sheet, err := utils.NewXLSXWriter(w, "Equipment")
+		if err != nil {
+			return err
+		}
+		header := make([]any, len(columns))
+		for i, column := range columns {
+			header[i] = column
+		}
+		if err := sheet.WriteRow(header); err != nil {
+			return err
+		}
+		if err := export(sheet.WriteRow); err != nil {
+			return err
+		}
+		return sheet.Close()
+
+	default:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+		err := export(func(row []any) error {
+			record := make([]string, len(row))
+			for i, value := range row {
+				record[i] = exportCSVValue(value)
+			}
+			return writer.Write(record)
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+}
+
+// exportCSVValue formats a cell for CSV. Text is escaped so values such as
+// "=HYPERLINK(...)" are not run as formulas when the file is opened.
+func exportCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return utils.EscapeCSVFormula(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func RegisterEquipmentFieldRoutes(app *fiber.App) {
+	fields := app.Group("/api/equipment-fields", middleware.RequireUser, middleware.RequireBusiness)
+
+	fields.Get("/", listEquipmentFields)
+	fields.Get("/report", middleware.RequireBusinessAdmin, equipmentFieldReport)
+	fields.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreateEquipmentFieldRequest](), createEquipmentField)
+	fields.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateEquipmentFieldRequest](), updateEquipmentField)
+	fields.Delete("/:id", middleware.RequireBusinessAdmin, deleteEquipmentField)
+}
+
+// listEquipmentFields returns the field definitions of the active business,
+// optionally for a single equipment type (?type=).
+func listEquipmentFields(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	fields, err := repositories.GetEquipmentFields(c.UserContext(), businessID, c.Query("type"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch field definitions",
+		})
+	}
+
+	return c.JSON(fields)
+}
+
+func createEquipmentField(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateEquipmentFieldRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	equipmentType, err := repositories.ResolveEquipmentType(c.UserContext(), businessID, req.EquipmentType)
+	if err != nil {
+		return respondEquipmentTypeError(c, err, "failed to resolve equipment type")
+	}
+
+	field := models.EquipmentField{
+		BusinessID:    businessID,
+		EquipmentType: equipmentType.Name,
+		Key:           req.Key,
+		Label:         req.Label,
+		Kind:          req.Kind,
+		Unit:          req.Unit,
+		Options:       req.Options,
+		Required:      req.Required,
+		Position:      req.Position,
+	}
+	if req.Default != nil {
+		encoded, err := json.Marshal(req.Default)
+		if err != nil {
+			return respondEquipmentFieldError(c, repositories.ErrFieldBadDefault, "")
+		}
+		field.Default = datatypes.JSON(encoded)
+	}
+
+	if err := repositories.CreateEquipmentField(c.UserContext(), &field); err != nil {
+		return respondEquipmentFieldError(c, err, "could not create field definition")
+	}
+
+	return equipmentFieldWithReport(c, &field, fiber.StatusCreated)
+}
+
+func updateEquipmentField(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateEquipmentFieldRequest)
+
+	field, err := equipmentFieldFromParams(c)
+	if err != nil {
+		return respondEquipmentFieldError(c, err, "failed to fetch field definition")
+	}
+
+	if req.Label != nil {
+		field.Label = *req.Label
+	}
+	if req.Kind != nil {
+		field.Kind = *req.Kind
+	}
+	if req.Unit != nil {
+		field.Unit = *req.Unit
+	}
+	if req.Options != nil {
+		field.Options = req.Options
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+	if req.Default != nil {
+		field.Default = datatypes.JSON(req.Default)
+	}
+	if req.Position != nil {
+		field.Position = *req.Position
+	}
+
+	if err := repositories.UpdateEquipmentField(c.UserContext(), field); err != nil {
+		return respondEquipmentFieldError(c, err, "could not update field definition")
+	}
+
+	return equipmentFieldWithReport(c, field, fiber.StatusOK)
+}
+
+func deleteEquipmentField(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	fieldID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return respondEquipmentFieldError(c, repositories.ErrFieldNotFound, "")
+	}
+
+	if err := repositories.DeleteEquipmentField(c.UserContext(), businessID, fieldID); err != nil {
+		return respondEquipmentFieldError(c, err, "could not delete field definition")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// equipmentFieldReport lists equipment of a type whose custom fields no longer
+// match the type's schema.
+func equipmentFieldReport(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	equipmentType := c.Query("type")
+	if equipmentType == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "type is required",
+		})
+	}
+
+	report, err := repositories.CheckFieldSchemaConformance(c.UserContext(), businessID, equipmentType)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to check equipment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"type":          equipmentType,
+		"nonConforming": report,
+	})
+}
+
+// equipmentFieldWithReport responds with a changed definition and the existing
+// equipment that does not satisfy it, so admins see the impact of a schema change.
+func equipmentFieldWithReport(c *fiber.Ctx, field *models.EquipmentField, status int) error {
+	report, err := repositories.CheckFieldSchemaConformance(c.UserContext(), field.BusinessID, field.EquipmentType)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to check equipment",
+		})
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"field":         field,
+		"nonConforming": report,
+	})
+}
+
+func equipmentFieldFromParams(c *fiber.Ctx) (*models.EquipmentField, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	fieldID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrFieldNotFound
+	}
+
+	return repositories.GetEquipmentFieldByID(c.UserContext(), businessID, fieldID)
+}
+
+func respondEquipmentFieldError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrFieldNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrFieldDuplicate):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrFieldKeyRequired),
+		errors.Is(err, repositories.ErrFieldNeedsOptions),
+		errors.Is(err, repositories.ErrFieldBadDefault):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxImportFileSize caps uploaded spreadsheets at Fiber's default body limit.
+const maxImportFileSize = 4 << 20
+
+func RegisterEquipmentImportRoutes(app *fiber.App) {
+	imports := app.Group("/api/equipment/imports", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin)
+
+	imports.Post("/", importEquipment)
+	imports.Get("/:id", getEquipmentImport)
+	imports.Post("/:id/resume", resumeEquipmentImport)
+}
+
+// importEquipment creates equipment from an uploaded CSV or XLSX file. Form fields:
+//   - file: the spreadsheet; the first row holds the column headers
+//   - dry_run: "true" only validates and returns the report
+//   - mode: "atomic" (default) creates all rows or none; "chunked" skips invalid
+//     rows and creates the rest in resumable chunks in the background
+//   - chunk_size: rows per chunk in chunked mode
+//   - unique_field: MoreFields key whose values must be unique, e.g. a serial number
+//   - mapping: JSON object from column header to status, type, type_id, location,
+//     location_id, id, ignore or field.<key>
+func importEquipment(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file not provided",
+		})
+	}
+	if fileHeader.Size > maxImportFileSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "file is too large",
+		})
+	}
+
+	mode := c.FormValue("mode", repositories.ImportModeAtomic)
+	if mode != repositories.ImportModeAtomic && mode != repositories.ImportModeChunked {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "mode must be atomic or chunked",
+		})
+	}
+
+	chunkSize, err := strconv.Atoi(c.FormValue("chunk_size", strconv.Itoa(repositories.DefaultImportChunkSize)))
+	if err != nil || chunkSize < 1 || chunkSize > 1000 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "chunk_size must be between 1 and 1000",
+		})
+	}
+
+	options := repositories.ImportOptions{UniqueField: c.FormValue("unique_field")}
+	if mapping := c.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "mapping must be a JSON object of column header to target",
+			})
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not open file",
+		})
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not read file",
+		})
+	}
+
+	// The header, the row limit and one more row, so oversized files are still
+	// reported as such.
+	rows, err := utils.ReadSpreadsheet(fileHeader.Filename, data, repositories.MaxImportRows+2)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report, valid, err := repositories.PrepareImport(c.UserContext(), businessID, rows, options)
+	if err != nil {
+		return respondImportError(c, err, "could not validate import")
+	}
+
+	if c.FormValue("dry_run") == "true" {
+		return c.JSON(fiber.Map{
+			"dryRun": true,
+			"mode":   mode,
+			"report": report,
+		})
+	}
+
+	if mode == repositories.ImportModeChunked {
+		if len(valid) == 0 {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":  "no valid rows to import",
+				"report": report,
+			})
+		}
+
+		job, err := repositories.StartChunkedImport(c.UserContext(), businessID, user.ID, fileHeader.Filename, valid, report, chunkSize)
+		if err != nil {
+			return respondImportError(c, err, "could not start import")
+		}
+		return c.Status(fiber.StatusAccepted).JSON(job)
+	}
+
+	if len(report.Errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  repositories.ErrImportInvalid.Error(),
+			"report": report,
+		})
+	}
+
+	createdIDs, err := repositories.CommitImport(c.UserContext(), businessID, user.ID, valid)
+	if err != nil {
+		return respondImportError(c, err, "could not import equipment")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"mode":       mode,
+		"createdIds": createdIDs,
+		"report":     report,
+	})
+}
+
+// getEquipmentImport returns the progress of a chunked import, including the IDs
+// created so far.
+func getEquipmentImport(c *fiber.Ctx) error {
+	job, err := importFromParams(c, repositories.GetImport)
+	if err != nil {
+		return respondImportError(c, err, "failed to fetch import")
+	}
+	return c.JSON(job)
+}
+
+func resumeEquipmentImport(c *fiber.Ctx) error {
+	job, err := importFromParams(c, repositories.ResumeImport)
+	if err != nil {
+		return respondImportError(c, err, "could not resume import")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+func importFromParams(c *fiber.Ctx, load func(context.Context, uuid.UUID, uuid.UUID) (*models.EquipmentImport, error)) (*models.EquipmentImport, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	importID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrImportNotFound
+	}
+
+	return load(c.UserContext(), businessID, importID)
+}
+
+func respondImportError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrImportNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrImportNotResumable):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrImportEmpty),
+		errors.Is(err, repositories.ErrImportTooLarge),
+		errors.Is(err, repositories.ErrImportNoType),
+		errors.Is(err, repositories.ErrImportBadMapping):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
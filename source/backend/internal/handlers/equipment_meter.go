@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxReadingsPageSize caps how many readings a single page can return.
+const maxReadingsPageSize = 500
+
+func RegisterEquipmentMeterRoutes(app *fiber.App) {
+	app.Get("/api/equipment/:id/meters", middleware.RequireUser, middleware.RequireBusiness, listEquipmentMeters)
+	app.Post("/api/equipment/:id/meters", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreateEquipmentMeterRequest](), createEquipmentMeter)
+	app.Patch("/api/equipment/:id/meters/:meterId", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateEquipmentMeterRequest](), updateEquipmentMeter)
+	app.Delete("/api/equipment/:id/meters/:meterId", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, deleteEquipmentMeter)
+	app.Get("/api/equipment/:id/meters/:meterId/readings", middleware.RequireUser, middleware.RequireBusiness, listMeterReadings)
+	app.Delete("/api/equipment/:id/meters/:meterId/readings/:readingId", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, deleteMeterReading)
+	app.Get("/api/equipment/:id/meters/:meterId/usage", middleware.RequireUser, middleware.RequireBusiness, getMeterUsage)
+	app.Post("/api/equipment/:id/readings", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.RecordMeterReadingsRequest](), recordMeterReadings)
+}
+
+func listEquipmentMeters(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	meters, err := repositories.ListEquipmentMeters(c.UserContext(), eq.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch meters",
+		})
+	}
+
+	return c.JSON(meters)
+}
+
+func createEquipmentMeter(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateEquipmentMeterRequest)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	meter := models.EquipmentMeter{
+		BusinessID:  eq.BusinessID,
+		EquipmentID: eq.ID,
+		Name:        req.Name,
+		Unit:        req.Unit,
+		Monotonic:   req.Monotonic == nil || *req.Monotonic,
+	}
+	if err := repositories.CreateEquipmentMeter(c.UserContext(), &meter); err != nil {
+		return respondMeterError(c, err, "could not create meter")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(meter)
+}
+
+func updateEquipmentMeter(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateEquipmentMeterRequest)
+
+	meter, err := meterFromParams(c)
+	if err != nil {
+		return respondMeterError(c, err, "failed to fetch meter")
+	}
+
+	if req.Name != nil {
+		meter.Name = *req.Name
+	}
+	if req.Unit != nil {
+		meter.Unit = *req.Unit
+	}
+	if req.Monotonic != nil {
+		meter.Monotonic = *req.Monotonic
+	}
+
+	if err := repositories.UpdateEquipmentMeter(c.UserContext(), meter); err != nil {
+		return respondMeterError(c, err, "could not update meter")
+	}
+
+	return c.JSON(meter)
+}
+
+func deleteEquipmentMeter(c *fiber.Ctx) error {
+	meter, err := meterFromParams(c)
+	if err != nil {
+		return respondMeterError(c, err, "failed to fetch meter")
+	}
+
+	if err := repositories.DeleteEquipmentMeter(c.UserContext(), meter); err != nil {
+		return respondMeterError(c, err, "could not delete meter")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// recordMeterReadings stores readings of the equipment's meters. It is what the
+// QR page posts after a scan, so several meters can be read in one request;
+// if any reading is rejected none are stored.
+func recordMeterReadings(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.RecordMeterReadingsRequest)
+	user := c.Locals("user").(*models.User)
+	membership, _ := c.Locals("membership").(*models.UserBusiness)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+	if eq.RetiredAt != nil {
+		return respondEquipmentError(c, repositories.ErrEquipmentRetired, "")
+	}
+
+	readings := make([]repositories.NewMeterReading, 0, len(req.Readings))
+	for _, reading := range req.Readings {
+		if reading.Reset && (membership == nil || !membership.IsAdmin) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "only business admins can reset a meter",
+			})
+		}
+
+		next := repositories.NewMeterReading{
+			MeterID: uuid.MustParse(reading.MeterID),
+			Value:   *reading.Value,
+			Reset:   reading.Reset,
+		}
+		if reading.ReadAt != nil {
+			next.ReadAt = *reading.ReadAt
+		}
+		readings = append(readings, next)
+	}
+
+	source := req.Source
+	if source == "" {
+		source = models.MeterSourceManual
+	}
+
+	stored, err := repositories.RecordMeterReadings(c.UserContext(), eq, readings, source, req.Note, &user.ID)
+	if err != nil {
+		return respondMeterError(c, err, "could not record readings")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(stored)
+}
+
+// listMeterReadings returns a meter's readings, newest first, optionally limited
+// to from <= read_at < to.
+func listMeterReadings(c *fiber.Ctx) error {
+	meter, err := meterFromParams(c)
+	if err != nil {
+		return respondMeterError(c, err, "failed to fetch meter")
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "100"))
+	if err != nil || limit < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+	if limit > maxReadingsPageSize {
+		limit = maxReadingsPageSize
+	}
+
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	to, err := timeQuery(c, "to")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	readings, total, err := repositories.ListMeterReadings(c.UserContext(), meter.ID, from, to, limit, (page-1)*limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch readings",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"meter":    meter,
+		"readings": readings,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+func deleteMeterReading(c *fiber.Ctx) error {
+	meter, err := meterFromParams(c)
+	if err != nil {
+		return respondMeterError(c, err, "failed to fetch meter")
+	}
+
+	readingID, err := uuid.Parse(c.Params("readingId"))
+	if err != nil {
+		return respondMeterError(c, repositories.ErrMeterReadingNotFound, "")
+	}
+
+	if err := repositories.DeleteMeterReading(c.UserContext(), meter, readingID); err != nil {
+		return respondMeterError(c, err, "could not delete reading")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// getMeterUsage aggregates a meter's readings per day or week (UTC). Without a
+// range it covers the last 30 days, or the last 12 weeks for interval=week.
+func getMeterUsage(c *fiber.Ctx) error {
+	meter, err := meterFromParams(c)
+	if err != nil {
+		return respondMeterError(c, err, "failed to fetch meter")
+	}
+
+	interval := c.Query("interval", repositories.UsageIntervalDay)
+
+	to := time.Now().UTC()
+	if value, err := timeQuery(c, "to"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	} else if value != nil {
+		to = *value
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if interval == repositories.UsageIntervalWeek {
+		from = to.AddDate(0, 0, -7*12)
+	}
+	if value, err := timeQuery(c, "from"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	} else if value != nil {
+		from = *value
+	}
+
+	usage, err := repositories.GetMeterUsage(c.UserContext(), meter.ID, interval, from, to)
+	if err != nil {
+		return respondMeterError(c, err, "failed to fetch usage")
+	}
+
+	var total float64
+	for _, period := range usage {
+		total += period.Usage
+	}
+
+	return c.JSON(fiber.Map{
+		"meter":    meter,
+		"interval": interval,
+		"from":     from,
+		"to":       to,
+		"total":    total,
+		"usage":    usage,
+	})
+}
+
+func meterFromParams(c *fiber.Ctx) (*models.EquipmentMeter, error) {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return nil, err
+	}
+
+	meterID, err := uuid.Parse(c.Params("meterId"))
+	if err != nil {
+		return nil, repositories.ErrMeterNotFound
+	}
+
+	return repositories.GetEquipmentMeter(c.UserContext(), eq.ID, meterID)
+}
+
+// timeQuery parses an optional RFC 3339 timestamp or YYYY-MM-DD date (UTC
+// midnight) from the query string.
+func timeQuery(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, errors.New(key + " must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+}
+
+func respondMeterError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrMeterNotFound),
+		errors.Is(err, repositories.ErrMeterReadingNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrMeterDuplicate):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrMeterRollback),
+		errors.Is(err, repositories.ErrMeterReadingFuture):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvalidUsageInterval):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
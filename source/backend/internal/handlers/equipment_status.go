@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterEquipmentStatusRoutes(app *fiber.App) {
+	statuses := app.Group("/api/equipment-statuses", middleware.RequireUser, middleware.RequireBusiness)
+
+	statuses.Get("/", listEquipmentStatuses)
+	statuses.Put("/transitions", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.SetStatusTransitionsRequest](), setStatusTransitions)
+	statuses.Delete("/transitions", middleware.RequireBusinessAdmin, resetStatusTransitions)
+
+	app.Post("/api/equipment/:id/status", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.ChangeEquipmentStatusRequest](), changeEquipmentStatus)
+	app.Get("/api/equipment/:id/status-history", middleware.RequireUser, middleware.RequireBusiness, getEquipmentStatusHistory)
+}
+
+// listEquipmentStatuses returns every status and the transitions the active
+// business allows between them.
+func listEquipmentStatuses(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	transitions, custom, err := repositories.GetStatusTransitions(c.UserContext(), businessID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch status transitions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"statuses":    models.EquipmentStatuses,
+		"transitions": transitions,
+		"custom":      custom,
+	})
+}
+
+func setStatusTransitions(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.SetStatusTransitionsRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	transitions := make([]models.EquipmentStatusTransition, 0, len(req.Transitions))
+	for _, transition := range req.Transitions {
+		transitions = append(transitions, models.EquipmentStatusTransition{
+			FromStatus: transition.From,
+			ToStatus:   transition.To,
+		})
+	}
+
+	if err := repositories.SetStatusTransitions(c.UserContext(), businessID, transitions); err != nil {
+		return respondEquipmentError(c, err, "could not save status transitions")
+	}
+
+	return listEquipmentStatuses(c)
+}
+
+func resetStatusTransitions(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	if err := repositories.ResetStatusTransitions(c.UserContext(), businessID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not reset status transitions",
+		})
+	}
+
+	return listEquipmentStatuses(c)
+}
+
+func changeEquipmentStatus(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.ChangeEquipmentStatusRequest)
+	user := c.Locals("user").(*models.User)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+	if err := requireAdminForRetirement(c, eq.Status, req.Status); err != nil {
+		return middleware.RespondFiberError(c, err)
+	}
+
+	if err := repositories.ChangeEquipmentStatus(c.UserContext(), eq, req.Status, &user.ID, req.Reason, true); err != nil {
+		return respondEquipmentError(c, err, "could not change status")
+	}
+
+	return c.JSON(eq)
+}
+
+// getEquipmentStatusHistory returns the status timeline of equipment, oldest first,
+// with how long each status lasted.
+func getEquipmentStatusHistory(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	timeline, err := repositories.GetEquipmentStatusTimeline(c.UserContext(), eq.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch status history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"equipmentId": eq.ID,
+		"status":      eq.Status,
+		"timeline":    timeline,
+	})
+}
+
+// requireAdminForRetirement rejects moving equipment into or out of "retired"
+// unless the user is a business admin, as for the retire and reinstate actions.
+func requireAdminForRetirement(c *fiber.Ctx, from string, to string) error {
+	if from != models.EquipmentStatusRetired && to != models.EquipmentStatusRetired {
+		return nil
+	}
+	membership, _ := c.Locals("membership").(*models.UserBusiness)
+	if membership == nil || !membership.IsAdmin {
+		return fiber.NewError(fiber.StatusForbidden, "business admin access required")
+	}
+	return nil
+}
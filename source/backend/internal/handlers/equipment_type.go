@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func RegisterEquipmentTypeRoutes(app *fiber.App) {
+	types := app.Group("/api/equipment-types", middleware.RequireUser, middleware.RequireBusiness)
+
+	types.Get("/", listEquipmentTypes)
+	types.Get("/:id", getEquipmentType)
+	types.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreateEquipmentTypeRequest](), createEquipmentType)
+	types.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateEquipmentTypeRequest](), updateEquipmentType)
+	types.Delete("/:id", middleware.RequireBusinessAdmin, deleteEquipmentType)
+
+	app.Get("/api/equipment/:id/issue-templates", middleware.RequireUser, middleware.RequireBusiness, listEquipmentIssueTemplates)
+}
+
+func listEquipmentTypes(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	types, err := repositories.ListEquipmentTypes(c.UserContext(), businessID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch equipment types",
+		})
+	}
+
+	return c.JSON(types)
+}
+
+func getEquipmentType(c *fiber.Ctx) error {
+	equipmentType, err := equipmentTypeFromParams(c)
+	if err != nil {
+		return respondEquipmentTypeError(c, err, "failed to fetch equipment type")
+	}
+
+	return c.JSON(equipmentType)
+}
+
+func createEquipmentType(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateEquipmentTypeRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	equipmentType := models.EquipmentType{
+		BusinessID:            businessID,
+		Name:                  req.Name,
+		Icon:                  req.Icon,
+		DefaultIssueTemplates: issueTemplatesFromRequest(req.DefaultIssueTemplates),
+	}
+	if req.DefaultFields != nil {
+		encoded, _ := json.Marshal(req.DefaultFields)
+		equipmentType.DefaultFields = datatypes.JSON(encoded)
+	}
+	if req.DefaultMaintenancePlan != nil {
+		encoded, _ := json.Marshal(req.DefaultMaintenancePlan)
+		equipmentType.DefaultMaintenancePlan = datatypes.JSON(encoded)
+	}
+
+	if err := repositories.CreateEquipmentType(c.UserContext(), &equipmentType); err != nil {
+		return respondEquipmentTypeError(c, err, "could not create equipment type")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(equipmentType)
+}
+
+func updateEquipmentType(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateEquipmentTypeRequest)
+
+	equipmentType, err := equipmentTypeFromParams(c)
+	if err != nil {
+		return respondEquipmentTypeError(c, err, "failed to fetch equipment type")
+	}
+	previousName := equipmentType.Name
+
+	if req.Name != nil {
+		equipmentType.Name = *req.Name
+	}
+	if req.Icon != nil {
+		equipmentType.Icon = *req.Icon
+	}
+	if req.DefaultFields != nil {
+		encoded, _ := json.Marshal(req.DefaultFields)
+		equipmentType.DefaultFields = datatypes.JSON(encoded)
+	}
+	if req.DefaultMaintenancePlan != nil {
+		equipmentType.DefaultMaintenancePlan = datatypes.JSON(req.DefaultMaintenancePlan)
+	}
+	if req.DefaultIssueTemplates != nil {
+		equipmentType.DefaultIssueTemplates = issueTemplatesFromRequest(req.DefaultIssueTemplates)
+	}
+
+	if err := repositories.UpdateEquipmentType(c.UserContext(), equipmentType, previousName); err != nil {
+		return respondEquipmentTypeError(c, err, "could not update equipment type")
+	}
+
+	return c.JSON(equipmentType)
+}
+
+func deleteEquipmentType(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	typeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return respondEquipmentTypeError(c, repositories.ErrEquipmentTypeNotFound, "")
+	}
+
+	if err := repositories.DeleteEquipmentType(c.UserContext(), businessID, typeID); err != nil {
+		return respondEquipmentTypeError(c, err, "could not delete equipment type")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// equipmentTypeForRequest picks the catalog entry for equipment being created or
+// changed: an explicit type ID, otherwise the type name. Only business admins add
+// unknown names to the catalog; other members must use an existing type.
+func equipmentTypeForRequest(c *fiber.Ctx, businessID uuid.UUID, typeID string, typeName string) (*models.EquipmentType, error) {
+	if typeID != "" {
+		id, err := uuid.Parse(typeID)
+		if err != nil {
+			return nil, repositories.ErrEquipmentTypeNotFound
+		}
+		return repositories.GetEquipmentTypeByID(c.UserContext(), businessID, id)
+	}
+
+	// Equipment can be created in a business other than the active one.
+	membership, _ := c.Locals("membership").(*models.UserBusiness)
+	if membership == nil || membership.BusinessID != businessID {
+		user := c.Locals("user").(*models.User)
+		membership, _ = repositories.GetUserBusinessMembership(c.UserContext(), user.ID, businessID)
+	}
+	if membership == nil || !membership.IsAdmin {
+		return repositories.GetEquipmentTypeByName(c.UserContext(), businessID, typeName)
+	}
+	return repositories.ResolveEquipmentType(c.UserContext(), businessID, typeName)
+}
+
+// listEquipmentIssueTemplates returns the issue templates of the equipment's type,
+// which the issue form offers to pre-fill the title and description.
+func listEquipmentIssueTemplates(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	templates := []models.IssueTemplate{}
+	if eq.TypeID != nil {
+		equipmentType, err := repositories.GetEquipmentTypeByID(c.UserContext(), eq.BusinessID, *eq.TypeID)
+		if err != nil && !errors.Is(err, repositories.ErrEquipmentTypeNotFound) {
+			return respondEquipmentTypeError(c, err, "failed to fetch issue templates")
+		}
+		if err == nil && len(equipmentType.DefaultIssueTemplates) > 0 {
+			templates = equipmentType.DefaultIssueTemplates
+		}
+	}
+
+	return c.JSON(templates)
+}
+
+func issueTemplatesFromRequest(templates []utils.IssueTemplateRequest) []models.IssueTemplate {
+	result := make([]models.IssueTemplate, 0, len(templates))
+	for _, template := range templates {
+		result = append(result, models.IssueTemplate{
+			Title:       template.Title,
+			Description: template.Description,
+		})
+	}
+	return result
+}
+
+func equipmentTypeFromParams(c *fiber.Ctx) (*models.EquipmentType, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	typeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrEquipmentTypeNotFound
+	}
+
+	return repositories.GetEquipmentTypeByID(c.UserContext(), businessID, typeID)
+}
+
+func respondEquipmentTypeError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrEquipmentTypeNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrEquipmentTypeDuplicate),
+		errors.Is(err, repositories.ErrEquipmentTypeInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvalidTypeDefaults),
+		errors.Is(err, repositories.ErrInvalidMoreFields):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterInspectionRoutes(app *fiber.App) {
+	templates := app.Group("/api/inspection-templates", middleware.RequireUser, middleware.RequireBusiness)
+
+	templates.Get("/", listInspectionTemplates)
+	templates.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.InspectionTemplateRequest](), createInspectionTemplate)
+	templates.Get("/:id", getInspectionTemplate)
+	templates.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateInspectionTemplateRequest](), updateInspectionTemplate)
+	templates.Delete("/:id", middleware.RequireBusinessAdmin, deleteInspectionTemplate)
+
+	inspections := app.Group("/api/inspections", middleware.RequireUser, middleware.RequireBusiness)
+
+	inspections.Get("/:id", getInspection)
+	inspections.Put("/:id/answers", utils.ValidateBody[utils.InspectionAnswersRequest](), answerInspection)
+	inspections.Post("/:id/complete", utils.ValidateBody[utils.CompleteInspectionRequest](), completeInspection)
+	inspections.Delete("/:id", middleware.RequireBusinessAdmin, deleteInspection)
+
+	app.Get("/api/equipment/:id/inspection-templates", middleware.RequireUser, middleware.RequireBusiness, listEquipmentInspectionTemplates)
+	app.Get("/api/equipment/:id/inspections", middleware.RequireUser, middleware.RequireBusiness, listEquipmentInspections)
+	app.Post("/api/equipment/:id/inspections", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.StartInspectionRequest](), startInspection)
+}
+
+// listInspectionTemplates returns the business's checklists, optionally for a
+// single equipment type (?type_id=).
+func listInspectionTemplates(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	typeID, err := uuidQuery(c, "type_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	templates, err := repositories.ListInspectionTemplates(c.UserContext(), businessID, typeID, false)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch checklists",
+		})
+	}
+
+	return c.JSON(templates)
+}
+
+func createInspectionTemplate(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.InspectionTemplateRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	template := models.InspectionTemplate{
+		BusinessID:      businessID,
+		EquipmentTypeID: uuid.MustParse(req.EquipmentTypeID),
+		Name:            req.Name,
+		Description:     req.Description,
+		Items:           inspectionItemsFromRequest(req.Items),
+		Active:          req.Active == nil || *req.Active,
+	}
+
+	if err := repositories.CreateInspectionTemplate(c.UserContext(), &template); err != nil {
+		return respondInspectionError(c, err, "could not create checklist")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(template)
+}
+
+func getInspectionTemplate(c *fiber.Ctx) error {
+	template, err := inspectionTemplateFromParams(c)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch checklist")
+	}
+
+	return c.JSON(template)
+}
+
+func updateInspectionTemplate(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateInspectionTemplateRequest)
+
+	template, err := inspectionTemplateFromParams(c)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch checklist")
+	}
+
+	if req.Name != nil {
+		template.Name = *req.Name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.Active != nil {
+		template.Active = *req.Active
+	}
+	if req.Items != nil {
+		template.Items = inspectionItemsFromRequest(req.Items)
+	}
+
+	if err := repositories.UpdateInspectionTemplate(c.UserContext(), template, req.Items != nil); err != nil {
+		return respondInspectionError(c, err, "could not update checklist")
+	}
+
+	return c.JSON(template)
+}
+
+func deleteInspectionTemplate(c *fiber.Ctx) error {
+	template, err := inspectionTemplateFromParams(c)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch checklist")
+	}
+
+	if err := repositories.DeleteInspectionTemplate(c.UserContext(), template); err != nil {
+		return respondInspectionError(c, err, "could not delete checklist")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// listEquipmentInspectionTemplates returns the active checklists that can be run
+// on equipment, for the picker shown after a QR scan.
+func listEquipmentInspectionTemplates(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	templates := []models.InspectionTemplate{}
+	if eq.TypeID != nil {
+		templates, err = repositories.ListInspectionTemplates(c.UserContext(), eq.BusinessID, eq.TypeID, true)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch checklists",
+			})
+		}
+	}
+
+	return c.JSON(templates)
+}
+
+// startInspection begins an inspection of the equipment and returns the run
+// with one unanswered result per checklist item.
+func startInspection(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.StartInspectionRequest)
+	user := c.Locals("user").(*models.User)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	var templateID *uuid.UUID
+	if req.TemplateID != nil && *req.TemplateID != "" {
+		id := uuid.MustParse(*req.TemplateID)
+		templateID = &id
+	}
+
+	template, err := repositories.ResolveInspectionTemplate(c.UserContext(), eq, templateID)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch checklist")
+	}
+
+	source := req.Source
+	if source == "" {
+		source = models.InspectionSourceManual
+	}
+
+	run, err := repositories.StartInspection(c.UserContext(), eq, template, source, user.ID)
+	if err != nil {
+		return respondInspectionError(c, err, "could not start inspection")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(run)
+}
+
+// listEquipmentInspections returns the equipment's inspection history, newest
+// first, optionally filtered by status.
+func listEquipmentInspections(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.InspectionInProgress, models.InspectionPassed, models.InspectionFailed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be in progress, passed or failed",
+		})
+	}
+
+	runs, total, err := repositories.ListEquipmentInspections(c.UserContext(), eq.ID, repositories.InspectionRunFilter{
+		Status: status,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch inspections",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"inspections": runs,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+func getInspection(c *fiber.Ctx) error {
+	run, err := inspectionFromParams(c)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch inspection")
+	}
+
+	return c.JSON(run)
+}
+
+// answerInspection records answers on an inspection in progress. Only the
+// inspector who started it, or a business admin, can answer.
+func answerInspection(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.InspectionAnswersRequest)
+
+	run, err := inspectionFromParams(c)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch inspection")
+	}
+	if !canEditInspection(c, run) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only the inspector or a business admin can answer this inspection",
+		})
+	}
+
+	if err := repositories.RecordInspectionAnswers(c.UserContext(), run, inspectionAnswersFromRequest(req.Answers)); err != nil {
+		return respondInspectionError(c, err, "could not save answers")
+	}
+
+	return c.JSON(run)
+}
+
+// completeInspection finishes an inspection. Failed items that are set to create
+// issues open them on the equipment.
+func completeInspection(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CompleteInspectionRequest)
+	user := c.Locals("user").(*models.User)
+
+	run, err := inspectionFromParams(c)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch inspection")
+	}
+	if !canEditInspection(c, run) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only the inspector or a business admin can complete this inspection",
+		})
+	}
+
+	if len(req.Answers) > 0 {
+		if err := repositories.RecordInspectionAnswers(c.UserContext(), run, inspectionAnswersFromRequest(req.Answers)); err != nil {
+			return respondInspectionError(c, err, "could not save answers")
+		}
+	}
+
+	if err := repositories.CompleteInspection(c.UserContext(), run, user.ID, req.Note); err != nil {
+		return respondInspectionError(c, err, "could not complete inspection")
+	}
+
+	return c.JSON(run)
+}
+
+func deleteInspection(c *fiber.Ctx) error {
+	run, err := inspectionFromParams(c)
+	if err != nil {
+		return respondInspectionError(c, err, "failed to fetch inspection")
+	}
+
+	if err := repositories.DeleteInspectionRun(c.UserContext(), run); err != nil {
+		return respondInspectionError(c, err, "could not delete inspection")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func canEditInspection(c *fiber.Ctx, run *models.InspectionRun) bool {
+	user := c.Locals("user").(*models.User)
+	membership, _ := c.Locals("membership").(*models.UserBusiness)
+
+	if membership != nil && membership.IsAdmin {
+		return true
+	}
+	return run.InspectorID != nil && *run.InspectorID == user.ID
+}
+
+func inspectionItemsFromRequest(items []utils.InspectionItemRequest) []models.InspectionItem {
+	result := make([]models.InspectionItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, models.InspectionItem{
+			Key:          item.Key,
+			Label:        item.Label,
+			Kind:         item.Kind,
+			Required:     item.Required,
+			Min:          item.Min,
+			Max:          item.Max,
+			Unit:         item.Unit,
+			CreatesIssue: item.CreatesIssue,
+		})
+	}
+	return result
+}
+
+func inspectionAnswersFromRequest(answers []utils.InspectionAnswerRequest) []repositories.InspectionAnswer {
+	result := make([]repositories.InspectionAnswer, 0, len(answers))
+	for _, answer := range answers {
+		result = append(result, repositories.InspectionAnswer{
+			ItemKey:  answer.ItemKey,
+			Passed:   answer.Passed,
+			Value:    answer.Value,
+			Text:     answer.Text,
+			PhotoKey: answer.PhotoKey,
+			Note:     answer.Note,
+		})
+	}
+	return result
+}
+
+func inspectionTemplateFromParams(c *fiber.Ctx) (*models.InspectionTemplate, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrInspectionTemplateNotFound
+	}
+
+	return repositories.GetInspectionTemplate(c.UserContext(), businessID, id)
+}
+
+func inspectionFromParams(c *fiber.Ctx) (*models.InspectionRun, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrInspectionNotFound
+	}
+
+	return repositories.GetInspectionRun(c.UserContext(), businessID, id)
+}
+
+func respondInspectionError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrInspectionTemplateNotFound),
+		errors.Is(err, repositories.ErrInspectionNotFound),
+		errors.Is(err, repositories.ErrEquipmentTypeNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInspectionCompleted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInspectionIncomplete),
+		errors.Is(err, repositories.ErrInvalidInspectionAnswer):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInspectionTemplateAmbiguous),
+		errors.Is(err, repositories.ErrInspectionTemplateMismatch),
+		errors.Is(err, repositories.ErrInvalidInspectionItems):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
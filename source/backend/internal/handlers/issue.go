@@ -8,19 +8,20 @@ import (
 	"github.com/EquipQR/equipqr/backend/internal/repositories"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 func RegisterIssueRoutes(app *fiber.App) {
-	app.Get("/api/issue/:id", middleware.RequireUser, handleGetIssue)
+	app.Get("/api/issue/:id", middleware.RequireUser, middleware.RequireBusiness, handleGetIssue)
 	app.Post("/api/issue", middleware.RequireUser, utils.ValidateBody[utils.CreateIssueRequest](), handleCreateIssue)
+	app.Put("/api/issue/:id/team", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.AssignIssueTeamRequest](), handleAssignIssueTeam)
+	app.Post("/api/issue/:id/claim", middleware.RequireUser, middleware.RequireBusiness, handleClaimIssue)
 }
 
 func handleGetIssue(c *fiber.Ctx) error {
-	id := c.Params("id")
-
-	issue, err := repositories.GetIssueByID(id)
+	issue, err := issueFromParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "issue not found"})
+		return respondTeamError(c, err, "failed to fetch issue")
 	}
 
 	return c.JSON(issue)
@@ -31,7 +32,7 @@ func handleCreateIssue(c *fiber.Ctx) error {
 	user := c.Locals("user").(*models.User)
 	userID := user.ID
 	log.Println("User Id:", userID)
-	issue, err := repositories.CreateIssueFromRequest(req, userID)
+	issue, err := repositories.CreateIssueFromRequest(c.UserContext(), req, userID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -40,3 +41,56 @@ func handleCreateIssue(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusCreated).JSON(issue)
 }
+
+func handleAssignIssueTeam(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.AssignIssueTeamRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	issue, err := issueFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch issue")
+	}
+
+	var teamID *uuid.UUID
+	if req.TeamID != "" {
+		team, err := repositories.GetTeamByID(c.UserContext(), businessID, uuid.MustParse(req.TeamID))
+		if err != nil {
+			return respondTeamError(c, err, "failed to fetch team")
+		}
+		teamID = &team.ID
+	}
+
+	if err := repositories.AssignIssueToTeam(c.UserContext(), issue, teamID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not assign issue",
+		})
+	}
+
+	return c.JSON(issue)
+}
+
+func handleClaimIssue(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+
+	issue, err := issueFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch issue")
+	}
+
+	if err := repositories.ClaimIssue(c.UserContext(), issue, user.ID); err != nil {
+		return respondTeamError(c, err, "could not claim issue")
+	}
+
+	return c.JSON(issue)
+}
+
+func issueFromParams(c *fiber.Ctx) (*models.Issue, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	issueID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrIssueNotFound
+	}
+
+	return repositories.GetIssueInBusiness(c.UserContext(), businessID, issueID)
+}
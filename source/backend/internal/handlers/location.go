@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterLocationRoutes(app *fiber.App) {
+	locations := app.Group("/api/locations", middleware.RequireUser, middleware.RequireBusiness)
+
+	locations.Get("/", listLocations)
+	locations.Get("/:id/equipment", getLocationEquipment)
+	locations.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreateLocationRequest](), createLocation)
+	locations.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateLocationRequest](), updateLocation)
+	locations.Delete("/:id", middleware.RequireBusinessAdmin, deleteLocation)
+}
+
+func listLocations(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	locations, err := repositories.GetLocationsForBusiness(c.UserContext(), businessID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch locations",
+		})
+	}
+
+	return c.JSON(locations)
+}
+
+func getLocationEquipment(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid location ID",
+		})
+	}
+
+	equipment, err := repositories.GetEquipmentInLocationSubtree(c.UserContext(), businessID, locationID)
+	if err != nil {
+		return respondLocationError(c, err, "failed to fetch equipment")
+	}
+
+	return c.JSON(equipment)
+}
+
+func createLocation(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateLocationRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	location := models.Location{
+		BusinessID: businessID,
+		Name:       req.Name,
+		Kind:       req.Kind,
+	}
+	if location.Kind == "" {
+		location.Kind = "area"
+	}
+	if req.ParentID != "" {
+		parentID := uuid.MustParse(req.ParentID)
+		location.ParentID = &parentID
+	}
+
+	if err := repositories.CreateLocation(c.UserContext(), &location); err != nil {
+		return respondLocationError(c, err, "could not create location")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(location)
+}
+
+func updateLocation(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateLocationRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid location ID",
+		})
+	}
+
+	location, err := repositories.GetLocationByID(c.UserContext(), businessID, locationID)
+	if err != nil {
+		return respondLocationError(c, err, "failed to fetch location")
+	}
+
+	if req.Name != "" {
+		location.Name = req.Name
+	}
+	if req.Kind != "" {
+		location.Kind = req.Kind
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			location.ParentID = nil
+		} else {
+			parentID, err := uuid.Parse(*req.ParentID)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "invalid parent ID",
+				})
+			}
+			location.ParentID = &parentID
+		}
+	}
+
+	if err := repositories.UpdateLocation(c.UserContext(), location); err != nil {
+		return respondLocationError(c, err, "could not update location")
+	}
+
+	return c.JSON(location)
+}
+
+func deleteLocation(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	locationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid location ID",
+		})
+	}
+
+	if err := repositories.DeleteLocation(c.UserContext(), businessID, locationID); err != nil {
+		return respondLocationError(c, err, "could not delete location")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func respondLocationError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrLocationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrLocationDuplicate),
+		errors.Is(err, repositories.ErrLocationCycle),
+		errors.Is(err, repositories.ErrLocationInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
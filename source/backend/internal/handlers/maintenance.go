@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterMaintenanceRoutes(app *fiber.App) {
+	app.Get("/api/maintenance/plans", middleware.RequireUser, middleware.RequireBusiness, listMaintenancePlans)
+	app.Post("/api/maintenance/plans", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.MaintenancePlanRequest](), createMaintenancePlan)
+	app.Get("/api/maintenance/plans/:id", middleware.RequireUser, middleware.RequireBusiness, getMaintenancePlan)
+	app.Patch("/api/maintenance/plans/:id", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateMaintenancePlanRequest](), updateMaintenancePlan)
+	app.Delete("/api/maintenance/plans/:id", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, deleteMaintenancePlan)
+	app.Get("/api/maintenance/occurrences", middleware.RequireUser, middleware.RequireBusiness, listMaintenanceOccurrences)
+	app.Post("/api/maintenance/occurrences/:id/complete", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.CloseMaintenanceRequest](), completeMaintenance)
+	app.Post("/api/maintenance/occurrences/:id/skip", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CloseMaintenanceRequest](), skipMaintenance)
+	app.Get("/api/maintenance/compliance", middleware.RequireUser, middleware.RequireBusiness, getMaintenanceCompliance)
+	app.Post("/api/maintenance/run", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, runMaintenance)
+	app.Get("/api/equipment/:id/maintenance", middleware.RequireUser, middleware.RequireBusiness, getEquipmentMaintenance)
+}
+
+func listMaintenancePlans(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	equipmentID, err := uuidQuery(c, "equipment_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	typeID, err := uuidQuery(c, "type_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	plans, err := repositories.ListMaintenancePlans(c.UserContext(), businessID, equipmentID, typeID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch maintenance plans",
+		})
+	}
+
+	return c.JSON(plans)
+}
+
+// createMaintenancePlan saves a plan and schedules it on the equipment it applies
+// to. Work that is already within the lead time is opened immediately.
+func createMaintenancePlan(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.MaintenancePlanRequest)
+	user := c.Locals("user").(*models.User)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	plan := models.MaintenancePlan{
+		BusinessID:    businessID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Trigger:       req.Trigger,
+		IntervalDays:  req.IntervalDays,
+		FirstDueAt:    req.FirstDueAt,
+		LeadDays:      req.LeadDays,
+		GraceDays:     req.GraceDays,
+		MeterName:     req.MeterName,
+		MeterInterval: req.MeterInterval,
+		LeadUsage:     req.LeadUsage,
+		GraceUsage:    req.GraceUsage,
+		FixedSchedule: req.FixedSchedule,
+		Active:        req.Active == nil || *req.Active,
+		CreatedBy:     &user.ID,
+	}
+	if req.EquipmentID != nil && *req.EquipmentID != "" {
+		id := uuid.MustParse(*req.EquipmentID)
+		plan.EquipmentID = &id
+	}
+	if req.EquipmentTypeID != nil && *req.EquipmentTypeID != "" {
+		id := uuid.MustParse(*req.EquipmentTypeID)
+		plan.EquipmentTypeID = &id
+	}
+
+	if err := repositories.CreateMaintenancePlan(c.UserContext(), &plan); err != nil {
+		return respondMaintenanceError(c, err, "could not create maintenance plan")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(plan)
+}
+
+func getMaintenancePlan(c *fiber.Ctx) error {
+	plan, err := maintenancePlanFromParams(c)
+	if err != nil {
+		return respondMaintenanceError(c, err, "failed to fetch maintenance plan")
+	}
+
+	return c.JSON(plan)
+}
+
+func updateMaintenancePlan(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateMaintenancePlanRequest)
+
+	plan, err := maintenancePlanFromParams(c)
+	if err != nil {
+		return respondMaintenanceError(c, err, "failed to fetch maintenance plan")
+	}
+
+	if req.Name != nil {
+		plan.Name = *req.Name
+	}
+	if req.Description != nil {
+		plan.Description = *req.Description
+	}
+	if req.Trigger != nil {
+		plan.Trigger = *req.Trigger
+	}
+	if req.IntervalDays != nil {
+		plan.IntervalDays = *req.IntervalDays
+	}
+	if req.FirstDueAt != nil {
+		plan.FirstDueAt = req.FirstDueAt
+	}
+	if req.LeadDays != nil {
+		plan.LeadDays = *req.LeadDays
+	}
+	if req.GraceDays != nil {
+		plan.GraceDays = *req.GraceDays
+	}
+	if req.MeterName != nil {
+		plan.MeterName = *req.MeterName
+	}
+	if req.MeterInterval != nil {
+		plan.MeterInterval = *req.MeterInterval
+	}
+	if req.LeadUsage != nil {
+		plan.LeadUsage = *req.LeadUsage
+	}
+	if req.GraceUsage != nil {
+		plan.GraceUsage = *req.GraceUsage
+	}
+	if req.FixedSchedule != nil {
+		plan.FixedSchedule = *req.FixedSchedule
+	}
+	if req.Active != nil {
+		plan.Active = *req.Active
+	}
+
+	if err := repositories.UpdateMaintenancePlan(c.UserContext(), plan); err != nil {
+		return respondMaintenanceError(c, err, "could not update maintenance plan")
+	}
+
+	return c.JSON(plan)
+}
+
+func deleteMaintenancePlan(c *fiber.Ctx) error {
+	plan, err := maintenancePlanFromParams(c)
+	if err != nil {
+		return respondMaintenanceError(c, err, "failed to fetch maintenance plan")
+	}
+
+	if err := repositories.DeleteMaintenancePlan(c.UserContext(), plan); err != nil {
+		return respondMaintenanceError(c, err, "could not delete maintenance plan")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// getEquipmentMaintenance returns the maintenance schedules of equipment with
+// when each is next due and the work currently open for it.
+func getEquipmentMaintenance(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	schedules, err := repositories.GetEquipmentMaintenance(c.UserContext(), eq.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch maintenance",
+		})
+	}
+
+	return c.JSON(schedules)
+}
+
+// listMaintenanceOccurrences lists maintenance work, soonest due first, filtered
+// by status, equipment_id and plan_id.
+func listMaintenanceOccurrences(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+
+	filter := repositories.MaintenanceOccurrenceFilter{
+		Status: c.Query("status"),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+	switch filter.Status {
+	case "", models.MaintenanceOpen, models.MaintenanceCompleted, models.MaintenanceSkipped:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be open, completed or skipped",
+		})
+	}
+	if filter.EquipmentID, err = uuidQuery(c, "equipment_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.PlanID, err = uuidQuery(c, "plan_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	occurrences, total, err := repositories.ListMaintenanceOccurrences(c.UserContext(), businessID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch maintenance work",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"occurrences": occurrences,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// completeMaintenance closes maintenance work as done, together with its issue,
+// and schedules the next round.
+func completeMaintenance(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CloseMaintenanceRequest)
+	user := c.Locals("user").(*models.User)
+
+	occurrence, err := maintenanceOccurrenceFromParams(c)
+	if err != nil {
+		return respondMaintenanceError(c, err, "failed to fetch maintenance work")
+	}
+
+	if err := repositories.CompleteMaintenance(c.UserContext(), occurrence, user.ID, req.MeterValue, req.Note); err != nil {
+		return respondMaintenanceError(c, err, "could not complete maintenance")
+	}
+
+	return c.JSON(occurrence)
+}
+
+func skipMaintenance(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CloseMaintenanceRequest)
+	user := c.Locals("user").(*models.User)
+
+	occurrence, err := maintenanceOccurrenceFromParams(c)
+	if err != nil {
+		return respondMaintenanceError(c, err, "failed to fetch maintenance work")
+	}
+
+	if err := repositories.SkipMaintenance(c.UserContext(), occurrence, user.ID, req.Note); err != nil {
+		return respondMaintenanceError(c, err, "could not skip maintenance")
+	}
+
+	return c.JSON(occurrence)
+}
+
+// getMaintenanceCompliance reports how much of the maintenance due between from
+// and to was done on time. It covers the last 90 days by default.
+func getMaintenanceCompliance(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	filter := repositories.ComplianceFilter{To: time.Now().UTC()}
+	if value, err := timeQuery(c, "to"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	} else if value != nil {
+		filter.To = *value
+	}
+
+	filter.From = filter.To.AddDate(0, 0, -90)
+	if value, err := timeQuery(c, "from"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	} else if value != nil {
+		filter.From = *value
+	}
+
+	var err error
+	if filter.EquipmentID, err = uuidQuery(c, "equipment_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.PlanID, err = uuidQuery(c, "plan_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	compliance, err := repositories.GetMaintenanceCompliance(c.UserContext(), businessID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch compliance",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"from":       filter.From,
+		"to":         filter.To,
+		"compliance": compliance,
+	})
+}
+
+// runMaintenance opens the business's maintenance work that has come due
+// without waiting for the hourly scheduler, e.g. right after meter readings.
+func runMaintenance(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	opened, err := repositories.GenerateMaintenanceWork(c.UserContext(), &businessID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":  "could not generate all maintenance work",
+			"opened": opened,
+		})
+	}
+
+	return c.JSON(fiber.Map{"opened": opened})
+}
+
+func maintenancePlanFromParams(c *fiber.Ctx) (*models.MaintenancePlan, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrMaintenancePlanNotFound
+	}
+
+	return repositories.GetMaintenancePlan(c.UserContext(), businessID, id)
+}
+
+func maintenanceOccurrenceFromParams(c *fiber.Ctx) (*models.MaintenanceOccurrence, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrMaintenanceNotFound
+	}
+
+	return repositories.GetMaintenanceOccurrence(c.UserContext(), businessID, id)
+}
+
+// uuidQuery parses an optional UUID from the query string.
+func uuidQuery(c *fiber.Ctx, key string) (*uuid.UUID, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, errors.New("invalid " + key)
+	}
+	return &id, nil
+}
+
+func respondMaintenanceError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrMaintenancePlanNotFound),
+		errors.Is(err, repositories.ErrMaintenanceNotFound),
+		errors.Is(err, repositories.ErrEquipmentTypeNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrMaintenanceClosed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvalidMaintenancePlan),
+		errors.Is(err, repositories.ErrMaintenanceMeterRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
@@ -1,52 +1,126 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"path"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
 	"github.com/EquipQR/equipqr/backend/internal/s3"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/minio/minio-go/v7"
+	"github.com/valyala/fasthttp"
 )
 
 var config = utils.LoadConfigFromEnv()
 
+// unsafeObjectNameChars are replaced when a file name becomes part of an object key.
+var unsafeObjectNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// inlineContentTypes are the stored types browsers may display in place. Anything
+// else, HTML and SVG included, is always sent as a download.
+var inlineContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// uploadPaths are the routes that accept files, and so bodies larger than
+// Fiber's default limit.
+var uploadPaths = regexp.MustCompile(`^/api/(files|documents|equipment/[^/]+/photos)/?$`)
+
+// UploadBodyLimit raises the request body limit to limit bytes for uploads only.
+// It is installed as the server's HeaderReceived hook, so every other request
+// keeps the default limit and is rejected before its body is read.
+func UploadBodyLimit(limit int) func(*fasthttp.RequestHeader) fasthttp.RequestConfig {
+	return func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		uri, _, _ := bytes.Cut(header.RequestURI(), []byte("?"))
+		if header.IsPost() && uploadPaths.Match(uri) {
+			return fasthttp.RequestConfig{MaxRequestBodySize: limit}
+		}
+		return fasthttp.RequestConfig{}
+	}
+}
+
+func RegisterMediaRoutes(app *fiber.App) {
+	app.Post("/api/files", middleware.RequireUser, middleware.RequireBusiness, UploadFile)
+	app.Get("/api/files/*", middleware.RequireUser, middleware.RequireBusiness, GetFile)
+}
+
+// UploadFile stores a file, such as a photo for an inspection item, under the
+// active business's key prefix and returns its key.
 func UploadFile(c *fiber.Ctx) error {
-	bucket := config.MinioBucket
+	businessID, _ := middleware.ActiveBusinessID(c)
+
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "file not provided")
 	}
 
-	objectName := fmt.Sprintf("%d-%s", time.Now().UnixNano(), fileHeader.Filename)
+	objectName := s3.BusinessPrefix(businessID) + "uploads/" + fmt.Sprintf("%d-%s", time.Now().UnixNano(), safeObjectName(fileHeader.Filename))
+
+	if err := putUploadedFile(objectName, fileHeader); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "upload failed")
+	}
+
+	return c.JSON(fiber.Map{
+		"key": objectName,
+		"url": "/api/files/" + objectName,
+	})
+}
+
+// GetFile streams a stored file. Only keys under the active business's prefix
+// can be read.
+func GetFile(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+	key := c.Params("*")
 
+	if !strings.HasPrefix(key, s3.BusinessPrefix(businessID)) || strings.Contains(key, "..") {
+		return fiber.NewError(fiber.StatusNotFound, "file not found")
+	}
+
+	return sendObject(c, key, path.Base(key), false)
+}
+
+func putUploadedFile(objectName string, fileHeader *multipart.FileHeader) error {
 	file, err := fileHeader.Open()
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "could not open file")
+		return err
 	}
 	defer file.Close()
 
-	_, err = s3.Client.PutObject(context.Background(), bucket, objectName, file, fileHeader.Size, minio.PutObjectOptions{
-		ContentType: fileHeader.Header.Get("Content-Type"),
-	})
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "upload failed")
+	contentType := fileHeader.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
 	}
 
-	return c.JSON(fiber.Map{
-		"key": objectName,
-		"url": fmt.Sprintf("/files/%s", objectName),
+	_, err = s3.Client.PutObject(context.Background(), config.MinioBucket, objectName, file, fileHeader.Size, minio.PutObjectOptions{
+		ContentType: contentType,
 	})
+	return err
 }
 
-func GetFile(c *fiber.Ctx) error {
-	bucket := config.MinioBucket
-	key := c.Params("key")
+func putObjectBytes(objectName string, data []byte, contentType string) error {
+	_, err := s3.Client.PutObject(context.Background(), config.MinioBucket, objectName, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
 
-	obj, err := s3.Client.GetObject(context.Background(), bucket, key, minio.GetObjectOptions{})
+// sendObject streams an object to the client, inline or as a download, under
+// the given file name. Only images and PDFs are shown inline, and responses are
+// sandboxed so a stored file cannot run script on the app's origin.
+func sendObject(c *fiber.Ctx, key string, filename string, download bool) error {
+	obj, err := s3.Client.GetObject(context.Background(), config.MinioBucket, key, minio.GetObjectOptions{})
 	if err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "file not found")
 	}
@@ -57,8 +131,15 @@ func GetFile(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "file stat error")
 	}
 
-	c.Type(stat.ContentType)
-	c.Response().Header.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", key))
+	disposition := "inline"
+	if download || !inlineContentTypes[strings.ToLower(strings.TrimSpace(strings.Split(stat.ContentType, ";")[0]))] {
+		disposition = "attachment"
+	}
+
+	c.Set(fiber.HeaderContentType, stat.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentSecurityPolicy, "sandbox")
+	c.Response().Header.Set("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, safeObjectName(filename)))
 	c.Response().Header.Set("Content-Length", fmt.Sprintf("%d", stat.Size))
 
 	_, err = io.Copy(c.Response().BodyWriter(), obj)
@@ -68,3 +149,17 @@ func GetFile(c *fiber.Ctx) error {
 
 	return nil
 }
+
+// safeObjectName reduces a client-supplied file name to characters that are
+// safe in object keys and headers.
+func safeObjectName(name string) string {
+	name = unsafeObjectNameChars.ReplaceAllString(path.Base(strings.ReplaceAll(name, "\\", "/")), "_")
+	name = strings.Trim(name, "._")
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+	if name == "" {
+		name = "file"
+	}
+	return name
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterPartRoutes(app *fiber.App) {
+	parts := app.Group("/api/parts", middleware.RequireUser, middleware.RequireBusiness)
+
+	parts.Get("/", listParts)
+	parts.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreatePartRequest](), createPart)
+	parts.Get("/low-stock", getLowStockReport)
+	parts.Get("/:id", getPart)
+	parts.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdatePartRequest](), updatePart)
+	parts.Delete("/:id", middleware.RequireBusinessAdmin, deletePart)
+	parts.Post("/:id/stock", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.AdjustPartStockRequest](), adjustPartStock)
+	parts.Put("/:id/stock/:locationId/minimum", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.SetPartMinimumRequest](), setPartMinimum)
+	parts.Get("/:id/movements", getPartMovements)
+
+	app.Get("/api/equipment/:id/parts", middleware.RequireUser, middleware.RequireBusiness, getEquipmentParts)
+	app.Put("/api/equipment/:id/parts/:partId", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.FitPartRequest](), fitEquipmentPart)
+	app.Delete("/api/equipment/:id/parts/:partId", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, unfitEquipmentPart)
+
+	app.Get("/api/issue/:id/parts", middleware.RequireUser, middleware.RequireBusiness, getIssueParts)
+	app.Post("/api/issue/:id/parts", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.ConsumePartsRequest](), consumeIssueParts)
+}
+
+// listParts returns the parts catalogue with stock on hand, filtered by q (part
+// number or name), equipment_id (parts that fit it) or vendor_id.
+func listParts(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	filter := repositories.PartFilter{Query: c.Query("q")}
+	var err error
+	if filter.EquipmentID, err = uuidQuery(c, "equipment_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.VendorID, err = uuidQuery(c, "vendor_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	parts, err := repositories.ListParts(c.UserContext(), businessID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch parts",
+		})
+	}
+
+	return c.JSON(parts)
+}
+
+func createPart(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreatePartRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	part := models.Part{
+		BusinessID:  businessID,
+		PartNumber:  req.PartNumber,
+		Name:        req.Name,
+		Description: req.Description,
+		Unit:        req.Unit,
+		UnitCost:    req.UnitCost,
+		VendorID:    optionalUUID(req.VendorID),
+	}
+	if err := repositories.CreatePart(c.UserContext(), &part); err != nil {
+		return respondPartError(c, err, "could not create part")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(part)
+}
+
+// getPart returns a part with its stock at each location.
+func getPart(c *fiber.Ctx) error {
+	part, err := partFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch part")
+	}
+
+	stock, err := repositories.GetPartStock(c.UserContext(), part.ID)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch stock")
+	}
+
+	return c.JSON(fiber.Map{
+		"part":  part,
+		"stock": stock,
+	})
+}
+
+func updatePart(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdatePartRequest)
+
+	part, err := partFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch part")
+	}
+
+	if req.PartNumber != nil {
+		part.PartNumber = *req.PartNumber
+	}
+	if req.Name != nil {
+		part.Name = *req.Name
+	}
+	if req.Description != nil {
+		part.Description = *req.Description
+	}
+	if req.Unit != nil {
+		part.Unit = *req.Unit
+	}
+	if req.UnitCost != nil {
+		part.UnitCost = req.UnitCost
+	}
+	if req.VendorID != nil {
+		part.VendorID = optionalUUID(*req.VendorID)
+		part.Vendor = nil
+	}
+
+	if err := repositories.UpdatePart(c.UserContext(), part); err != nil {
+		return respondPartError(c, err, "could not update part")
+	}
+
+	return c.JSON(part)
+}
+
+func deletePart(c *fiber.Ctx) error {
+	part, err := partFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch part")
+	}
+
+	if err := repositories.DeletePart(c.UserContext(), part); err != nil {
+		return respondPartError(c, err, "could not delete part")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func adjustPartStock(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.AdjustPartStockRequest)
+	user := c.Locals("user").(*models.User)
+
+	part, err := partFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch part")
+	}
+
+	stock, err := repositories.AdjustPartStock(c.UserContext(), part, uuid.MustParse(req.LocationID), req.Kind, req.Quantity, user.ID, req.Note)
+	if err != nil {
+		return respondPartError(c, err, "could not adjust stock")
+	}
+
+	return c.JSON(stock)
+}
+
+func setPartMinimum(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.SetPartMinimumRequest)
+
+	part, err := partFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch part")
+	}
+
+	locationID, err := uuid.Parse(c.Params("locationId"))
+	if err != nil {
+		return respondPartError(c, repositories.ErrLocationNotFound, "failed to fetch location")
+	}
+
+	stock, err := repositories.SetPartMinimum(c.UserContext(), part, locationID, req.MinQuantity)
+	if err != nil {
+		return respondPartError(c, err, "could not set minimum stock")
+	}
+
+	return c.JSON(stock)
+}
+
+// getPartMovements returns one page of a part's stock movements, newest first.
+func getPartMovements(c *fiber.Ctx) error {
+	part, err := partFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch part")
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+
+	movements, total, err := repositories.GetPartMovements(c.UserContext(), part.ID, limit, (page-1)*limit)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch movements")
+	}
+
+	return c.JSON(fiber.Map{
+		"movements": movements,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// getLowStockReport lists stock levels below their minimum, largest shortfall
+// first. location_id narrows it to a location and everything beneath it.
+func getLowStockReport(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	locationID, err := uuidQuery(c, "location_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	items, err := repositories.GetLowStockReport(c.UserContext(), businessID, locationID)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch low stock")
+	}
+
+	return c.JSON(items)
+}
+
+func getEquipmentParts(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	fitments, err := repositories.ListEquipmentParts(c.UserContext(), eq.ID)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch parts")
+	}
+
+	return c.JSON(fitments)
+}
+
+func fitEquipmentPart(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.FitPartRequest)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	part, err := equipmentPartFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch part")
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	fitment, err := repositories.FitPart(c.UserContext(), eq, part, quantity, req.Note)
+	if err != nil {
+		return respondPartError(c, err, "could not link part")
+	}
+
+	return c.JSON(fitment)
+}
+
+func unfitEquipmentPart(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	partID, err := uuid.Parse(c.Params("partId"))
+	if err != nil {
+		return respondPartError(c, repositories.ErrPartNotFitted, "could not unlink part")
+	}
+
+	if err := repositories.UnfitPart(c.UserContext(), eq.ID, partID); err != nil {
+		return respondPartError(c, err, "could not unlink part")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func getIssueParts(c *fiber.Ctx) error {
+	issue, err := issueFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch issue")
+	}
+
+	movements, err := repositories.GetIssuePartUsage(c.UserContext(), issue.ID)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch parts")
+	}
+
+	return c.JSON(movements)
+}
+
+// consumeIssueParts takes the parts used on an issue out of stock in one
+// transaction; if any location runs short nothing is taken.
+func consumeIssueParts(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.ConsumePartsRequest)
+	user := c.Locals("user").(*models.User)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	issue, err := issueFromParams(c)
+	if err != nil {
+		return respondPartError(c, err, "failed to fetch issue")
+	}
+
+	lines := make([]repositories.PartConsumption, len(req.Parts))
+	for i, line := range req.Parts {
+		lines[i] = repositories.PartConsumption{
+			PartID:     uuid.MustParse(line.PartID),
+			LocationID: uuid.MustParse(line.LocationID),
+			Quantity:   line.Quantity,
+		}
+	}
+
+	movements, err := repositories.ConsumeParts(c.UserContext(), businessID, issue, lines, user.ID, req.Note)
+	if err != nil {
+		return respondPartError(c, err, "could not record parts")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(movements)
+}
+
+func partFromParams(c *fiber.Ctx) (*models.Part, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrPartNotFound
+	}
+
+	return repositories.GetPart(c.UserContext(), businessID, id)
+}
+
+func equipmentPartFromParams(c *fiber.Ctx) (*models.Part, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("partId"))
+	if err != nil {
+		return nil, repositories.ErrPartNotFound
+	}
+
+	return repositories.GetPart(c.UserContext(), businessID, id)
+}
+
+func respondPartError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrPartNotFound),
+		errors.Is(err, repositories.ErrPartNotFitted),
+		errors.Is(err, repositories.ErrLocationNotFound),
+		errors.Is(err, repositories.ErrVendorNotFound),
+		errors.Is(err, repositories.ErrIssueNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrPartNumberDuplicate),
+		errors.Is(err, repositories.ErrPartInUse),
+		errors.Is(err, repositories.ErrInsufficientStock):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvalidPartQuantity),
+		errors.Is(err, repositories.ErrPartAdjustmentNoOp),
+		errors.Is(err, repositories.ErrInvalidPartMovement),
+		errors.Is(err, repositories.ErrNoPartsToConsume),
+		errors.Is(err, repositories.ErrPartConsumptionSplit):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/middleware"
 	"github.com/EquipQR/equipqr/backend/internal/repositories"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
@@ -9,23 +12,32 @@ import (
 )
 
 func RegisterPendingRoutes(app *fiber.App) {
-	app.Get("/api/pending/:businessID", middleware.RequireUser, getPendingJoinRequests)
-	app.Post("/api/pending/approve", middleware.RequireUser, approvePendingJoin)
-	app.Post("/api/pending/deny", middleware.RequireUser, denyPendingJoin)
+	app.Get("/api/pending", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, getPendingJoinRequests)
+	app.Post("/api/pending", middleware.RequireUser, utils.ValidateBody[utils.CreateJoinRequest](), createJoinRequest)
+	app.Get("/api/pending/requests/:id/history", middleware.RequireUser, getJoinRequestHistory)
+	app.Get("/api/pending/:businessID", middleware.RequireUser, middleware.ResolveBusiness, getPendingJoinRequests)
+	app.Post("/api/pending/approve", middleware.RequireUser, utils.ValidateBody[utils.DecideJoinRequest](), approvePendingJoin)
+	app.Post("/api/pending/deny", middleware.RequireUser, utils.ValidateBody[utils.DecideJoinRequest](), denyPendingJoin)
 	app.Get("/api/pending/:businessID/invite", middleware.RequireUser, GenerateInviteLinkHandler)
 	app.Get("/api/invite/accept", middleware.RequireUser, AcceptInviteHandler)
 }
 
 func getPendingJoinRequests(c *fiber.Ctx) error {
-	businessIDStr := c.Params("businessID")
-	businessID, err := uuid.Parse(businessIDStr)
+	businessID, err := businessIDForRequest(c, c.Params("businessID"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid business ID",
-		})
+		return middleware.RespondFiberError(c, err)
 	}
 
-	requests, err := repositories.GetAllPendingJoinsForBusiness(businessID)
+	if err := ensureBusinessAdmin(c, businessID); err != nil {
+		return middleware.RespondFiberError(c, err)
+	}
+
+	status := c.Query("status", models.JoinStatusPending)
+	if status == "all" {
+		status = ""
+	}
+
+	requests, err := repositories.GetAllPendingJoinsForBusiness(c.UserContext(), businessID, status)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to fetch pending requests",
@@ -35,62 +47,108 @@ func getPendingJoinRequests(c *fiber.Ctx) error {
 	return c.JSON(requests)
 }
 
-func approvePendingJoin(c *fiber.Ctx) error {
-	type ApproveJoinRequest struct {
-		RequestID string `json:"request_id"`
-	}
+func createJoinRequest(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateJoinRequest)
+	user := c.Locals("user").(*models.User)
 
-	var req ApproveJoinRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid request body",
-		})
+	joinRequest, err := repositories.CreatePendingJoinRequest(c.UserContext(), user.ID, uuid.MustParse(req.BusinessID), req.Message)
+	if err != nil {
+		return respondJoinRequestError(c, err, "failed to request business approval")
 	}
 
-	requestID, err := uuid.Parse(req.RequestID)
+	return c.Status(fiber.StatusCreated).JSON(joinRequest)
+}
+
+func getJoinRequestHistory(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+
+	joinRequest, err := joinRequestFromID(c, c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid request ID",
-		})
+		return respondJoinRequestError(c, err, "failed to fetch request")
+	}
+
+	if joinRequest.UserID != user.ID {
+		if err := ensureBusinessAdmin(c, joinRequest.BusinessID); err != nil {
+			return middleware.RespondFiberError(c, err)
+		}
 	}
 
-	if err := repositories.ApprovePendingJoin(requestID); err != nil {
+	events, err := repositories.GetJoinRequestHistory(c.UserContext(), joinRequest.ID)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to approve request",
+			"error": "failed to fetch request history",
 		})
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
+	return c.JSON(fiber.Map{
+		"request": joinRequest,
+		"history": events,
+	})
 }
 
-func denyPendingJoin(c *fiber.Ctx) error {
-	type DenyJoinRequest struct {
-		RequestID string `json:"request_id"`
+func approvePendingJoin(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.DecideJoinRequest)
+	user := c.Locals("user").(*models.User)
+
+	joinRequest, err := joinRequestFromID(c, req.RequestID)
+	if err != nil {
+		return respondJoinRequestError(c, err, "failed to approve request")
 	}
 
-	var req DenyJoinRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid request body",
-		})
+	if err := ensureBusinessAdmin(c, joinRequest.BusinessID); err != nil {
+		return middleware.RespondFiberError(c, err)
 	}
 
-	requestID, err := uuid.Parse(req.RequestID)
+	if err := repositories.ApprovePendingJoin(c.UserContext(), joinRequest.ID, user.ID); err != nil {
+		return respondJoinRequestError(c, err, "failed to approve request")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func denyPendingJoin(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.DecideJoinRequest)
+	user := c.Locals("user").(*models.User)
+
+	joinRequest, err := joinRequestFromID(c, req.RequestID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid request ID",
-		})
+		return respondJoinRequestError(c, err, "failed to deny request")
 	}
 
-	if err := repositories.DenyPendingJoin(requestID); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to deny request",
-		})
+	if err := ensureBusinessAdmin(c, joinRequest.BusinessID); err != nil {
+		return middleware.RespondFiberError(c, err)
+	}
+
+	if err := repositories.DenyPendingJoin(c.UserContext(), joinRequest.ID, user.ID, req.Reason); err != nil {
+		return respondJoinRequestError(c, err, "failed to deny request")
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+func joinRequestFromID(c *fiber.Ctx, id string) (*models.PendingJoinRequest, error) {
+	requestID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, repositories.ErrJoinRequestNotFound
+	}
+	return repositories.GetPendingJoinRequestByID(c.UserContext(), requestID)
+}
+
+func respondJoinRequestError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrJoinRequestNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrJoinRequestDuplicate),
+		errors.Is(err, repositories.ErrJoinRequestClosed),
+		errors.Is(err, repositories.ErrAlreadyMember):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrBusinessClosed):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
+
 func GenerateInviteLinkHandler(c *fiber.Ctx) error {
 	businessIDParam := c.Params("businessID")
 	email := c.Query("email")
@@ -108,6 +166,10 @@ func GenerateInviteLinkHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := ensureBusinessAdmin(c, businessID); err != nil {
+		return middleware.RespondFiberError(c, err)
+	}
+
 	link, err := repositories.GenerateInviteLinkWithEmail(
 		businessID,
 		email,
@@ -148,7 +210,7 @@ func AcceptInviteHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	err = repositories.ProcessInvite(params, userID)
+	err = repositories.ProcessInvite(c.UserContext(), params, userID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/s3"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+func RegisterPhotoRoutes(app *fiber.App) {
+	app.Get("/api/equipment/:id/photos", middleware.RequireUser, middleware.RequireBusiness, listEquipmentPhotos)
+	app.Post("/api/equipment/:id/photos", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.UploadPhotoRequest](), uploadEquipmentPhoto)
+	app.Get("/api/equipment/:id/photos/:photoId/:variant", middleware.RequireUser, middleware.RequireBusiness, getEquipmentPhotoFile)
+	app.Patch("/api/equipment/:id/photos/:photoId", middleware.RequireUser, middleware.RequireBusiness, utils.ValidateBody[utils.UpdatePhotoRequest](), updateEquipmentPhoto)
+	app.Put("/api/equipment/:id/photos/:photoId/primary", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, setPrimaryPhoto)
+	app.Delete("/api/equipment/:id/photos/:photoId", middleware.RequireUser, middleware.RequireBusiness, deleteEquipmentPhoto)
+}
+
+func listEquipmentPhotos(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	photos, err := repositories.ListEquipmentPhotos(c.UserContext(), eq.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch photos",
+		})
+	}
+
+	return c.JSON(photos)
+}
+
+// uploadEquipmentPhoto re-encodes the "file" form field into full-size, web-size
+// and thumbnail JPEGs, which drops its EXIF metadata, and stores them.
+func uploadEquipmentPhoto(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UploadPhotoRequest)
+	user := c.Locals("user").(*models.User)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	// Choosing the primary photo is an admin action, as on PUT .../primary.
+	membership, _ := c.Locals("membership").(*models.UserBusiness)
+	if req.Primary && (membership == nil || !membership.IsAdmin) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "business admin access required",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file not provided",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "could not read file",
+		})
+	}
+	processed, err := utils.ProcessPhoto(file)
+	file.Close()
+	if err != nil {
+		return respondPhotoError(c, err, "could not process photo")
+	}
+
+	photo := models.EquipmentPhoto{
+		ID:          uuid.New(),
+		BusinessID:  eq.BusinessID,
+		EquipmentID: eq.ID,
+		IsPrimary:   req.Primary,
+		Caption:     req.Caption,
+		Thumbnail:   processed.Thumbnail,
+		Width:       processed.Width,
+		Height:      processed.Height,
+		UploadedBy:  &user.ID,
+	}
+	prefix := s3.BusinessPrefix(eq.BusinessID) + "photos/" + photo.ID.String() + "/"
+	photo.FullKey = prefix + "full.jpg"
+	photo.WebKey = prefix + "web.jpg"
+
+	if err := putObjectBytes(photo.FullKey, processed.Full, "image/jpeg"); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "upload failed",
+		})
+	}
+	if err := putObjectBytes(photo.WebKey, processed.Web, "image/jpeg"); err != nil {
+		removePhotoFiles(&photo)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "upload failed",
+		})
+	}
+
+	if err := repositories.CreateEquipmentPhoto(c.UserContext(), &photo); err != nil {
+		removePhotoFiles(&photo)
+		return respondPhotoError(c, err, "could not save photo")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(photo)
+}
+
+// getEquipmentPhotoFile serves a photo variant: thumb, web or full.
+func getEquipmentPhotoFile(c *fiber.Ctx) error {
+	photo, err := photoFromParams(c)
+	if err != nil {
+		return respondPhotoError(c, err, "failed to fetch photo")
+	}
+
+	variant := c.Params("variant")
+	switch variant {
+	case "thumb":
+		c.Set(fiber.HeaderContentType, "image/jpeg")
+		return c.Send(photo.Thumbnail)
+	case "web":
+		return sendObject(c, photo.WebKey, photo.ID.String()+"-web.jpg", c.QueryBool("download"))
+	case "full":
+		return sendObject(c, photo.FullKey, photo.ID.String()+".jpg", c.QueryBool("download"))
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "variant must be thumb, web or full",
+		})
+	}
+}
+
+func updateEquipmentPhoto(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdatePhotoRequest)
+
+	photo, err := photoFromParams(c)
+	if err != nil {
+		return respondPhotoError(c, err, "failed to fetch photo")
+	}
+	if !canEditPhoto(c, photo) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only the uploader or a business admin can change this photo",
+		})
+	}
+
+	if err := repositories.UpdatePhotoCaption(c.UserContext(), photo, req.Caption); err != nil {
+		return respondPhotoError(c, err, "could not update photo")
+	}
+
+	return c.JSON(photo)
+}
+
+func setPrimaryPhoto(c *fiber.Ctx) error {
+	photo, err := photoFromParams(c)
+	if err != nil {
+		return respondPhotoError(c, err, "failed to fetch photo")
+	}
+
+	if err := repositories.SetPrimaryPhoto(c.UserContext(), photo); err != nil {
+		return respondPhotoError(c, err, "could not set primary photo")
+	}
+
+	return c.JSON(photo)
+}
+
+func deleteEquipmentPhoto(c *fiber.Ctx) error {
+	photo, err := photoFromParams(c)
+	if err != nil {
+		return respondPhotoError(c, err, "failed to fetch photo")
+	}
+	if !canEditPhoto(c, photo) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only the uploader or a business admin can delete this photo",
+		})
+	}
+
+	if err := repositories.DeleteEquipmentPhoto(c.UserContext(), photo); err != nil {
+		return respondPhotoError(c, err, "could not delete photo")
+	}
+	database.AfterCommit(c.UserContext(), func() { removePhotoFiles(photo) })
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func canEditPhoto(c *fiber.Ctx, photo *models.EquipmentPhoto) bool {
+	user := c.Locals("user").(*models.User)
+	membership, _ := c.Locals("membership").(*models.UserBusiness)
+
+	if membership != nil && membership.IsAdmin {
+		return true
+	}
+	return photo.UploadedBy != nil && *photo.UploadedBy == user.ID
+}
+
+// removePhotoFiles deletes a photo's stored variants. Failures only leave
+// orphaned objects behind, which are removed with the business, so they are logged.
+func removePhotoFiles(photo *models.EquipmentPhoto) {
+	for _, key := range []string{photo.FullKey, photo.WebKey} {
+		err := s3.Client.RemoveObject(context.Background(), config.MinioBucket, key, minio.RemoveObjectOptions{})
+		if err != nil {
+			log.Printf("photo %s: could not remove %s: %v", photo.ID, key, err)
+		}
+	}
+}
+
+func photoFromParams(c *fiber.Ctx) (*models.EquipmentPhoto, error) {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(c.Params("photoId"))
+	if err != nil {
+		return nil, repositories.ErrPhotoNotFound
+	}
+
+	return repositories.GetEquipmentPhoto(c.UserContext(), eq.ID, id)
+}
+
+func respondPhotoError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrPhotoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrPrimaryPhotoConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, utils.ErrUnsupportedImage):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, utils.ErrImageTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxExpiryWindowDays caps how far ahead the upcoming-expiry queries look.
+const maxExpiryWindowDays = 365
+
+func RegisterProcurementRoutes(app *fiber.App) {
+	vendors := app.Group("/api/vendors", middleware.RequireUser, middleware.RequireBusiness)
+
+	vendors.Get("/", listVendors)
+	vendors.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreateVendorRequest](), createVendor)
+	vendors.Get("/:id", getVendor)
+	vendors.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateVendorRequest](), updateVendor)
+	vendors.Delete("/:id", middleware.RequireBusinessAdmin, deleteVendor)
+
+	contracts := app.Group("/api/service-contracts", middleware.RequireUser, middleware.RequireBusiness)
+
+	contracts.Get("/", listServiceContracts)
+	contracts.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreateServiceContractRequest](), createServiceContract)
+	contracts.Get("/:id", getServiceContract)
+	contracts.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateServiceContractRequest](), updateServiceContract)
+	contracts.Delete("/:id", middleware.RequireBusinessAdmin, deleteServiceContract)
+
+	app.Get("/api/warranties/expiring", middleware.RequireUser, middleware.RequireBusiness, listExpiringWarranties)
+	app.Get("/api/equipment/:id/procurement", middleware.RequireUser, middleware.RequireBusiness, getEquipmentProcurement)
+	app.Put("/api/equipment/:id/procurement", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, utils.ValidateBody[utils.ProcurementRequest](), saveEquipmentProcurement)
+	app.Delete("/api/equipment/:id/procurement", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin, deleteEquipmentProcurement)
+}
+
+func listVendors(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	vendors, err := repositories.ListVendors(c.UserContext(), businessID, c.Query("kind"))
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch vendors")
+	}
+
+	return c.JSON(vendors)
+}
+
+func createVendor(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateVendorRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	vendor := models.Vendor{
+		BusinessID:  businessID,
+		Name:        req.Name,
+		Kind:        req.Kind,
+		ContactName: req.ContactName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Website:     req.Website,
+		Notes:       req.Notes,
+	}
+	if err := repositories.CreateVendor(c.UserContext(), &vendor); err != nil {
+		return respondProcurementError(c, err, "could not create vendor")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(vendor)
+}
+
+func getVendor(c *fiber.Ctx) error {
+	vendor, err := vendorFromParams(c)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch vendor")
+	}
+
+	return c.JSON(vendor)
+}
+
+func updateVendor(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateVendorRequest)
+
+	vendor, err := vendorFromParams(c)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch vendor")
+	}
+
+	if req.Name != nil {
+		vendor.Name = *req.Name
+	}
+	if req.Kind != nil {
+		vendor.Kind = *req.Kind
+	}
+	if req.ContactName != nil {
+		vendor.ContactName = *req.ContactName
+	}
+	if req.Email != nil {
+		vendor.Email = *req.Email
+	}
+	if req.Phone != nil {
+		vendor.Phone = *req.Phone
+	}
+	if req.Website != nil {
+		vendor.Website = *req.Website
+	}
+	if req.Notes != nil {
+		vendor.Notes = *req.Notes
+	}
+
+	if err := repositories.UpdateVendor(c.UserContext(), vendor); err != nil {
+		return respondProcurementError(c, err, "could not update vendor")
+	}
+
+	return c.JSON(vendor)
+}
+
+func deleteVendor(c *fiber.Ctx) error {
+	vendor, err := vendorFromParams(c)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch vendor")
+	}
+
+	if err := repositories.DeleteVendor(c.UserContext(), vendor); err != nil {
+		return respondProcurementError(c, err, "could not delete vendor")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// getEquipmentProcurement answers "is it still under warranty?" for scanned
+// equipment: its procurement record, warranty badge and service contracts.
+func getEquipmentProcurement(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	procurement, err := repositories.GetEquipmentProcurement(c.UserContext(), eq.ID)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch procurement")
+	}
+
+	contracts, err := repositories.ListServiceContracts(c.UserContext(), eq.BusinessID, repositories.ServiceContractFilter{EquipmentID: &eq.ID})
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch service contracts")
+	}
+
+	var warranty *repositories.WarrantyBadge
+	if procurement != nil && procurement.WarrantyEnd != nil {
+		warranty = repositories.NewWarrantyBadge(procurement.WarrantyStart, *procurement.WarrantyEnd, time.Now())
+	}
+
+	return c.JSON(fiber.Map{
+		"procurement":      procurement,
+		"warranty":         warranty,
+		"serviceContracts": contracts,
+	})
+}
+
+func saveEquipmentProcurement(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.ProcurementRequest)
+
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	procurement := models.EquipmentProcurement{
+		BusinessID:     eq.BusinessID,
+		EquipmentID:    eq.ID,
+		ManufacturerID: optionalUUID(req.ManufacturerID),
+		SupplierID:     optionalUUID(req.SupplierID),
+		PurchaseOrder:  req.PurchaseOrder,
+		PurchaseDate:   optionalDate(req.PurchaseDate),
+		PurchaseCost:   req.PurchaseCost,
+		Currency:       req.Currency,
+		WarrantyStart:  optionalDate(req.WarrantyStart),
+		WarrantyEnd:    optionalDate(req.WarrantyEnd),
+		WarrantyTerms:  req.WarrantyTerms,
+	}
+
+	saved, err := repositories.SaveEquipmentProcurement(c.UserContext(), &procurement)
+	if err != nil {
+		return respondProcurementError(c, err, "could not save procurement")
+	}
+
+	return c.JSON(saved)
+}
+
+func deleteEquipmentProcurement(c *fiber.Ctx) error {
+	eq, err := equipmentFromParams(c)
+	if err != nil {
+		return respondEquipmentError(c, err, "failed to fetch equipment")
+	}
+
+	if err := repositories.DeleteEquipmentProcurement(c.UserContext(), eq.ID); err != nil {
+		return respondProcurementError(c, err, "could not delete procurement")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// listExpiringWarranties returns equipment whose warranty ends within the next
+// days days (30 by default), soonest first.
+func listExpiringWarranties(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	days, err := expiryWindow(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	procurements, err := repositories.ListExpiringWarranties(c.UserContext(), businessID, days)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch warranties")
+	}
+
+	return c.JSON(fiber.Map{
+		"days":       days,
+		"warranties": procurements,
+	})
+}
+
+// listServiceContracts returns the business's service contracts, filtered by
+// vendor_id or equipment_id. expiring=true narrows the list to contracts ending
+// within the next days days (30 by default), soonest first.
+func listServiceContracts(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	var filter repositories.ServiceContractFilter
+	var err error
+	if filter.VendorID, err = uuidQuery(c, "vendor_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.EquipmentID, err = uuidQuery(c, "equipment_id"); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if c.QueryBool("expiring") {
+		days, err := expiryWindow(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		filter.ExpiringWithin = &days
+	}
+
+	contracts, err := repositories.ListServiceContracts(c.UserContext(), businessID, filter)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch service contracts")
+	}
+
+	return c.JSON(contracts)
+}
+
+func createServiceContract(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateServiceContractRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	contract := models.ServiceContract{
+		BusinessID:   businessID,
+		VendorID:     uuid.MustParse(req.VendorID),
+		Title:        req.Title,
+		Reference:    req.Reference,
+		StartsOn:     *optionalDate(req.StartsOn),
+		EndsOn:       *optionalDate(req.EndsOn),
+		Terms:        req.Terms,
+		ResponseTime: req.ResponseTime,
+		Cost:         req.Cost,
+		Currency:     req.Currency,
+	}
+
+	detail, err := repositories.CreateServiceContract(c.UserContext(), &contract, parseUUIDs(req.EquipmentIDs))
+	if err != nil {
+		return respondProcurementError(c, err, "could not create service contract")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(detail)
+}
+
+func getServiceContract(c *fiber.Ctx) error {
+	detail, err := serviceContractFromParams(c)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch service contract")
+	}
+
+	return c.JSON(detail)
+}
+
+func updateServiceContract(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateServiceContractRequest)
+
+	detail, err := serviceContractFromParams(c)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch service contract")
+	}
+	contract := detail.ServiceContract
+
+	if req.VendorID != nil {
+		contract.VendorID = uuid.MustParse(*req.VendorID)
+	}
+	if req.Title != nil {
+		contract.Title = *req.Title
+	}
+	if req.Reference != nil {
+		contract.Reference = *req.Reference
+	}
+	if req.StartsOn != nil {
+		contract.StartsOn = *optionalDate(*req.StartsOn)
+	}
+	if req.EndsOn != nil {
+		contract.EndsOn = *optionalDate(*req.EndsOn)
+	}
+	if req.Terms != nil {
+		contract.Terms = *req.Terms
+	}
+	if req.ResponseTime != nil {
+		contract.ResponseTime = *req.ResponseTime
+	}
+	if req.Cost != nil {
+		contract.Cost = req.Cost
+	}
+	if req.Currency != nil {
+		contract.Currency = *req.Currency
+	}
+
+	var equipmentIDs *[]uuid.UUID
+	if req.EquipmentIDs != nil {
+		ids := parseUUIDs(*req.EquipmentIDs)
+		equipmentIDs = &ids
+	}
+
+	updated, err := repositories.UpdateServiceContract(c.UserContext(), &contract, equipmentIDs)
+	if err != nil {
+		return respondProcurementError(c, err, "could not update service contract")
+	}
+
+	return c.JSON(updated)
+}
+
+func deleteServiceContract(c *fiber.Ctx) error {
+	detail, err := serviceContractFromParams(c)
+	if err != nil {
+		return respondProcurementError(c, err, "failed to fetch service contract")
+	}
+
+	if err := repositories.DeleteServiceContract(c.UserContext(), &detail.ServiceContract); err != nil {
+		return respondProcurementError(c, err, "could not delete service contract")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func vendorFromParams(c *fiber.Ctx) (*models.Vendor, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrVendorNotFound
+	}
+
+	return repositories.GetVendor(c.UserContext(), businessID, id)
+}
+
+func serviceContractFromParams(c *fiber.Ctx) (*repositories.ServiceContractDetail, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrServiceContractNotFound
+	}
+
+	return repositories.GetServiceContract(c.UserContext(), businessID, id)
+}
+
+func expiryWindow(c *fiber.Ctx) (int, error) {
+	days, err := strconv.Atoi(c.Query("days", "30"))
+	if err != nil || days < 0 || days > maxExpiryWindowDays {
+		return 0, errors.New("days must be between 0 and 365")
+	}
+	return days, nil
+}
+
+// optionalUUID parses an ID that has already been validated, or returns nil
+// when it is empty.
+func optionalUUID(value string) *uuid.UUID {
+	if value == "" {
+		return nil
+	}
+	id := uuid.MustParse(value)
+	return &id
+}
+
+// optionalDate parses a YYYY-MM-DD date that has already been validated, or
+// returns nil when it is empty.
+func optionalDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
+func parseUUIDs(values []string) []uuid.UUID {
+	ids := make([]uuid.UUID, len(values))
+	for i, value := range values {
+		ids[i] = uuid.MustParse(value)
+	}
+	return ids
+}
+
+func respondProcurementError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrVendorNotFound),
+		errors.Is(err, repositories.ErrServiceContractNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrVendorDuplicate),
+		errors.Is(err, repositories.ErrVendorInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvalidVendorKind),
+		errors.Is(err, repositories.ErrInvalidWarrantyPeriod),
+		errors.Is(err, repositories.ErrInvalidContractPeriod):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return respondEquipmentError(c, err, fallback)
+	}
+}
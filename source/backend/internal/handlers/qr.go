@@ -29,7 +29,7 @@ func generateQRCodeZip(c *fiber.Ctx) error {
 		})
 	}
 
-	data, err := repositories.GenerateQRCodeZipBytes(body.EquipmentIDs)
+	data, err := repositories.GenerateQRCodeZipBytes(c.UserContext(), body.EquipmentIDs)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -59,7 +59,7 @@ func generateSingleQRCode(c *fiber.Ctx) error {
 		})
 	}
 
-	qrCode, filename, err := repositories.GenerateSingleQRCodeBytes(body.EquipmentID)
+	qrCode, filename, err := repositories.GenerateSingleQRCodeBytes(c.UserContext(), body.EquipmentID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -0,0 +1,805 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	scimContentType     = "application/scim+json"
+	scimUserSchema      = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema     = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema      = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema     = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimSPConfigSchema  = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimDefaultPageSize = 100
+	scimMaxPageSize     = 200
+)
+
+// scimEqFilter matches the only filter form identity providers rely on: attr eq "value".
+var scimEqFilter = regexp.MustCompile(`(?i)^\s*([a-zA-Z.]+)\s+eq\s+"([^"]*)"\s*$`)
+
+// scimMemberFilter matches a PATCH path such as members[value eq "id"].
+var scimMemberFilter = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+type scimMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimUserResource struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id,omitempty"`
+	ExternalID  string            `json:"externalId,omitempty"`
+	UserName    string            `json:"userName"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Emails      []scimMultiValue  `json:"emails,omitempty"`
+	Active      *bool             `json:"active,omitempty"`
+	Roles       []scimMultiValue  `json:"roles,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
+}
+
+type scimGroupResource struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id,omitempty"`
+	ExternalID  string            `json:"externalId,omitempty"`
+	DisplayName string            `json:"displayName"`
+	Members     []scimMultiValue  `json:"members"`
+	Meta        map[string]string `json:"meta,omitempty"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string `json:"schemas"`
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+func RegisterSCIMRoutes(app *fiber.App) {
+	tokens := app.Group("/api/scim/tokens", middleware.RequireUser, middleware.RequireBusiness, middleware.RequireBusinessAdmin)
+	tokens.Get("/", listSCIMTokens)
+	tokens.Post("/", utils.ValidateBody[utils.CreateSCIMTokenRequest](), createSCIMToken)
+	tokens.Delete("/:id", revokeSCIMToken)
+
+	scim := app.Group("/scim/v2", middleware.RequireSCIMToken)
+	scim.Get("/ServiceProviderConfig", scimServiceProviderConfig)
+
+	scim.Get("/Users", scimListUsers)
+	scim.Post("/Users", scimCreateUser)
+	scim.Get("/Users/:id", scimGetUser)
+	scim.Put("/Users/:id", scimReplaceUser)
+	scim.Patch("/Users/:id", scimPatchUser)
+	scim.Delete("/Users/:id", scimDeleteUser)
+
+	scim.Get("/Groups", scimListGroups)
+	scim.Post("/Groups", scimCreateGroup)
+	scim.Get("/Groups/:id", scimGetGroup)
+	scim.Put("/Groups/:id", scimReplaceGroup)
+	scim.Patch("/Groups/:id", scimPatchGroup)
+	scim.Delete("/Groups/:id", scimDeleteGroup)
+}
+
+// ─────────────────────────────────────────────
+// Token management
+// ─────────────────────────────────────────────
+
+func listSCIMTokens(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	tokens, err := repositories.GetSCIMTokens(c.UserContext(), businessID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch SCIM tokens",
+		})
+	}
+
+	return c.JSON(tokens)
+}
+
+func createSCIMToken(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateSCIMTokenRequest)
+	user := c.Locals("user").(*models.User)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	plaintext, token, err := repositories.CreateSCIMToken(c.UserContext(), businessID, req.Name, user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not create SCIM token",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"token":    plaintext, // only shown once
+		"details":  token,
+		"scimBase": utils.AppConfig.BaseURL + "/scim/v2",
+	})
+}
+
+func revokeSCIMToken(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	tokenID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid token ID",
+		})
+	}
+
+	if err := repositories.RevokeSCIMToken(c.UserContext(), businessID, tokenID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "token not found",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ─────────────────────────────────────────────
+// SCIM discovery
+// ─────────────────────────────────────────────
+
+func scimServiceProviderConfig(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"schemas":        []string{scimSPConfigSchema},
+		"patch":          fiber.Map{"supported": true},
+		"bulk":           fiber.Map{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         fiber.Map{"supported": true, "maxResults": scimMaxPageSize},
+		"changePassword": fiber.Map{"supported": false},
+		"sort":           fiber.Map{"supported": false},
+		"etag":           fiber.Map{"supported": false},
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Business-scoped SCIM token issued from EquipQR",
+			"primary":     true,
+		}},
+	}, scimContentType)
+}
+
+// ─────────────────────────────────────────────
+// Users
+// ─────────────────────────────────────────────
+
+func scimListUsers(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	userName, err := scimFilterValue(c.Query("filter"), "userName", "emails.value")
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidFilter", err.Error())
+	}
+
+	startIndex, count := scimPagination(c)
+	memberships, total, err := repositories.ListSCIMUsers(c.UserContext(), businessID, userName, startIndex-1, count)
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", "failed to list users")
+	}
+
+	resources := make([]scimUserResource, 0, len(memberships))
+	for i := range memberships {
+		resources = append(resources, toSCIMUser(&memberships[i]))
+	}
+
+	return scimList(c, resources, total, startIndex)
+}
+
+func scimGetUser(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", repositories.ErrSCIMUserNotFound.Error())
+	}
+
+	membership, err := repositories.GetSCIMUser(c.UserContext(), businessID, userID)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return c.JSON(toSCIMUser(membership), scimContentType)
+}
+
+func scimCreateUser(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	var resource scimUserResource
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "invalid JSON")
+	}
+
+	input, err := scimUserInput(resource)
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", err.Error())
+	}
+
+	membership, err := repositories.ProvisionSCIMUser(c.UserContext(), businessID, input)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toSCIMUser(membership), scimContentType)
+}
+
+func scimReplaceUser(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", repositories.ErrSCIMUserNotFound.Error())
+	}
+
+	var resource scimUserResource
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "invalid JSON")
+	}
+
+	input, err := scimUserInput(resource)
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", err.Error())
+	}
+
+	membership, err := repositories.UpdateSCIMUser(c.UserContext(), businessID, userID, input)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return c.JSON(toSCIMUser(membership), scimContentType)
+}
+
+func scimPatchUser(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", repositories.ErrSCIMUserNotFound.Error())
+	}
+
+	var patch scimPatchRequest
+	if err := json.Unmarshal(c.Body(), &patch); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "invalid JSON")
+	}
+
+	membership, err := repositories.GetSCIMUser(c.UserContext(), businessID, userID)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	input := repositories.SCIMUserInput{
+		UserName:   scimUserName(membership),
+		Email:      membership.User.Email,
+		ExternalID: membership.ExternalID,
+		Active:     membership.DeactivatedAt == nil,
+		IsAdmin:    membership.IsAdmin,
+	}
+
+	for _, op := range patch.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return scimError(c, fiber.StatusBadRequest, "invalidValue", "unsupported operation: "+op.Op)
+		}
+
+		values := map[string]json.RawMessage{}
+		if op.Path == "" {
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return scimError(c, fiber.StatusBadRequest, "invalidValue", "operation value must be an object")
+			}
+		} else {
+			values[op.Path] = op.Value
+		}
+
+		for path, value := range values {
+			if err := applySCIMUserValue(&input, path, value); err != nil {
+				return scimError(c, fiber.StatusBadRequest, "invalidValue", err.Error())
+			}
+		}
+	}
+
+	membership, err = repositories.UpdateSCIMUser(c.UserContext(), businessID, userID, input)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return c.JSON(toSCIMUser(membership), scimContentType)
+}
+
+func scimDeleteUser(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", repositories.ErrSCIMUserNotFound.Error())
+	}
+
+	if err := repositories.RemoveSCIMUser(c.UserContext(), businessID, userID); err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func applySCIMUserValue(input *repositories.SCIMUserInput, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "active":
+		active, err := scimBool(value)
+		if err != nil {
+			return err
+		}
+		input.Active = active
+	case "username":
+		return json.Unmarshal(value, &input.UserName)
+	case "externalid":
+		return json.Unmarshal(value, &input.ExternalID)
+	case "emails":
+		var emails []scimMultiValue
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return errors.New("emails must be a list")
+		}
+		if email := primarySCIMValue(emails); email != "" {
+			input.Email = email
+		}
+	case `emails[type eq "work"].value`, "emails.value":
+		return json.Unmarshal(value, &input.Email)
+	case "roles":
+		var roles []scimMultiValue
+		if err := json.Unmarshal(value, &roles); err != nil {
+			return errors.New("roles must be a list")
+		}
+		input.IsAdmin = hasSCIMAdminRole(roles)
+	default:
+		// Attributes EquipQR does not store (name, title, ...) are accepted and ignored.
+	}
+	return nil
+}
+
+func scimUserInput(resource scimUserResource) (repositories.SCIMUserInput, error) {
+	input := repositories.SCIMUserInput{
+		UserName:   strings.TrimSpace(resource.UserName),
+		Email:      primarySCIMValue(resource.Emails),
+		ExternalID: resource.ExternalID,
+		Active:     resource.Active == nil || *resource.Active,
+		IsAdmin:    hasSCIMAdminRole(resource.Roles),
+	}
+
+	if input.UserName == "" {
+		return input, errors.New("userName is required")
+	}
+	if input.Email == "" && strings.Contains(input.UserName, "@") {
+		input.Email = input.UserName
+	}
+	if input.Email == "" {
+		return input, errors.New("an email address is required")
+	}
+	return input, nil
+}
+
+func toSCIMUser(membership *models.UserBusiness) scimUserResource {
+	active := membership.DeactivatedAt == nil
+	role := "member"
+	if membership.IsAdmin {
+		role = "admin"
+	}
+
+	return scimUserResource{
+		Schemas:     []string{scimUserSchema},
+		ID:          membership.UserID.String(),
+		ExternalID:  membership.ExternalID,
+		UserName:    scimUserName(membership),
+		DisplayName: membership.User.Username,
+		Emails:      []scimMultiValue{{Value: membership.User.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Roles:       []scimMultiValue{{Value: role, Primary: true}},
+		Meta: map[string]string{
+			"resourceType": "User",
+			"location":     utils.AppConfig.BaseURL + "/scim/v2/Users/" + membership.UserID.String(),
+		},
+	}
+}
+
+// scimUserName is the name the business's directory knows the member by.
+// Members it did not provision are listed under their account's username.
+func scimUserName(membership *models.UserBusiness) string {
+	if membership.UserName != "" {
+		return membership.UserName
+	}
+	return membership.User.Username
+}
+
+// ─────────────────────────────────────────────
+// Groups (teams)
+// ─────────────────────────────────────────────
+
+func scimListGroups(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	displayName, err := scimFilterValue(c.Query("filter"), "displayName")
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidFilter", err.Error())
+	}
+
+	startIndex, count := scimPagination(c)
+	teams, total, err := repositories.ListSCIMGroups(c.UserContext(), businessID, displayName, startIndex-1, count)
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", "failed to list groups")
+	}
+
+	excludeMembers := strings.Contains(c.Query("excludedAttributes"), "members")
+	resources := make([]scimGroupResource, 0, len(teams))
+	for i := range teams {
+		resource, err := toSCIMGroup(c.UserContext(), &teams[i], !excludeMembers)
+		if err != nil {
+			return scimError(c, fiber.StatusInternalServerError, "", "failed to list groups")
+		}
+		resources = append(resources, resource)
+	}
+
+	return scimList(c, resources, total, startIndex)
+}
+
+func scimGetGroup(c *fiber.Ctx) error {
+	team, err := scimGroupFromParams(c)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return scimGroupResponse(c, fiber.StatusOK, team)
+}
+
+func scimCreateGroup(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	var resource scimGroupResource
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "invalid JSON")
+	}
+	if strings.TrimSpace(resource.DisplayName) == "" {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	team, err := repositories.CreateSCIMGroup(c.UserContext(), businessID, repositories.SCIMGroupInput{
+		DisplayName: strings.TrimSpace(resource.DisplayName),
+		ExternalID:  resource.ExternalID,
+		MemberIDs:   scimMemberIDs(resource.Members),
+	})
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return scimGroupResponse(c, fiber.StatusCreated, team)
+}
+
+func scimReplaceGroup(c *fiber.Ctx) error {
+	team, err := scimGroupFromParams(c)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	var resource scimGroupResource
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "invalid JSON")
+	}
+
+	err = repositories.ReplaceSCIMGroup(c.UserContext(), team, repositories.SCIMGroupInput{
+		DisplayName: strings.TrimSpace(resource.DisplayName),
+		ExternalID:  resource.ExternalID,
+		MemberIDs:   scimMemberIDs(resource.Members),
+	})
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return scimGroupResponse(c, fiber.StatusOK, team)
+}
+
+func scimPatchGroup(c *fiber.Ctx) error {
+	team, err := scimGroupFromParams(c)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	var patch scimPatchRequest
+	if err := json.Unmarshal(c.Body(), &patch); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "invalid JSON")
+	}
+
+	changes := make([]repositories.SCIMGroupChange, 0, len(patch.Operations))
+	for _, op := range patch.Operations {
+		change, err := scimGroupChange(strings.ToLower(op.Op), op.Path, op.Value)
+		if err != nil {
+			return scimError(c, fiber.StatusBadRequest, "invalidValue", err.Error())
+		}
+		changes = append(changes, change)
+	}
+
+	if err := repositories.PatchSCIMGroup(c.UserContext(), team, changes); err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	// Identity providers usually ignore the body of a PATCH response.
+	if c.Query("attributes") == "" && len(patch.Operations) > 0 {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	return scimGroupResponse(c, fiber.StatusOK, team)
+}
+
+func scimDeleteGroup(c *fiber.Ctx) error {
+	team, err := scimGroupFromParams(c)
+	if err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	if err := repositories.DeleteTeam(c.UserContext(), team.BusinessID, team.ID); err != nil {
+		return respondSCIMError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// scimGroupChange translates one PATCH operation on a group into the change
+// PatchSCIMGroup applies, so every operation is validated before any is saved.
+func scimGroupChange(op string, path string, value json.RawMessage) (repositories.SCIMGroupChange, error) {
+	var change repositories.SCIMGroupChange
+
+	if match := scimMemberFilter.FindStringSubmatch(path); match != nil {
+		if op != "remove" {
+			return change, errors.New("member filters are only supported for remove")
+		}
+		if id, err := uuid.Parse(match[1]); err == nil {
+			change.RemoveMembers = []uuid.UUID{id}
+		}
+		return change, nil
+	}
+
+	switch strings.ToLower(path) {
+	case "members":
+		var members []scimMultiValue
+		if len(value) > 0 {
+			if err := json.Unmarshal(value, &members); err != nil {
+				return change, errors.New("members must be a list")
+			}
+		}
+		ids := scimMemberIDs(members)
+
+		switch op {
+		case "add":
+			change.AddMembers = ids
+		case "remove":
+			if len(value) == 0 {
+				change.ReplaceMembers = true
+			} else {
+				change.RemoveMembers = ids
+			}
+		case "replace":
+			change.ReplaceMembers = true
+			change.AddMembers = ids
+		default:
+			return change, errors.New("unsupported operation: " + op)
+		}
+		return change, nil
+	case "displayname":
+		var name string
+		if err := json.Unmarshal(value, &name); err != nil || strings.TrimSpace(name) == "" {
+			return change, errors.New("displayName must be a non-empty string")
+		}
+		name = strings.TrimSpace(name)
+		change.DisplayName = &name
+		return change, nil
+	case "externalid":
+		var externalID string
+		if err := json.Unmarshal(value, &externalID); err != nil {
+			return change, errors.New("externalId must be a string")
+		}
+		change.ExternalID = &externalID
+		return change, nil
+	case "":
+		var resource scimGroupResource
+		if err := json.Unmarshal(value, &resource); err != nil {
+			return change, errors.New("operation value must be an object")
+		}
+		if resource.Members != nil {
+			var err error
+			if change, err = scimGroupChange(op, "members", mustMarshal(resource.Members)); err != nil {
+				return change, err
+			}
+		}
+		if name := strings.TrimSpace(resource.DisplayName); name != "" {
+			change.DisplayName = &name
+		}
+		return change, nil
+	}
+
+	return change, errors.New("unsupported path: " + path)
+}
+
+func scimGroupFromParams(c *fiber.Ctx) (*models.Team, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrSCIMGroupNotFound
+	}
+
+	return repositories.GetSCIMGroup(c.UserContext(), businessID, teamID)
+}
+
+func scimGroupResponse(c *fiber.Ctx, status int, team *models.Team) error {
+	resource, err := toSCIMGroup(c.UserContext(), team, true)
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", "failed to load group members")
+	}
+	return c.Status(status).JSON(resource, scimContentType)
+}
+
+func toSCIMGroup(ctx context.Context, team *models.Team, includeMembers bool) (scimGroupResource, error) {
+	resource := scimGroupResource{
+		Schemas:     []string{scimGroupSchema},
+		ID:          team.ID.String(),
+		ExternalID:  team.ExternalID,
+		DisplayName: team.Name,
+		Members:     []scimMultiValue{},
+		Meta: map[string]string{
+			"resourceType": "Group",
+			"location":     utils.AppConfig.BaseURL + "/scim/v2/Groups/" + team.ID.String(),
+		},
+	}
+
+	if !includeMembers {
+		return resource, nil
+	}
+
+	members, err := repositories.GetTeamMembers(ctx, team.ID)
+	if err != nil {
+		return resource, err
+	}
+	for _, m := range members {
+		resource.Members = append(resource.Members, scimMultiValue{
+			Value:   m.UserID.String(),
+			Display: m.User.Username,
+		})
+	}
+	return resource, nil
+}
+
+// ─────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────
+
+func scimList[T any](c *fiber.Ctx, resources []T, total int64, startIndex int) error {
+	return c.JSON(fiber.Map{
+		"schemas":      []string{scimListSchema},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	}, scimContentType)
+}
+
+func scimPagination(c *fiber.Ctx) (int, int) {
+	startIndex, err := strconv.Atoi(c.Query("startIndex", "1"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+
+	count, err := strconv.Atoi(c.Query("count", strconv.Itoa(scimDefaultPageSize)))
+	if err != nil || count < 0 {
+		count = scimDefaultPageSize
+	}
+	if count > scimMaxPageSize {
+		count = scimMaxPageSize
+	}
+
+	return startIndex, count
+}
+
+// scimFilterValue extracts the value of an `attr eq "value"` filter for one of the
+// supported attributes. An empty filter yields an empty value.
+func scimFilterValue(filter string, attributes ...string) (string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", nil
+	}
+
+	match := scimEqFilter.FindStringSubmatch(filter)
+	if match == nil {
+		return "", errors.New("only 'attribute eq \"value\"' filters are supported")
+	}
+
+	for _, attribute := range attributes {
+		if strings.EqualFold(match[1], attribute) {
+			return match[2], nil
+		}
+	}
+	return "", errors.New("filtering on " + match[1] + " is not supported")
+}
+
+func scimError(c *fiber.Ctx, status int, scimType string, detail string) error {
+	body := fiber.Map{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	}
+	if scimType != "" {
+		body["scimType"] = scimType
+	}
+	return c.Status(status).JSON(body, scimContentType)
+}
+
+func respondSCIMError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrSCIMUserNotFound),
+		errors.Is(err, repositories.ErrSCIMGroupNotFound),
+		errors.Is(err, repositories.ErrTeamNotFound):
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	case errors.Is(err, repositories.ErrSCIMUserConflict):
+		return scimError(c, fiber.StatusConflict, "uniqueness", err.Error())
+	default:
+		return scimError(c, fiber.StatusInternalServerError, "", "internal error")
+	}
+}
+
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+
+	// Some identity providers send booleans as strings ("False").
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if parsed, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return parsed, nil
+		}
+	}
+	return false, errors.New("active must be a boolean")
+}
+
+func primarySCIMValue(values []scimMultiValue) string {
+	for _, v := range values {
+		if v.Primary && v.Value != "" {
+			return strings.TrimSpace(v.Value)
+		}
+	}
+	for _, v := range values {
+		if v.Value != "" {
+			return strings.TrimSpace(v.Value)
+		}
+	}
+	return ""
+}
+
+func hasSCIMAdminRole(roles []scimMultiValue) bool {
+	for _, role := range roles {
+		if strings.EqualFold(role.Value, "admin") {
+			return true
+		}
+	}
+	return false
+}
+
+func scimMemberIDs(members []scimMultiValue) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		if id, err := uuid.Parse(m.Value); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func mustMarshal(v any) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
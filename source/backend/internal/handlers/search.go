@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSearchPageSize caps how many results a single search page can return.
+const maxSearchPageSize = 50
+
+func RegisterSearchRoutes(app *fiber.App) {
+	app.Get("/api/search", middleware.RequireUser, middleware.RequireBusiness, search)
+}
+
+// search ranks the active business's equipment and issues against ?q=. Results
+// can be narrowed with kind=equipment|issue and field.<name>=<value> parameters.
+func search(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	query := strings.TrimSpace(c.Query("q"))
+	if len(repositories.SearchWords(query)) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "q must contain at least one word",
+		})
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid page number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid limit number",
+		})
+	}
+	if limit > maxSearchPageSize {
+		limit = maxSearchPageSize
+	}
+
+	filter := repositories.SearchFilter{
+		Query:  query,
+		Kind:   c.Query("kind"),
+		Fields: map[string]string{},
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+
+	switch filter.Kind {
+	case "", repositories.SearchKindEquipment, repositories.SearchKindIssue:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "kind must be equipment or issue",
+		})
+	}
+
+	for key, value := range c.Queries() {
+		if name, ok := strings.CutPrefix(key, "field."); ok && name != "" {
+			filter.Fields[name] = value
+		}
+	}
+
+	results, total, err := repositories.Search(c.UserContext(), businessID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "search failed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"query":   query,
+		"results": results,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
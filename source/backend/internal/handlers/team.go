@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func RegisterTeamRoutes(app *fiber.App) {
+	teams := app.Group("/api/teams", middleware.RequireUser, middleware.RequireBusiness)
+
+	teams.Get("/", listTeams)
+	teams.Get("/:id/members", listTeamMembers)
+	teams.Get("/:id/issues", getTeamQueue)
+	teams.Post("/", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.CreateTeamRequest](), createTeam)
+	teams.Patch("/:id", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.UpdateTeamRequest](), updateTeam)
+	teams.Delete("/:id", middleware.RequireBusinessAdmin, deleteTeam)
+	teams.Post("/:id/members", middleware.RequireBusinessAdmin, utils.ValidateBody[utils.AddTeamMemberRequest](), addTeamMember)
+	teams.Delete("/:id/members/:userID", middleware.RequireBusinessAdmin, removeTeamMember)
+}
+
+func listTeams(c *fiber.Ctx) error {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	teams, err := repositories.GetTeamsForBusiness(c.UserContext(), businessID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch teams",
+		})
+	}
+
+	return c.JSON(teams)
+}
+
+func createTeam(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.CreateTeamRequest)
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	team := models.Team{
+		BusinessID:  businessID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := repositories.CreateTeam(c.UserContext(), &team); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not create team",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(team)
+}
+
+func updateTeam(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.UpdateTeamRequest)
+
+	team, err := teamFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch team")
+	}
+
+	if req.Name != "" {
+		team.Name = req.Name
+	}
+	if req.Description != nil {
+		team.Description = *req.Description
+	}
+
+	if err := repositories.UpdateTeam(c.UserContext(), team); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "could not update team",
+		})
+	}
+
+	return c.JSON(team)
+}
+
+func deleteTeam(c *fiber.Ctx) error {
+	team, err := teamFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch team")
+	}
+
+	if err := repositories.DeleteTeam(c.UserContext(), team.BusinessID, team.ID); err != nil {
+		return respondTeamError(c, err, "could not delete team")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func listTeamMembers(c *fiber.Ctx) error {
+	team, err := teamFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch team")
+	}
+
+	members, err := repositories.GetTeamMembers(c.UserContext(), team.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch team members",
+		})
+	}
+
+	return c.JSON(members)
+}
+
+func addTeamMember(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.AddTeamMemberRequest)
+
+	team, err := teamFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch team")
+	}
+
+	if err := repositories.AddTeamMember(c.UserContext(), team, uuid.MustParse(req.UserID)); err != nil {
+		return respondTeamError(c, err, "could not add team member")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func removeTeamMember(c *fiber.Ctx) error {
+	team, err := teamFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch team")
+	}
+
+	userID, err := uuid.Parse(c.Params("userID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid user ID",
+		})
+	}
+
+	if err := repositories.RemoveTeamMember(c.UserContext(), team.ID, userID); err != nil {
+		return respondTeamError(c, err, "could not remove team member")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func getTeamQueue(c *fiber.Ctx) error {
+	team, err := teamFromParams(c)
+	if err != nil {
+		return respondTeamError(c, err, "failed to fetch team")
+	}
+
+	issues, err := repositories.GetTeamQueue(c.UserContext(), team.ID, c.QueryBool("unclaimed", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch team issues",
+		})
+	}
+
+	return c.JSON(issues)
+}
+
+func teamFromParams(c *fiber.Ctx) (*models.Team, error) {
+	businessID, _ := middleware.ActiveBusinessID(c)
+
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, repositories.ErrTeamNotFound
+	}
+
+	return repositories.GetTeamByID(c.UserContext(), businessID, teamID)
+}
+
+func respondTeamError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, repositories.ErrTeamNotFound),
+		errors.Is(err, repositories.ErrIssueNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrNotTeamMember):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrNotBusinessUser),
+		errors.Is(err, repositories.ErrIssueHasNoTeam),
+		errors.Is(err, repositories.ErrIssueAlreadyDone),
+		errors.Is(err, repositories.ErrIssueClaimed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fallback})
+	}
+}
@@ -1,23 +1,29 @@
 package handlers
 
 import (
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/middleware"
 	"github.com/EquipQR/equipqr/backend/internal/repositories"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 func RegisterUserRoutes(app *fiber.App) {
-	app.Post("/api/auth/login", utils.ValidateBody[utils.LoginRequest](), handleLogin)
+	app.Post("/api/auth/login", middleware.SystemScope, utils.ValidateBody[utils.LoginRequest](), handleLogin)
 	app.Post("/api/auth/logout", handleLogout)
-	app.Post("/api/auth/register", utils.ValidateBody[utils.CreateUserRequest](), handleRegister)
-	app.Get("/api/user", middleware.RequireUser, handleGetUser)
+	app.Post("/api/auth/register", middleware.SystemScope, utils.ValidateBody[utils.CreateUserRequest](), handleRegister)
+	app.Get("/api/auth/verify-email", middleware.SystemScope, handleVerifyEmail)
+	app.Post("/api/auth/verify-email/resend", middleware.RequireUser, handleResendVerification)
+	app.Post("/api/auth/switch-business", middleware.RequireUser, utils.ValidateBody[utils.SwitchBusinessRequest](), handleSwitchBusiness)
+	app.Get("/api/user", middleware.RequireUser, middleware.ResolveBusiness, handleGetUser)
+	app.Get("/api/user/businesses", middleware.RequireUser, handleGetUserBusinesses)
 }
 
 func handleLogin(c *fiber.Ctx) error {
 	req := c.Locals("body").(utils.LoginRequest)
 
-	user, err := repositories.GetUserByEmail(req.Email)
+	user, err := repositories.GetUserByEmail(c.UserContext(), req.Email)
 	if err != nil || user == nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "invalid credentials",
@@ -31,6 +37,18 @@ func handleLogin(c *fiber.Ctx) error {
 		})
 	}
 
+	if !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "account is deactivated",
+		})
+	}
+
+	if blocked, err := repositories.UserLoginBlocked(c.UserContext(), user.ID); err != nil || blocked {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": repositories.ErrBusinessSuspended.Error(),
+		})
+	}
+
 	signedToken, err := utils.GenerateJWT(user.ID.String())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -56,7 +74,7 @@ func handleLogout(c *fiber.Ctx) error {
 func handleRegister(c *fiber.Ctx) error {
 	req := c.Locals("body").(utils.CreateUserRequest)
 
-	user, business, token, err := repositories.RegisterNewUser(req)
+	user, business, token, err := repositories.RegisterNewUser(c.UserContext(), req)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -81,7 +99,131 @@ func handleRegister(c *fiber.Ctx) error {
 
 func handleGetUser(c *fiber.Ctx) error {
 	user := c.Locals("user")
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"user": user,
+	}
+	if businessID, ok := middleware.ActiveBusinessID(c); ok {
+		response["activeBusinessId"] = businessID
+	}
+	return c.JSON(response)
+}
+
+func handleGetUserBusinesses(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+
+	memberships, err := repositories.GetMembershipsForUser(c.UserContext(), user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to fetch businesses",
+		})
+	}
+
+	businesses := make([]fiber.Map, 0, len(memberships))
+	for _, m := range memberships {
+		businesses = append(businesses, fiber.Map{
+			"id":           m.BusinessID,
+			"businessName": m.Business.BusinessName,
+			"businessType": m.Business.Type,
+			"isAdmin":      m.IsAdmin,
+		})
+	}
+
+	return c.JSON(businesses)
+}
+
+func handleSwitchBusiness(c *fiber.Ctx) error {
+	req := c.Locals("body").(utils.SwitchBusinessRequest)
+	user := c.Locals("user").(*models.User)
+
+	businessID, err := uuid.Parse(req.BusinessID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid business ID",
+		})
+	}
+
+	membership, err := repositories.GetUserBusinessMembership(c.UserContext(), user.ID, businessID)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "not a member of this business",
+		})
+	}
+
+	business, err := repositories.GetBusinessForLifecycle(c.UserContext(), businessID)
+	if err != nil || business.Status == models.BusinessStatusDeleted {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "business not found",
+		})
+	}
+	if business.Status == models.BusinessStatusSuspended {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": repositories.ErrBusinessSuspended.Error(),
+		})
+	}
+
+	signedToken, err := utils.GenerateJWTForBusiness(user.ID.String(), businessID.String())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to generate token",
+		})
+	}
+
+	utils.SetOrRemoveSessionCookie(c, signedToken)
+
+	return c.JSON(fiber.Map{
+		"message":          "active business switched",
+		"activeBusinessId": businessID,
+		"isAdmin":          membership.IsAdmin,
 	})
 }
+
+func handleVerifyEmail(c *fiber.Ctx) error {
+	params := utils.EmailVerificationParams{
+		UserID:    c.Query("user"),
+		Email:     c.Query("email"),
+		Expiry:    c.Query("exp"),
+		Signature: c.Query("sig"),
+	}
+
+	if params.UserID == "" || params.Email == "" || params.Expiry == "" || params.Signature == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "missing verification parameters",
+		})
+	}
+
+	user, rule, err := repositories.ProcessEmailVerification(c.UserContext(), params)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := fiber.Map{
+		"message": "email verified",
+		"user":    user,
+	}
+	if rule != nil {
+		response["businessId"] = rule.BusinessID
+		response["joinMode"] = rule.Mode
+	}
+
+	return c.JSON(response)
+}
+
+func handleResendVerification(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+
+	if user.EmailVerifiedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "email address is already verified",
+		})
+	}
+
+	if err := repositories.SendEmailVerification(user); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to send verification email",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
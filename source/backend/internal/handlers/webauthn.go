@@ -1,13 +1,14 @@
 package handlers
 
 import (
+	"github.com/EquipQR/equipqr/backend/internal/middleware"
 	"github.com/EquipQR/equipqr/backend/internal/repositories"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
 func RegisterWebAuthnRoutes(app *fiber.App) {
-	webAuthn := app.Group("/api/auth/webauthn")
+	webAuthn := app.Group("/api/auth/webauthn", middleware.SystemScope)
 
 	webAuthn.Post("/register/begin", beginRegistration)
 	webAuthn.Post("/register/finish", finishRegistration)
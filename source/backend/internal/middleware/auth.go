@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/repositories"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 func RequireUser(c *fiber.Ctx) error {
@@ -21,13 +24,37 @@ func RequireUser(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := repositories.GetUserByID(userID)
+	user, err := repositories.GetUserByID(c.UserContext(), userID)
 	if err != nil || user == nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "user not found",
 		})
 	}
 
+	if !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "account is deactivated",
+		})
+	}
+
 	c.Locals("user", user)
+	return withTenantScope(c, user.ID, uuid.Nil)
+}
+
+// RequirePlatformAdmin rejects the request unless the user is a platform operator,
+// and otherwise lifts the request's row-level security scope, since operators
+// act across businesses. Must run after RequireUser.
+func RequirePlatformAdmin(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+	if !utils.AppConfig.IsPlatformAdmin(user.Email) || user.EmailVerifiedAt == nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "platform admin access required",
+		})
+	}
+	if err := database.BypassTenantScope(c.UserContext()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to scope request",
+		})
+	}
 	return c.Next()
 }
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// BusinessHeader lets a client pick the business for a single request
+// without switching the session-wide selection.
+const BusinessHeader = "X-Business-ID"
+
+// ResolveBusiness determines the active business for the current user and stores
+// it in c.Locals("business_id") together with the membership in c.Locals("membership").
+//
+// The X-Business-ID header takes precedence over the business stored in the session.
+// When neither is present and the user belongs to exactly one business, that business is used.
+// Requests naming a business the user does not belong to are rejected; a session
+// whose business the user no longer belongs to falls back as if none were stored.
+// Must run after RequireUser.
+func ResolveBusiness(c *fiber.Ctx) error {
+	membership, err := resolveMembership(c)
+	if err != nil {
+		return RespondFiberError(c, err)
+	}
+
+	if membership != nil {
+		if err := ScopeBusiness(c, membership.BusinessID); err != nil {
+			return RespondFiberError(c, err)
+		}
+		c.Locals("business_id", membership.BusinessID)
+		c.Locals("membership", membership)
+	}
+	return c.Next()
+}
+
+// RequireBusiness behaves like ResolveBusiness but rejects the request when no
+// active business could be determined.
+func RequireBusiness(c *fiber.Ctx) error {
+	membership, err := resolveMembership(c)
+	if err != nil {
+		return RespondFiberError(c, err)
+	}
+
+	if membership == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "no active business selected",
+		})
+	}
+
+	if err := ScopeBusiness(c, membership.BusinessID); err != nil {
+		return RespondFiberError(c, err)
+	}
+
+	c.Locals("business_id", membership.BusinessID)
+	c.Locals("membership", membership)
+	return c.Next()
+}
+
+// ActiveBusinessID returns the business resolved by ResolveBusiness or RequireBusiness.
+func ActiveBusinessID(c *fiber.Ctx) (uuid.UUID, bool) {
+	id, ok := c.Locals("business_id").(uuid.UUID)
+	return id, ok
+}
+
+func resolveMembership(c *fiber.Ctx) (*models.UserBusiness, error) {
+	user := c.Locals("user").(*models.User)
+
+	if selected := c.Get(BusinessHeader); selected != "" {
+		businessID, err := uuid.Parse(selected)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid business ID")
+		}
+		membership, err := repositories.GetUserBusinessMembership(c.UserContext(), user.ID, businessID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusForbidden, "not a member of this business")
+		}
+		if err := CheckBusinessStatus(c, businessID); err != nil {
+			return nil, err
+		}
+		return membership, nil
+	}
+
+	// The session keeps the business it was issued for. When the user has since
+	// left or been removed from it, the claim is stale and ignored rather than
+	// locking the user out of switching to another business.
+	if businessID, ok := sessionBusinessID(c); ok {
+		membership, err := repositories.GetUserBusinessMembership(c.UserContext(), user.ID, businessID)
+		if err == nil {
+			if err := CheckBusinessStatus(c, businessID); err != nil {
+				return nil, err
+			}
+			return membership, nil
+		}
+	}
+
+	memberships, err := repositories.GetMembershipsForUser(c.UserContext(), user.ID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to load memberships")
+	}
+	if len(memberships) == 1 {
+		if err := CheckBusinessStatus(c, memberships[0].BusinessID); err != nil {
+			return nil, err
+		}
+		return &memberships[0], nil
+	}
+	return nil, nil
+}
+
+func sessionBusinessID(c *fiber.Ctx) (uuid.UUID, bool) {
+	claims, err := utils.GetSessionClaims(c)
+	if err != nil || claims.BusinessID == "" {
+		return uuid.Nil, false
+	}
+	businessID, err := uuid.Parse(claims.BusinessID)
+	return businessID, err == nil
+}
+
+// CheckBusinessStatus rejects requests for deleted businesses and anything but
+// reads for suspended ones.
+func CheckBusinessStatus(c *fiber.Ctx, businessID uuid.UUID) error {
+	business, err := repositories.GetBusinessForLifecycle(c.UserContext(), businessID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "business not found")
+	}
+
+	switch business.Status {
+	case models.BusinessStatusDeleted:
+		return fiber.NewError(fiber.StatusGone, "business has been deleted")
+	case models.BusinessStatusSuspended:
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return nil
+		}
+		return fiber.NewError(fiber.StatusLocked, "business is suspended")
+	}
+	return nil
+}
+
+// RespondFiberError writes err as a JSON error response, using the status of a
+// *fiber.Error and 500 for anything else.
+func RespondFiberError(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"error": fiberErr.Message,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// RequireBusinessAdmin rejects the request unless the user is an admin of the
+// active business. Must run after RequireBusiness.
+func RequireBusinessAdmin(c *fiber.Ctx) error {
+	membership, ok := c.Locals("membership").(*models.UserBusiness)
+	if !ok || !membership.IsAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "business admin access required",
+		})
+	}
+	return c.Next()
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequireSCIMToken authenticates SCIM provisioning requests with a business-scoped
+// bearer token and stores the business in c.Locals("business_id").
+func RequireSCIMToken(c *fiber.Ctx) error {
+	header := c.Get(fiber.HeaderAuthorization)
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return scimUnauthorized(c)
+	}
+
+	// The token is looked up before its business, and so the tenant scope, is known.
+	scimToken, err := repositories.AuthenticateSCIMToken(database.SystemContext(c.UserContext()), strings.TrimSpace(token))
+	if err != nil {
+		return scimUnauthorized(c)
+	}
+
+	if err := CheckBusinessStatus(c, scimToken.BusinessID); err != nil {
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) {
+			fiberErr = fiber.ErrInternalServerError
+		}
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
+			"status":  strconv.Itoa(fiberErr.Code),
+			"detail":  fiberErr.Message,
+		}, "application/scim+json")
+	}
+
+	c.Locals("business_id", scimToken.BusinessID)
+	c.Locals("scim_token", scimToken)
+
+	return withTenantScope(c, uuid.Nil, scimToken.BusinessID)
+}
+
+func scimUnauthorized(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="EquipQR SCIM"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
+		"status":  "401",
+		"detail":  "invalid or missing bearer token",
+	}, "application/scim+json")
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// errRollbackRequest aborts the request transaction without replacing the response.
+var errRollbackRequest = errors.New("rollback request transaction")
+
+// withTenantScope runs the rest of the handler chain in a transaction scoped to
+// the user, and to the business when one is given, if row-level security is
+// enabled. The transaction is committed unless the handler fails or responds
+// with a server error.
+func withTenantScope(c *fiber.Ctx, userID uuid.UUID, businessID uuid.UUID) error {
+	if !utils.AppConfig.Row_Level_Security {
+		return c.Next()
+	}
+
+	err := database.WithTenantScope(c.UserContext(), userID, func(ctx context.Context) error {
+		c.SetUserContext(ctx)
+		if businessID != uuid.Nil {
+			if err := database.SetTenantBusiness(ctx, businessID); err != nil {
+				return err
+			}
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			return errRollbackRequest
+		}
+		return nil
+	})
+	if errors.Is(err, errRollbackRequest) {
+		return nil
+	}
+	return err
+}
+
+// ScopeBusiness limits the request's row-level security scope to a business.
+// Handlers that act on a business other than the one resolved by middleware
+// must call it before touching tenant data.
+func ScopeBusiness(c *fiber.Ctx, businessID uuid.UUID) error {
+	if err := database.SetTenantBusiness(c.UserContext(), businessID); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to scope request")
+	}
+	return nil
+}
+
+// SystemScope runs unauthenticated routes such as sign-in, registration and
+// email verification, which have no tenant yet, outside row-level security.
+func SystemScope(c *fiber.Ctx) error {
+	c.SetUserContext(database.SystemContext(c.UserContext()))
+	return c.Next()
+}
@@ -1,74 +1,190 @@
 package repositories
 
 import (
+	"context"
+	"errors"
+	"strings"
+
 	"github.com/EquipQR/equipqr/backend/internal/database"
 	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func GetBusinessByID(id string) (*models.Business, error) {
+func GetBusinessByID(ctx context.Context, id string) (*models.Business, error) {
 	var business models.Business
 
-	if err := database.DB.First(&business, "id = ?", id).Error; err != nil {
+	if err := database.Conn(ctx).First(&business, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 
 	return &business, nil
 }
 
-func CreateBusiness(business *models.Business) error {
-	return database.DB.Create(business).Error
+func CreateBusiness(ctx context.Context, business *models.Business) error {
+	return database.Conn(ctx).Create(business).Error
+}
+
+// BusinessDirectoryEntry is the public projection of a business shown to users
+// looking for a business to join. It must never carry contact details.
+type BusinessDirectoryEntry struct {
+	ID           uuid.UUID `json:"id"`
+	BusinessName string    `json:"businessName"`
+	Type         string    `json:"type"`
+	Country      string    `json:"country"`
+	CountryCode  string    `json:"countryCode"`
+	CompanySize  string    `json:"companySize"`
+}
+
+type BusinessDirectoryFilter struct {
+	Query   string
+	Type    string
+	Country string
+	Limit   int
+	Offset  int
+}
+
+// SearchBusinessDirectory lists active businesses that accept self-registration
+// and have not opted out of the directory.
+func SearchBusinessDirectory(ctx context.Context, filter BusinessDirectoryFilter) ([]BusinessDirectoryEntry, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.Business{}).
+		Where("user_can_register = ? AND list_in_directory = ? AND status = ?", true, true, models.BusinessStatusActive)
+
+	if filter.Query != "" {
+		query = query.Where("business_name ILIKE ?", "%"+escapeLike(filter.Query)+"%")
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Country != "" {
+		query = query.Where("country ILIKE ? OR country_code ILIKE ?", filter.Country, filter.Country)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := []BusinessDirectoryEntry{}
+	err := query.
+		Select("id", "business_name", "type", "country", "country_code", "company_size").
+		Order("business_name ASC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Scan(&entries).Error
+
+	return entries, total, err
 }
 
-func GetBusinessesPaginated(limit int, offset int) ([]models.Business, error) {
-	var businesses []models.Business
-	result := database.DB.Limit(limit).Offset(offset).Find(&businesses)
-	return businesses, result.Error
+func SetBusinessDirectoryListing(ctx context.Context, businessID uuid.UUID, listed bool) error {
+	return database.Conn(ctx).
+		Model(&models.Business{}).
+		Where("id = ?", businessID).
+		Update("list_in_directory", listed).Error
 }
 
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// uniqueViolation reports whether err is Postgres rejecting a row because of the
+// named unique index or constraint. Statements whose violation is expected run in
+// a Transaction of their own, so inside a request's tenant transaction the
+// failure only rolls back to a savepoint instead of aborting the request.
+func uniqueViolation(err error, index string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == index
+}
+
+// CountBusinessMembers counts the active members of a business. The business page
+// is also shown to users outside the business, whose tenant scope would only
+// count their own membership, so the count runs in the system scope.
 func CountBusinessMembers(businessID string) (int64, error) {
 	var count int64
-	err := database.DB.
+	err := database.Conn(database.SystemContext(context.Background())).
 		Table("user_businesses").
-		Where("business_id = ?", businessID).
+		Where("business_id = ? AND deactivated_at IS NULL", businessID).
 		Count(&count).
 		Error
 
 	return count, err
 }
 
-func CreatePendingJoinRequest(userID uuid.UUID, businessID uuid.UUID) error {
-	req := models.PendingJoinRequest{
-		UserID:     userID,
-		BusinessID: businessID,
-	}
-	return database.DB.Create(&req).Error
+func AddUserToBusiness(ctx context.Context, userID string, businessID string, isAdmin bool) error {
+	return addMembership(database.Conn(ctx), uuid.MustParse(userID), uuid.MustParse(businessID), isAdmin)
 }
 
-func AddUserToBusiness(userID string, businessID string, isAdmin bool) error {
+// addMembership makes the user an active member of the business. An existing
+// membership is reactivated and only ever promoted to admin, never demoted.
+func addMembership(tx *gorm.DB, userID uuid.UUID, businessID uuid.UUID, isAdmin bool) error {
 	entry := models.UserBusiness{
-		UserID:     uuid.MustParse(userID),
-		BusinessID: uuid.MustParse(businessID),
+		UserID:     userID,
+		BusinessID: businessID,
 		IsAdmin:    isAdmin,
 	}
 
-	var existing models.UserBusiness
-	err := database.DB.
-		Where("user_id = ? AND business_id = ?", entry.UserID, entry.BusinessID).
-		Take(&existing).Error
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "business_id"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "deactivated_at"}, Value: nil},
+			{Column: clause.Column{Name: "is_admin"}, Value: gorm.Expr("user_businesses.is_admin OR excluded.is_admin")},
+		},
+	}).Omit(clause.Associations).Create(&entry).Error
+}
 
-	if err == nil {
-		if !existing.IsAdmin && isAdmin {
-			existing.IsAdmin = true
-			return database.DB.Save(&existing).Error
+// MigrateMembershipUniqueness removes duplicate memberships, keeping an active
+// one and preferring admin rights, and then adds the unique index on user and
+// business that addMembership relies on.
+func MigrateMembershipUniqueness(ctx context.Context) error {
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			DELETE FROM user_businesses WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (
+						PARTITION BY user_id, business_id
+						ORDER BY deactivated_at IS NULL DESC, is_admin DESC, id
+					) AS n
+					FROM user_businesses
+				) ranked
+				WHERE n > 1
+			)`).Error
+		if err != nil {
+			return err
 		}
-		return nil
-	}
+		return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_user_business ON user_businesses (user_id, business_id)").Error
+	})
+}
 
-	if err == gorm.ErrRecordNotFound {
-		return database.DB.Create(&entry).Error
+func GetUserBusinessMembership(ctx context.Context, userID uuid.UUID, businessID uuid.UUID) (*models.UserBusiness, error) {
+	var membership models.UserBusiness
+	err := database.Conn(ctx).
+		Where("user_id = ? AND business_id = ? AND deactivated_at IS NULL", userID, businessID).
+		Take(&membership).Error
+	if err != nil {
+		return nil, err
 	}
+	return &membership, nil
+}
+
+func GetMembershipsForUser(ctx context.Context, userID uuid.UUID) ([]models.UserBusiness, error) {
+	var memberships []models.UserBusiness
+	err := database.Conn(ctx).
+		Preload("Business").
+		Where("user_id = ? AND deactivated_at IS NULL", userID).
+		Where("business_id IN (?)", database.Conn(ctx).Model(&models.Business{}).Select("id").Where("status <> ?", models.BusinessStatusDeleted)).
+		Find(&memberships).Error
+	return memberships, err
+}
 
-	return err
+func GetBusinessAdmins(ctx context.Context, businessID uuid.UUID) ([]models.User, error) {
+	var admins []models.User
+	err := database.Conn(ctx).
+		Joins("JOIN user_businesses ON user_businesses.user_id = users.id").
+		Where("user_businesses.business_id = ? AND user_businesses.is_admin = ? AND user_businesses.deactivated_at IS NULL", businessID, true).
+		Find(&admins).Error
+	return admins, err
 }
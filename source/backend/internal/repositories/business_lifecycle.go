@@ -0,0 +1,266 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/s3"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBusinessNotFound      = errors.New("business not found")
+	ErrBusinessSuspended     = errors.New("business is suspended")
+	ErrBusinessNotSuspended  = errors.New("business is not suspended")
+	ErrBusinessDeleted       = errors.New("business is scheduled for deletion")
+	ErrBusinessNotDeleted    = errors.New("business is not scheduled for deletion")
+	ErrBusinessRestoreClosed = errors.New("restore period has ended")
+)
+
+// GetBusinessForLifecycle loads a business regardless of its status.
+func GetBusinessForLifecycle(ctx context.Context, id uuid.UUID) (*models.Business, error) {
+	var business models.Business
+	err := database.Conn(ctx).Where("id = ?", id).Take(&business).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBusinessNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &business, nil
+}
+
+// SuspendBusiness puts a business into read-only mode. Members can still read
+// data through existing sessions but cannot write or sign in to it.
+func SuspendBusiness(ctx context.Context, id uuid.UUID, reason string) (*models.Business, error) {
+	business, err := GetBusinessForLifecycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if business.Status == models.BusinessStatusDeleted {
+		return nil, ErrBusinessDeleted
+	}
+
+	now := time.Now()
+	business.Status = models.BusinessStatusSuspended
+	business.SuspendedAt = &now
+	business.SuspensionReason = reason
+
+	err = database.Conn(ctx).
+		Model(&models.Business{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":            business.Status,
+			"suspended_at":      now,
+			"suspension_reason": reason,
+		}).Error
+	return business, err
+}
+
+func UnsuspendBusiness(ctx context.Context, id uuid.UUID) (*models.Business, error) {
+	business, err := GetBusinessForLifecycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if business.Status == models.BusinessStatusDeleted {
+		return nil, ErrBusinessDeleted
+	}
+	if business.SuspendedAt == nil {
+		return nil, ErrBusinessNotSuspended
+	}
+
+	business.Status = models.BusinessStatusActive
+	business.SuspendedAt = nil
+	business.SuspensionReason = ""
+
+	err = database.Conn(ctx).
+		Model(&models.Business{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":            business.Status,
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}).Error
+	return business, err
+}
+
+// SoftDeleteBusiness hides a business from every member and schedules it for
+// purging once the grace period has passed. Nothing is removed until then.
+func SoftDeleteBusiness(ctx context.Context, id uuid.UUID, actorID *uuid.UUID, grace time.Duration) (*models.Business, error) {
+	business, err := GetBusinessForLifecycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if business.Status == models.BusinessStatusDeleted {
+		return nil, ErrBusinessDeleted
+	}
+
+	now := time.Now()
+	purgeAfter := now.Add(grace)
+	business.Status = models.BusinessStatusDeleted
+	business.DeletedAt = &now
+	business.DeletedBy = actorID
+	business.PurgeAfter = &purgeAfter
+
+	err = database.Conn(ctx).
+		Model(&models.Business{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":      business.Status,
+			"deleted_at":  now,
+			"deleted_by":  actorID,
+			"purge_after": purgeAfter,
+		}).Error
+	return business, err
+}
+
+// RestoreBusiness undoes a soft delete during the grace period. A business that
+// was suspended before it was deleted comes back suspended.
+func RestoreBusiness(ctx context.Context, id uuid.UUID) (*models.Business, error) {
+	business, err := GetBusinessForLifecycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if business.Status != models.BusinessStatusDeleted {
+		return nil, ErrBusinessNotDeleted
+	}
+	if business.PurgeAfter != nil && time.Now().After(*business.PurgeAfter) {
+		return nil, ErrBusinessRestoreClosed
+	}
+
+	business.Status = models.BusinessStatusActive
+	if business.SuspendedAt != nil {
+		business.Status = models.BusinessStatusSuspended
+	}
+	business.DeletedAt = nil
+	business.DeletedBy = nil
+	business.PurgeAfter = nil
+
+	err = database.Conn(ctx).
+		Model(&models.Business{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":      business.Status,
+			"deleted_at":  nil,
+			"deleted_by":  nil,
+			"purge_after": nil,
+		}).Error
+	return business, err
+}
+
+// PurgeExpiredBusinesses permanently removes businesses whose grace period has
+// ended. A business that cannot be purged is logged and retried on the next run
+// without holding up the others.
+func PurgeExpiredBusinesses(ctx context.Context) (int, error) {
+	var businesses []models.Business
+	err := database.Conn(ctx).
+		Where("status = ? AND purge_after <= ?", models.BusinessStatusDeleted, time.Now()).
+		Find(&businesses).Error
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, business := range businesses {
+		if err := purgeBusiness(ctx, business.ID); err != nil {
+			log.Printf("⚠️  Could not purge business %s: %v", business.ID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeBusiness deletes the business's rows and then its stored objects. Objects
+// go last so a failed transaction never leaves rows pointing at missing files;
+// objects left behind by a failed removal are only logged.
+func purgeBusiness(ctx context.Context, id uuid.UUID) error {
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Issues restrict equipment deletion so history is never lost by accident;
+		// purging is the one place where it has to go.
+		if err := tx.Where("equipment_id IN (?)", tx.Model(&models.Equipment{}).Select("id").Where("business_id = ?", id)).
+			Delete(&models.Issue{}).Error; err != nil {
+			return err
+		}
+
+		// Stock movements restrict part and location deletion, as history.
+		if err := tx.Where("business_id = ?", id).Delete(&models.PartMovement{}).Error; err != nil {
+			return err
+		}
+
+		// Service contracts restrict vendor deletion; remove them before the vendors.
+		if err := tx.Where("business_id = ?", id).Delete(&models.ServiceContract{}).Error; err != nil {
+			return err
+		}
+
+		// Location parents are RESTRICT; detach the tree so the cascade can remove it.
+		if err := tx.Model(&models.Location{}).
+			Where("business_id = ?", id).
+			Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ? AND status = ?", id, models.BusinessStatusDeleted).
+			Delete(&models.Business{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if s3.Client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		if _, err := s3.RemovePrefix(ctx, utils.AppConfig.MinioBucket, s3.BusinessPrefix(id)); err != nil {
+			log.Printf("⚠️  Purged business %s but could not remove its stored objects: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// UserLoginBlocked reports whether every business the user belongs to is
+// suspended. Users without any business can still sign in to create or join one.
+func UserLoginBlocked(ctx context.Context, userID uuid.UUID) (bool, error) {
+	memberships, err := GetMembershipsForUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if len(memberships) == 0 {
+		return false, nil
+	}
+
+	for _, membership := range memberships {
+		if membership.Business.Status == models.BusinessStatusActive {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ListBusinessesForAdmin lists businesses of every status for platform operators,
+// optionally narrowed to a single status.
+func ListBusinessesForAdmin(ctx context.Context, status string, limit int, offset int) ([]models.Business, int64, error) {
+	query := database.Conn(ctx).Model(&models.Business{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var businesses []models.Business
+	err := query.
+		Order("business_name ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&businesses).Error
+	return businesses, total, err
+}
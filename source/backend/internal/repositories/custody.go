@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAlreadyCheckedOut    = errors.New("equipment is already checked out")
+	ErrNotCheckedOut        = errors.New("equipment is not checked out")
+	ErrCheckoutUnavailable  = errors.New("equipment in this status cannot be checked out")
+	ErrCustodianNotMember   = errors.New("custodian is not a member of this business")
+	ErrCheckoutDueInPast    = errors.New("expected return must be in the future")
+	ErrInvalidCustodyPeriod = errors.New("from must be before to")
+)
+
+// unavailableForCheckout are the statuses in which equipment may not be lent out.
+var unavailableForCheckout = map[string]bool{
+	models.EquipmentStatusOutOfService: true,
+	models.EquipmentStatusUnderRepair:  true,
+	models.EquipmentStatusQuarantined:  true,
+	models.EquipmentStatusRetired:      true,
+}
+
+type CheckoutFilter struct {
+	CustodianID *uuid.UUID
+	OverdueOnly bool
+}
+
+// CustodyUtilisation summarises how a piece of equipment was lent out in a
+// period. Utilisation is the share of the period it was checked out; Late counts
+// checkouts returned, or still out, after their expected return.
+type CustodyUtilisation struct {
+	EquipmentID  uuid.UUID `json:"equipmentId"`
+	Checkouts    int64     `json:"checkouts"`
+	Custodians   int64     `json:"custodians"`
+	HoursOut     float64   `json:"hoursOut"`
+	AverageHours float64   `json:"averageHours"`
+	Late         int64     `json:"late"`
+	Utilisation  float64   `json:"utilisation"`
+}
+
+// GetCurrentCheckout returns the open checkout of equipment, or nil when it is
+// not checked out.
+func GetCurrentCheckout(ctx context.Context, equipmentID uuid.UUID) (*models.EquipmentCheckout, error) {
+	var checkout models.EquipmentCheckout
+	err := database.Conn(ctx).
+		Preload("Custodian").
+		Where("equipment_id = ? AND checked_in_at IS NULL", equipmentID).
+		Take(&checkout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkout, nil
+}
+
+// CheckOutEquipment hands equipment to custodianID until dueAt, if given.
+func CheckOutEquipment(ctx context.Context, eq *models.Equipment, custodianID uuid.UUID, actorID uuid.UUID, dueAt *time.Time, note string) (*models.EquipmentCheckout, error) {
+	if eq.RetiredAt != nil {
+		return nil, ErrEquipmentRetired
+	}
+	if unavailableForCheckout[eq.Status] {
+		return nil, fmt.Errorf("%w (%s)", ErrCheckoutUnavailable, eq.Status)
+	}
+
+	now := time.Now()
+	if dueAt != nil && !dueAt.After(now) {
+		return nil, ErrCheckoutDueInPast
+	}
+	if _, err := GetUserBusinessMembership(ctx, custodianID, eq.BusinessID); err != nil {
+		return nil, ErrCustodianNotMember
+	}
+
+	checkout := models.EquipmentCheckout{
+		BusinessID:   eq.BusinessID,
+		EquipmentID:  eq.ID,
+		CustodianID:  custodianID,
+		CheckedOutBy: &actorID,
+		CheckedOutAt: now,
+		DueAt:        dueAt,
+		CheckoutNote: strings.TrimSpace(note),
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var open int64
+		err := tx.Model(&models.EquipmentCheckout{}).
+			Where("equipment_id = ? AND checked_in_at IS NULL", eq.ID).
+			Count(&open).Error
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrAlreadyCheckedOut
+		}
+		return tx.Omit(clause.Associations).Create(&checkout).Error
+	})
+	if err != nil {
+		if uniqueViolation(err, "idx_open_checkout") {
+			return nil, ErrAlreadyCheckedOut
+		}
+		return nil, err
+	}
+
+	return GetCurrentCheckout(ctx, eq.ID)
+}
+
+// CheckInEquipment closes the open checkout of equipment with the condition it
+// came back in.
+func CheckInEquipment(ctx context.Context, eq *models.Equipment, actorID uuid.UUID, condition string, note string) (*models.EquipmentCheckout, error) {
+	checkout, err := GetCurrentCheckout(ctx, eq.ID)
+	if err != nil {
+		return nil, err
+	}
+	if checkout == nil {
+		return nil, ErrNotCheckedOut
+	}
+
+	now := time.Now()
+	result := database.Conn(ctx).
+		Model(&models.EquipmentCheckout{}).
+		Where("id = ? AND checked_in_at IS NULL", checkout.ID).
+		Updates(map[string]any{
+			"checked_in_at":    now,
+			"checked_in_by":    actorID,
+			"return_condition": condition,
+			"return_note":      strings.TrimSpace(note),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotCheckedOut
+	}
+
+	checkout.CheckedInAt = &now
+	checkout.CheckedInBy = &actorID
+	checkout.ReturnCondition = condition
+	checkout.ReturnNote = strings.TrimSpace(note)
+	return checkout, nil
+}
+
+// ListCheckouts returns the equipment currently checked out in a business,
+// most overdue first, optionally only one custodian's or only overdue ones.
+func ListCheckouts(ctx context.Context, businessID uuid.UUID, filter CheckoutFilter) ([]models.EquipmentCheckout, error) {
+	query := database.Conn(ctx).
+		Preload("Custodian").
+		Where("business_id = ? AND checked_in_at IS NULL", businessID)
+	if filter.CustodianID != nil {
+		query = query.Where("custodian_id = ?", *filter.CustodianID)
+	}
+	if filter.OverdueOnly {
+		query = query.Where("due_at < ?", time.Now())
+	}
+
+	var checkouts []models.EquipmentCheckout
+	err := query.Order("due_at ASC NULLS LAST, checked_out_at ASC").Find(&checkouts).Error
+	return checkouts, err
+}
+
+// GetCustodyHistory returns one page of an equipment's checkouts, newest first,
+// and the total number.
+func GetCustodyHistory(ctx context.Context, equipmentID uuid.UUID, limit int, offset int) ([]models.EquipmentCheckout, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.EquipmentCheckout{}).
+		Where("equipment_id = ?", equipmentID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var checkouts []models.EquipmentCheckout
+	err := query.
+		Preload("Custodian").
+		Order("checked_out_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&checkouts).Error
+	return checkouts, total, err
+}
+
+// GetCustodyUtilisation summarises checkouts overlapping [from, to) per piece of
+// equipment, or for one piece when equipmentID is set. Time still checked out
+// counts up to now, and the period is cut off at now.
+func GetCustodyUtilisation(ctx context.Context, businessID uuid.UUID, equipmentID *uuid.UUID, from time.Time, to time.Time) ([]CustodyUtilisation, error) {
+	now := time.Now()
+	if to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidCustodyPeriod
+	}
+
+	query := database.Conn(ctx).
+		Model(&models.EquipmentCheckout{}).
+		Select(`equipment_id,
+			COUNT(*) AS checkouts,
+			COUNT(DISTINCT custodian_id) AS custodians,
+			SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(checked_in_at, ?), ?) - GREATEST(checked_out_at, ?))) / 3600 AS hours_out,
+			COUNT(*) FILTER (WHERE due_at IS NOT NULL AND COALESCE(checked_in_at, ?) > due_at) AS late`,
+			now, to, from, now).
+		Where("business_id = ?", businessID).
+		Where("checked_out_at < ? AND COALESCE(checked_in_at, ?) > ?", to, now, from)
+	if equipmentID != nil {
+		query = query.Where("equipment_id = ?", *equipmentID)
+	}
+
+	stats := []CustodyUtilisation{}
+	err := query.Group("equipment_id").Order("hours_out DESC").Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	period := to.Sub(from).Hours()
+	for i := range stats {
+		stats[i].AverageHours = stats[i].HoursOut / float64(stats[i].Checkouts)
+		stats[i].Utilisation = stats[i].HoursOut / period
+	}
+	return stats, nil
+}
+
+// NotifyOverdueCheckouts emails custodians, and the admins of their business,
+// about checkouts that are past their expected return. Each checkout is
+// reported once; one that no email could be sent for is tried again on the
+// next run. Checkouts of suspended and deleted businesses are left alone.
+func NotifyOverdueCheckouts(ctx context.Context) (int, error) {
+	if !utils.AppConfig.Email_Enabled {
+		return 0, nil
+	}
+
+	var overdue []models.EquipmentCheckout
+	err := database.Conn(ctx).
+		Preload("Custodian").
+		Preload("Equipment").
+		Where("checked_in_at IS NULL AND due_at < ? AND overdue_notified_at IS NULL", time.Now()).
+		Where("business_id IN (?)", database.Conn(ctx).Model(&models.Business{}).Select("id").Where("status = ?", models.BusinessStatusActive)).
+		Find(&overdue).Error
+	if err != nil {
+		return 0, err
+	}
+
+	admins := map[uuid.UUID][]models.User{}
+	notified := 0
+	for _, checkout := range overdue {
+		businessAdmins, ok := admins[checkout.BusinessID]
+		if !ok {
+			businessAdmins, err = GetBusinessAdmins(ctx, checkout.BusinessID)
+			if err != nil {
+				log.Printf("checkout %s: could not load admins: %v", checkout.ID, err)
+			}
+			admins[checkout.BusinessID] = businessAdmins
+		}
+
+		name := checkout.Equipment.Type
+		if name == "" {
+			name = "Equipment"
+		}
+		subject := fmt.Sprintf("Overdue: %s %s", name, checkout.EquipmentID.String()[:8])
+		body := fmt.Sprintf(
+			"<p><strong>%s</strong> (%s) checked out to <strong>%s</strong> was due back on %s.</p>",
+			html.EscapeString(name), checkout.EquipmentID, html.EscapeString(checkout.Custodian.Username), checkout.DueAt.Format("2006-01-02 15:04"),
+		)
+		body += fmt.Sprintf("<p>Check it in at %s/equipment/%s.</p>", utils.AppConfig.BaseURL, checkout.EquipmentID)
+
+		recipients := map[string]bool{checkout.Custodian.Email: true}
+		for _, admin := range businessAdmins {
+			recipients[admin.Email] = true
+		}
+		sent := false
+		for email := range recipients {
+			if err := utils.SendEmail(email, subject, body); err != nil {
+				log.Printf("checkout %s: could not notify %s: %v", checkout.ID, email, err)
+				continue
+			}
+			sent = true
+		}
+		if !sent {
+			continue
+		}
+
+		err := database.Conn(ctx).Model(&models.EquipmentCheckout{}).
+			Where("id = ?", checkout.ID).
+			Update("overdue_notified_at", time.Now()).Error
+		if err != nil {
+			return notified, err
+		}
+		notified++
+	}
+	return notified, nil
+}
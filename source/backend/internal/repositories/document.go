@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDocumentNotFound        = errors.New("document not found")
+	ErrDocumentTarget          = errors.New("attach the document to either equipment or an equipment type")
+	ErrInvalidDocumentCategory = errors.New("invalid document category")
+)
+
+type DocumentFilter struct {
+	EquipmentID     *uuid.UUID
+	EquipmentTypeID *uuid.UUID
+	Category        string
+}
+
+// ValidDocumentCategory reports whether category is one of the document categories.
+func ValidDocumentCategory(category string) bool {
+	switch category {
+	case models.DocumentCategoryManual,
+		models.DocumentCategoryWiringDiagram,
+		models.DocumentCategorySOP,
+		models.DocumentCategoryCertificate,
+		models.DocumentCategoryOther:
+		return true
+	}
+	return false
+}
+
+// CheckDocumentTarget makes sure a new document is attached to exactly one
+// piece of equipment or equipment type of its business.
+func CheckDocumentTarget(ctx context.Context, document *models.EquipmentDocument) error {
+	if (document.EquipmentID == nil) == (document.EquipmentTypeID == nil) {
+		return ErrDocumentTarget
+	}
+	if document.EquipmentID != nil {
+		_, err := GetEquipmentInBusiness(ctx, document.BusinessID, *document.EquipmentID)
+		return err
+	}
+	_, err := GetEquipmentTypeByID(ctx, document.BusinessID, *document.EquipmentTypeID)
+	return err
+}
+
+func CreateEquipmentDocument(ctx context.Context, document *models.EquipmentDocument) error {
+	document.Title = strings.TrimSpace(document.Title)
+	document.Version = strings.TrimSpace(document.Version)
+	return database.Conn(ctx).Create(document).Error
+}
+
+func GetEquipmentDocument(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.EquipmentDocument, error) {
+	var document models.EquipmentDocument
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&document).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// ListEquipmentDocuments returns a business's documents by title, newest
+// version first.
+func ListEquipmentDocuments(ctx context.Context, businessID uuid.UUID, filter DocumentFilter) ([]models.EquipmentDocument, error) {
+	if filter.Category != "" && !ValidDocumentCategory(filter.Category) {
+		return nil, ErrInvalidDocumentCategory
+	}
+
+	query := database.Conn(ctx).Where("business_id = ?", businessID)
+	if filter.EquipmentID != nil {
+		query = query.Where("equipment_id = ?", *filter.EquipmentID)
+	}
+	if filter.EquipmentTypeID != nil {
+		query = query.Where("equipment_type_id = ?", *filter.EquipmentTypeID)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+
+	var documents []models.EquipmentDocument
+	err := query.Order("title ASC, created_at DESC").Find(&documents).Error
+	return documents, err
+}
+
+// GetDocumentsForEquipment returns the documents that apply to equipment: its
+// own and those of its type, manuals first.
+func GetDocumentsForEquipment(ctx context.Context, eq *models.Equipment, category string) ([]models.EquipmentDocument, error) {
+	if category != "" && !ValidDocumentCategory(category) {
+		return nil, ErrInvalidDocumentCategory
+	}
+
+	query := database.Conn(ctx).Where("business_id = ?", eq.BusinessID)
+	if eq.TypeID != nil {
+		query = query.Where("equipment_id = ? OR equipment_type_id = ?", eq.ID, *eq.TypeID)
+	} else {
+		query = query.Where("equipment_id = ?", eq.ID)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	var documents []models.EquipmentDocument
+	err := query.
+		Order("category = 'manual' DESC, title ASC, created_at DESC").
+		Find(&documents).Error
+	return documents, err
+}
+
+// UpdateEquipmentDocument saves a document's title, category and version.
+func UpdateEquipmentDocument(ctx context.Context, document *models.EquipmentDocument) error {
+	document.Title = strings.TrimSpace(document.Title)
+	document.Version = strings.TrimSpace(document.Version)
+	return database.Conn(ctx).
+		Model(&models.EquipmentDocument{}).
+		Where("id = ?", document.ID).
+		Updates(map[string]any{
+			"title":    document.Title,
+			"category": document.Category,
+			"version":  document.Version,
+		}).Error
+}
+
+// DeleteEquipmentDocument removes a document's record; the caller removes the
+// stored file.
+func DeleteEquipmentDocument(ctx context.Context, document *models.EquipmentDocument) error {
+	return database.Conn(ctx).Delete(&models.EquipmentDocument{}, "id = ?", document.ID).Error
+}
@@ -0,0 +1,324 @@
+package repositories
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// domainVerificationPrefix is the DNS label under which businesses publish
+// their verification token as a TXT record.
+const domainVerificationPrefix = "_equipqr-verification."
+
+var (
+	ErrDomainNotFound   = errors.New("email domain not found")
+	ErrDomainInvalid    = errors.New("invalid email domain")
+	ErrDomainPublic     = errors.New("public email providers cannot be used for auto-join")
+	ErrDomainTaken      = errors.New("domain is already verified by another business")
+	ErrDomainUnverified = errors.New("verification TXT record not found")
+	ErrEmailLinkInvalid = errors.New("verification link expired or invalid")
+)
+
+// publicEmailDomains are shared mailbox providers nobody can claim for a business.
+var publicEmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"outlook.com":    true,
+	"hotmail.com":    true,
+	"live.com":       true,
+	"yahoo.com":      true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"aol.com":        true,
+	"proton.me":      true,
+	"protonmail.com": true,
+	"gmx.com":        true,
+	"mail.com":       true,
+}
+
+func GetEmailDomainsForBusiness(ctx context.Context, businessID uuid.UUID) ([]models.BusinessEmailDomain, error) {
+	var domains []models.BusinessEmailDomain
+	err := database.Conn(ctx).
+		Where("business_id = ?", businessID).
+		Order("domain ASC").
+		Find(&domains).Error
+	return domains, err
+}
+
+func GetEmailDomainByID(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.BusinessEmailDomain, error) {
+	var domain models.BusinessEmailDomain
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDomainNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// CreateEmailDomain registers an unverified domain and generates the token the
+// business must publish in DNS before the rule takes effect.
+func CreateEmailDomain(ctx context.Context, domain *models.BusinessEmailDomain) error {
+	name, err := normalizeDomain(domain.Domain)
+	if err != nil {
+		return err
+	}
+	if publicEmailDomains[name] {
+		return ErrDomainPublic
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+
+	domain.Domain = name
+	domain.VerificationToken = hex.EncodeToString(token)
+	domain.VerifiedAt = nil
+	return database.Conn(ctx).Create(domain).Error
+}
+
+func UpdateEmailDomainRule(ctx context.Context, domain *models.BusinessEmailDomain) error {
+	return database.Conn(ctx).
+		Model(&models.BusinessEmailDomain{}).
+		Where("id = ? AND business_id = ?", domain.ID, domain.BusinessID).
+		Updates(map[string]any{
+			"default_role": domain.DefaultRole,
+			"mode":         domain.Mode,
+		}).Error
+}
+
+func DeleteEmailDomain(ctx context.Context, businessID uuid.UUID, id uuid.UUID) error {
+	result := database.Conn(ctx).Where("id = ? AND business_id = ?", id, businessID).Delete(&models.BusinessEmailDomain{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDomainNotFound
+	}
+	return nil
+}
+
+// VerifyEmailDomain looks up the _equipqr-verification TXT record of the domain
+// and marks it verified when it contains the expected token. The unique index on
+// verified domains rejects a domain another business has already verified.
+func VerifyEmailDomain(ctx context.Context, domain *models.BusinessEmailDomain) error {
+	records, err := net.LookupTXT(domainVerificationPrefix + domain.Domain)
+	if err != nil {
+		return ErrDomainUnverified
+	}
+
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == domain.VerificationToken {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrDomainUnverified
+	}
+
+	now := time.Now()
+	err = database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.BusinessEmailDomain{}).
+			Where("id = ?", domain.ID).
+			Update("verified_at", now).Error
+	})
+	if uniqueViolation(err, "idx_verified_email_domain") {
+		return ErrDomainTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	domain.VerifiedAt = &now
+	return nil
+}
+
+// DomainVerificationRecord returns the DNS name and value a business must publish.
+func DomainVerificationRecord(domain *models.BusinessEmailDomain) (string, string) {
+	return domainVerificationPrefix + domain.Domain, domain.VerificationToken
+}
+
+// FindEmailDomainRule returns the verified rule covering the email's domain, if any.
+// Rules belong to other businesses than the user's, so it is only called from
+// the system scope of registration and email verification.
+func FindEmailDomainRule(ctx context.Context, email string) (*models.BusinessEmailDomain, error) {
+	name := emailDomain(email)
+	if name == "" {
+		return nil, nil
+	}
+
+	var domain models.BusinessEmailDomain
+	err := database.Conn(ctx).
+		Where("domain = ? AND verified_at IS NOT NULL", name).
+		Take(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// ApplyEmailDomainRules adds a user with a verified email address to the business
+// that verified the email's domain, either directly or through a join request
+// depending on the rule's mode. Identity providers that assert a verified email
+// (such as OIDC sign-in) should call this after creating or linking the user.
+func ApplyEmailDomainRules(ctx context.Context, user *models.User) (*models.BusinessEmailDomain, error) {
+	if user.EmailVerifiedAt == nil {
+		return nil, nil
+	}
+
+	rule, err := FindEmailDomainRule(ctx, user.Email)
+	if err != nil || rule == nil {
+		return nil, err
+	}
+
+	if _, err := GetUserBusinessMembership(ctx, user.ID, rule.BusinessID); err == nil {
+		return rule, nil
+	}
+
+	business, err := GetBusinessForLifecycle(ctx, rule.BusinessID)
+	if err != nil || business.Status != models.BusinessStatusActive {
+		return nil, err
+	}
+
+	switch rule.Mode {
+	case models.DomainModeAutoApprove:
+		err = AddUserToBusiness(ctx, user.ID.String(), rule.BusinessID.String(), rule.DefaultRole == models.DomainRoleAdmin)
+	default:
+		_, err = openJoinRequest(ctx, user.ID, rule.BusinessID, fmt.Sprintf("Requested automatically for verified address on %s", rule.Domain))
+		if errors.Is(err, ErrJoinRequestDuplicate) {
+			err = nil
+		}
+	}
+
+	return rule, err
+}
+
+// MarkEmailVerified records that the user proved ownership of their email address.
+func MarkEmailVerified(ctx context.Context, user *models.User) error {
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return database.Conn(ctx).
+		Model(&models.User{}).
+		Where("id = ?", user.ID).
+		Update("email_verified_at", now).Error
+}
+
+func GenerateEmailVerificationLink(user *models.User, expiryMinutes int) string {
+	expiry := time.Now().Add(time.Duration(expiryMinutes) * time.Minute).Unix()
+
+	params := url.Values{}
+	params.Set("user", user.ID.String())
+	params.Set("email", user.Email)
+	params.Set("exp", strconv.FormatInt(expiry, 10))
+	params.Set("sig", signEmailVerification(user.ID.String(), user.Email, strconv.FormatInt(expiry, 10)))
+
+	return fmt.Sprintf("%s/api/auth/verify-email?%s", utils.AppConfig.BaseURL, params.Encode())
+}
+
+// SendEmailVerification emails the user a signed link to confirm their address.
+// When email is disabled the link is logged so development setups still work.
+func SendEmailVerification(user *models.User) error {
+	link := GenerateEmailVerificationLink(user, 24*60)
+
+	if !utils.AppConfig.Email_Enabled {
+		log.Printf("Email disabled; verification link for %s: %s", user.Email, link)
+		return nil
+	}
+
+	body := fmt.Sprintf(
+		"<p>Hi %s,</p><p>Confirm your email address for EquipQR:</p><p><a href=\"%s\">%s</a></p>",
+		html.EscapeString(user.Username), html.EscapeString(link), html.EscapeString(link),
+	)
+	return utils.SendEmail(user.Email, "Confirm your email address", body)
+}
+
+// ProcessEmailVerification checks a verification link, marks the address verified
+// and applies any matching email domain rule.
+func ProcessEmailVerification(ctx context.Context, params utils.EmailVerificationParams) (*models.User, *models.BusinessEmailDomain, error) {
+	exp, err := strconv.ParseInt(params.Expiry, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return nil, nil, ErrEmailLinkInvalid
+	}
+
+	expectedSig := signEmailVerification(params.UserID, params.Email, params.Expiry)
+	if !hmac.Equal([]byte(expectedSig), []byte(params.Signature)) {
+		return nil, nil, ErrEmailLinkInvalid
+	}
+
+	user, err := GetUserByID(ctx, params.UserID)
+	if err != nil || !strings.EqualFold(user.Email, params.Email) {
+		return nil, nil, ErrEmailLinkInvalid
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := MarkEmailVerified(ctx, user); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rule, err := ApplyEmailDomainRules(ctx, user)
+	return user, rule, err
+}
+
+func signEmailVerification(userID string, email string, expiry string) string {
+	data := "verify-email:" + userID + ":" + strings.ToLower(email) + ":" + expiry
+	mac := hmac.New(sha256.New, []byte(utils.AppConfig.JWT_Secret))
+	mac.Write([]byte(data))
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+func normalizeDomain(domain string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(domain))
+	name = strings.TrimPrefix(name, "@")
+	name = strings.TrimSuffix(name, ".")
+
+	if len(name) < 3 || len(name) > 253 || !strings.Contains(name, ".") {
+		return "", ErrDomainInvalid
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", ErrDomainInvalid
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return "", ErrDomainInvalid
+			}
+		}
+	}
+	return name, nil
+}
@@ -1,41 +1,89 @@
 package repositories
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 
 	"github.com/EquipQR/equipqr/backend/internal/database"
 	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
-func GetEquipmentByID(id string) (*models.Equipment, error) {
+var (
+	ErrEquipmentNotFound   = errors.New("equipment not found")
+	ErrEquipmentRetired    = errors.New("equipment is retired")
+	ErrEquipmentNotRetired = errors.New("equipment is not retired")
+	ErrInvalidMoreFields   = errors.New("more_fields values must be strings, numbers, booleans or null")
+)
+
+// Values for EquipmentFilter.Retired.
+const (
+	RetiredExclude = ""
+	RetiredOnly    = "only"
+	RetiredInclude = "include"
+)
+
+// equipmentSortColumns maps the sort keys accepted by the list endpoint to columns.
+var equipmentSortColumns = map[string]string{
+	"type":     "type",
+	"status":   "status",
+	"location": "location",
+	"created":  "created_at",
+	"updated":  "updated_at",
+}
+
+func GetEquipmentByID(ctx context.Context, id string) (*models.Equipment, error) {
 	var eq models.Equipment
-	result := database.DB.Preload("Business").First(&eq, "id = ?", id)
+	result := database.Conn(ctx).Preload("Business").Preload("TypeEntry").Preload("LocationNode").First(&eq, "id = ?", id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &eq, nil
 }
 
-func GetIssuesByEquipmentID(id string) ([]models.Issue, error) {
+func GetIssuesByEquipmentID(ctx context.Context, id string) ([]models.Issue, error) {
 	var issues []models.Issue
-	result := database.DB.Where("equipment_id = ?", id).Find(&issues)
+	result := database.Conn(ctx).Where("equipment_id = ?", id).Find(&issues)
 	return issues, result.Error
 }
 
-func CreateEquipmentEntry(reqID string, status string, eqType string, location string, moreFields map[string]any) (*models.Equipment, error) {
+func CreateEquipmentEntry(ctx context.Context, reqID string, status string, equipmentType *models.EquipmentType, location string, locationID *uuid.UUID, moreFields map[string]any, actorID *uuid.UUID) (*models.Equipment, error) {
 	businessID, err := uuid.Parse(reqID)
 	if err != nil {
 		return nil, errors.New("invalid business_id")
 	}
 
-	_, err = GetBusinessByID(reqID)
+	if !ValidEquipmentStatus(status) || status == models.EquipmentStatusRetired {
+		return nil, ErrInvalidStatus
+	}
+
+	_, err = GetBusinessByID(ctx, reqID)
 	if err != nil {
 		return nil, errors.New("business not found")
 	}
 
+	if locationID != nil {
+		label, err := GetLocationPathLabel(ctx, businessID, *locationID)
+		if err != nil {
+			return nil, errors.New("location not found")
+		}
+		location = label
+	}
+
+	if err := ValidateMoreFields(moreFields); err != nil {
+		return nil, err
+	}
+
+	moreFields = ApplyTypeDefaults(equipmentType, moreFields)
+	moreFields, err = ApplyFieldSchema(ctx, businessID, equipmentType.Name, moreFields)
+	if err != nil {
+		return nil, err
+	}
+
 	moreFieldsJSON, err := json.Marshal(moreFields)
 	if err != nil {
 		return nil, errors.New("invalid more_fields format")
@@ -44,14 +92,280 @@ func CreateEquipmentEntry(reqID string, status string, eqType string, location s
 	equipment := models.Equipment{
 		BusinessID: businessID,
 		Status:     status,
-		Type:       eqType,
+		Type:       equipmentType.Name,
+		TypeID:     &equipmentType.ID,
 		Location:   location,
+		LocationID: locationID,
 		MoreFields: datatypes.JSON(moreFieldsJSON),
 	}
 
-	if err := database.DB.Create(&equipment).Error; err != nil {
+	err = database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Free text without a node is filed under matching nodes, as the
+		// start-up migration does for older equipment.
+		if equipment.LocationID == nil {
+			locationID, err := ensureLocationPath(tx, businessID, location)
+			if err != nil {
+				return err
+			}
+			equipment.LocationID = locationID
+		}
+		if err := tx.Create(&equipment).Error; err != nil {
+			return err
+		}
+		if err := recordStatusEvent(tx, &equipment, "", status, actorID, "created"); err != nil {
+			return err
+		}
+		return createDefaultMaintenancePlan(tx, &equipment, equipmentType, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetEquipmentByID(ctx, equipment.ID.String())
+}
+
+type EquipmentFilter struct {
+	Status     string
+	Type       string
+	TypeID     *uuid.UUID
+	LocationID *uuid.UUID        // includes equipment anywhere beneath the location
+	ParentID   *uuid.UUID        // direct components of an assembly
+	TopLevel   bool              // only equipment that is not a component
+	Fields     map[string]string // MoreFields key → exact value
+	Retired    string
+	Sort       string // one of equipmentSortColumns, prefixed with "-" for descending
+	Limit      int
+	Offset     int
+}
+
+// ValidEquipmentSort reports whether sort is accepted by ListEquipment.
+func ValidEquipmentSort(sort string) bool {
+	if sort == "" {
+		return true
+	}
+	_, ok := equipmentSortColumns[strings.TrimPrefix(sort, "-")]
+	return ok
+}
+
+// ListEquipment returns one page of a business's equipment, with thumbnails and
+// warranty badges, and the total number of matches.
+func ListEquipment(ctx context.Context, businessID uuid.UUID, filter EquipmentFilter) ([]EquipmentListItem, int64, error) {
+	query := equipmentListQuery(ctx, businessID, filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var equipment []models.Equipment
+	err := query.
+		Preload("LocationNode").
+		Order(equipmentListOrder(filter.Sort)).
+		Order("id").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&equipment).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items, err := EquipmentListItems(ctx, equipment)
+	return items, total, err
+}
+
+// EquipmentListItem is equipment as the API returns it, with the thumbnail of
+// its primary photo as a data URI and its warranty badge when it has them.
+type EquipmentListItem struct {
+	models.Equipment
+	Thumbnail string         `json:"thumbnail,omitempty"`
+	Warranty  *WarrantyBadge `json:"warranty,omitempty"`
+}
+
+// EquipmentListItems adds thumbnails and warranty badges to equipment.
+func EquipmentListItems(ctx context.Context, equipment []models.Equipment) ([]EquipmentListItem, error) {
+	items := make([]EquipmentListItem, len(equipment))
+	if len(equipment) == 0 {
+		return items, nil
+	}
+
+	ids := make([]uuid.UUID, len(equipment))
+	for i, eq := range equipment {
+		ids[i] = eq.ID
+	}
+
+	thumbnails, err := primaryThumbnails(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	warranties, err := warrantyBadges(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, eq := range equipment {
+		items[i] = EquipmentListItem{
+			Equipment: eq,
+			Thumbnail: thumbnails[eq.ID],
+			Warranty:  warranties[eq.ID],
+		}
+	}
+	return items, nil
+}
+
+// equipmentListQuery applies the filters shared by the list and export endpoints.
+func equipmentListQuery(ctx context.Context, businessID uuid.UUID, filter EquipmentFilter) *gorm.DB {
+	query := database.Conn(ctx).
+		Model(&models.Equipment{}).
+		Where("business_id = ?", businessID)
+
+	switch filter.Retired {
+	case RetiredOnly:
+		query = query.Where("retired_at IS NOT NULL")
+	case RetiredInclude:
+	default:
+		query = query.Where("retired_at IS NULL")
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.TypeID != nil {
+		query = query.Where("type_id = ?", *filter.TypeID)
+	}
+	if filter.LocationID != nil {
+		query = query.Where("location_id IN (?)", database.Conn(ctx).Raw(locationSubtreeSQL, *filter.LocationID, businessID))
+	}
+	if filter.ParentID != nil {
+		query = query.Where("parent_id = ?", *filter.ParentID)
+	}
+	if filter.TopLevel {
+		query = query.Where("parent_id IS NULL")
+	}
+	for key, value := range filter.Fields {
+		query = query.Where("more_fields ->> ? = ?", key, value)
+	}
+	return query.Session(&gorm.Session{})
+}
+
+func equipmentListOrder(sort string) string {
+	if sort == "" {
+		return "created_at DESC NULLS LAST"
+	}
+	column := equipmentSortColumns[strings.TrimPrefix(sort, "-")]
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+	}
+	return column + " " + direction + " NULLS LAST"
+}
+
+// GetEquipmentInBusiness loads equipment only if it belongs to the business.
+func GetEquipmentInBusiness(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.Equipment, error) {
+	var eq models.Equipment
+	err := database.Conn(ctx).
+		Preload("LocationNode").
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&eq).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEquipmentNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
+	return &eq, nil
+}
 
-	return GetEquipmentByID(equipment.ID.String())
+// UpdateEquipment saves the editable attributes of equipment that is still in use.
+// A free-text location without a node is filed under matching nodes, as on
+// create. Status is changed separately through ChangeEquipmentStatus.
+func UpdateEquipment(ctx context.Context, eq *models.Equipment) error {
+	if eq.RetiredAt != nil {
+		return ErrEquipmentRetired
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if eq.LocationID == nil {
+			locationID, err := ensureLocationPath(tx, eq.BusinessID, eq.Location)
+			if err != nil {
+				return err
+			}
+			eq.LocationID = locationID
+		}
+
+		return tx.Model(&models.Equipment{}).
+			Where("id = ?", eq.ID).
+			Updates(map[string]any{
+				"type":        eq.Type,
+				"type_id":     eq.TypeID,
+				"location":    eq.Location,
+				"location_id": eq.LocationID,
+				"more_fields": eq.MoreFields,
+			}).Error
+	})
+}
+
+// RetireEquipment takes equipment out of use without deleting it, so its issues
+// and history stay available.
+func RetireEquipment(ctx context.Context, eq *models.Equipment, actorID uuid.UUID, reason string) error {
+	if eq.RetiredAt != nil {
+		return ErrEquipmentRetired
+	}
+
+	return ChangeEquipmentStatus(ctx, eq, models.EquipmentStatusRetired, &actorID, reason, false)
+}
+
+// ReinstateEquipment returns retired equipment to the status it had before.
+func ReinstateEquipment(ctx context.Context, eq *models.Equipment, actorID uuid.UUID) error {
+	if eq.RetiredAt == nil {
+		return ErrEquipmentNotRetired
+	}
+
+	return ChangeEquipmentStatus(ctx, eq, statusBeforeRetirement(ctx, eq.ID), &actorID, "reinstated", false)
+}
+
+// MergeMoreFields applies a JSON merge patch to MoreFields: keys set to null are
+// removed and all other keys are added or replaced.
+func MergeMoreFields(current datatypes.JSON, patch map[string]any) (datatypes.JSON, error) {
+	if err := ValidateMoreFields(patch); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]any{}
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &fields); err != nil || fields == nil {
+			fields = map[string]any{}
+		}
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(fields, key)
+			continue
+		}
+		fields[key] = value
+	}
+
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return nil, ErrInvalidMoreFields
+	}
+	return datatypes.JSON(merged), nil
+}
+
+// ValidateMoreFields checks that custom fields form a flat object of scalar values.
+func ValidateMoreFields(fields map[string]any) error {
+	for key, value := range fields {
+		if strings.TrimSpace(key) == "" || len(key) > 64 {
+			return ErrInvalidMoreFields
+		}
+		switch value.(type) {
+		case nil, string, float64, bool, json.Number:
+		default:
+			return ErrInvalidMoreFields
+		}
+	}
+	return nil
 }
@@ -0,0 +1,235 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAssemblyCycle          = errors.New("equipment cannot be a component of itself or of its own components")
+	ErrAssemblyParentNotFound = errors.New("parent equipment not found")
+	ErrAssemblyUnchanged      = errors.New("equipment is already a component of this parent")
+)
+
+// equipmentSubtreeSQL selects the IDs of an assembly and all of its components,
+// however deeply nested. UNION rather than UNION ALL keeps the query finite even
+// if a cycle ever made it into the data.
+const equipmentSubtreeSQL = `
+WITH RECURSIVE subtree AS (
+	SELECT id FROM equipment WHERE id = ? AND business_id = ?
+	UNION
+	SELECT e.id FROM equipment e JOIN subtree s ON e.parent_id = s.id
+)
+SELECT id FROM subtree`
+
+// equipmentAncestorsSQL selects the assemblies above equipment, nearest first.
+const equipmentAncestorsSQL = `
+WITH RECURSIVE ancestors AS (
+	SELECT parent_id AS id, 1 AS depth FROM equipment WHERE id = ? AND business_id = ?
+	UNION
+	SELECT e.parent_id, a.depth + 1 FROM equipment e JOIN ancestors a ON e.id = a.id
+	WHERE a.depth < 64
+)
+SELECT id FROM ancestors WHERE id IS NOT NULL ORDER BY depth`
+
+// EquipmentTreeNode is equipment with its components. OpenIssues counts the
+// equipment's own open issues; TotalOpenIssues includes those of every component.
+type EquipmentTreeNode struct {
+	models.Equipment
+	OpenIssues      int64                `json:"openIssues"`
+	TotalOpenIssues int64                `json:"totalOpenIssues"`
+	Components      []*EquipmentTreeNode `json:"components"`
+}
+
+func GetEquipmentSubtreeIDs(ctx context.Context, businessID uuid.UUID, rootID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.Conn(ctx).Raw(equipmentSubtreeSQL, rootID, businessID).Scan(&ids).Error
+	return ids, err
+}
+
+// SetEquipmentParent makes equipment a component of parentID, or standalone when
+// parentID is nil, and records the move. Placing equipment beneath itself or one
+// of its own components is rejected.
+func SetEquipmentParent(ctx context.Context, eq *models.Equipment, parentID *uuid.UUID, actorID *uuid.UUID, reason string) error {
+	if sameParent(eq.ParentID, parentID) {
+		return ErrAssemblyUnchanged
+	}
+
+	if parentID != nil {
+		if *parentID == eq.ID {
+			return ErrAssemblyCycle
+		}
+		parent, err := GetEquipmentInBusiness(ctx, eq.BusinessID, *parentID)
+		if errors.Is(err, ErrEquipmentNotFound) {
+			return ErrAssemblyParentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if parent.RetiredAt != nil {
+			return ErrEquipmentRetired
+		}
+	}
+
+	from := eq.ParentID
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialise moves within a business so two concurrent moves cannot
+		// together create a cycle that neither would on its own.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", "equipment_assembly:"+eq.BusinessID.String()).Error; err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			var subtree []uuid.UUID
+			if err := tx.Raw(equipmentSubtreeSQL, eq.ID, eq.BusinessID).Scan(&subtree).Error; err != nil {
+				return err
+			}
+			if slices.Contains(subtree, *parentID) {
+				return ErrAssemblyCycle
+			}
+		}
+
+		if err := tx.Model(&models.Equipment{}).Where("id = ?", eq.ID).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.EquipmentMoveEvent{
+			EquipmentID:  eq.ID,
+			BusinessID:   eq.BusinessID,
+			FromParentID: from,
+			ToParentID:   parentID,
+			Reason:       strings.TrimSpace(reason),
+			ActorID:      actorID,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	eq.ParentID = parentID
+	return nil
+}
+
+// GetEquipmentTree returns an assembly with all of its components nested beneath
+// it, retired ones included, and open issue counts rolled up the tree.
+func GetEquipmentTree(ctx context.Context, businessID uuid.UUID, rootID uuid.UUID) (*EquipmentTreeNode, error) {
+	ids, err := GetEquipmentSubtreeIDs(ctx, businessID, rootID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrEquipmentNotFound
+	}
+
+	var equipment []models.Equipment
+	err = database.Conn(ctx).
+		Preload("LocationNode").
+		Where("id IN ?", ids).
+		Order("type ASC, created_at ASC").
+		Find(&equipment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := openIssueCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uuid.UUID]*EquipmentTreeNode, len(equipment))
+	for _, eq := range equipment {
+		nodes[eq.ID] = &EquipmentTreeNode{
+			Equipment:  eq,
+			OpenIssues: counts[eq.ID],
+			Components: []*EquipmentTreeNode{},
+		}
+	}
+	for _, eq := range equipment {
+		if eq.ID == rootID || eq.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*eq.ParentID]; ok {
+			parent.Components = append(parent.Components, nodes[eq.ID])
+		}
+	}
+
+	root := nodes[rootID]
+	if root == nil {
+		return nil, ErrEquipmentNotFound
+	}
+	sumOpenIssues(root)
+	return root, nil
+}
+
+func sumOpenIssues(node *EquipmentTreeNode) int64 {
+	node.TotalOpenIssues = node.OpenIssues
+	for _, component := range node.Components {
+		node.TotalOpenIssues += sumOpenIssues(component)
+	}
+	return node.TotalOpenIssues
+}
+
+// GetEquipmentAncestors returns the assemblies equipment belongs to, outermost first.
+func GetEquipmentAncestors(ctx context.Context, businessID uuid.UUID, id uuid.UUID) ([]models.Equipment, error) {
+	var ids []uuid.UUID
+	if err := database.Conn(ctx).Raw(equipmentAncestorsSQL, id, businessID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []models.Equipment{}, nil
+	}
+
+	var equipment []models.Equipment
+	if err := database.Conn(ctx).Where("id IN ?", ids).Find(&equipment).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]models.Equipment, len(equipment))
+	for _, eq := range equipment {
+		byID[eq.ID] = eq
+	}
+	ancestors := make([]models.Equipment, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		if eq, ok := byID[ids[i]]; ok {
+			ancestors = append(ancestors, eq)
+		}
+	}
+	return ancestors, nil
+}
+
+// GetAssemblyIssues returns the issues of equipment and of all its components,
+// newest first, each with the equipment it was reported on.
+func GetAssemblyIssues(ctx context.Context, businessID uuid.UUID, rootID uuid.UUID) ([]models.Issue, error) {
+	var issues []models.Issue
+	err := database.Conn(ctx).
+		Preload("Equipment").
+		Where("equipment_id IN (?)", database.Conn(ctx).Raw(equipmentSubtreeSQL, rootID, businessID)).
+		Order("date_submitted DESC").
+		Find(&issues).Error
+	return issues, err
+}
+
+// GetEquipmentMoveHistory returns the assembly moves of equipment, oldest first.
+func GetEquipmentMoveHistory(ctx context.Context, equipmentID uuid.UUID) ([]models.EquipmentMoveEvent, error) {
+	var events []models.EquipmentMoveEvent
+	err := database.Conn(ctx).
+		Preload("Actor").
+		Where("equipment_id = ?", equipmentID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error
+	return events, err
+}
+
+func sameParent(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
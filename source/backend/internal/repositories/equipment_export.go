@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+)
+
+// exportBatchSize is how much equipment is loaded per query while exporting.
+const exportBatchSize = 500
+
+// ExportOptions selects the equipment to export and the optional columns.
+type ExportOptions struct {
+	Filter         EquipmentFilter // Limit and Offset are ignored
+	OpenIssues     bool
+	LastInspection bool
+}
+
+// EquipmentExportColumns returns the header row of an export. MoreFields are
+// flattened into one "field.<key>" column per key used by the exported
+// equipment, which the import reads back into MoreFields.
+func EquipmentExportColumns(ctx context.Context, businessID uuid.UUID, opts ExportOptions) ([]string, error) {
+	var keys []string
+	err := equipmentListQuery(ctx, businessID, opts.Filter).
+		Select("DISTINCT jsonb_object_keys(CASE WHEN jsonb_typeof(more_fields) = 'object' THEN more_fields ELSE '{}'::jsonb END) AS key").
+		Pluck("key", &keys).Error
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	columns := []string{"id", "type", "type_id", "status", "location", "location_id"}
+	for _, key := range keys {
+		columns = append(columns, "field."+key)
+	}
+	if opts.OpenIssues {
+		columns = append(columns, "open_issues")
+	}
+	if opts.LastInspection {
+		columns = append(columns, "last_inspection")
+	}
+	return append(columns, "created_at", "updated_at", "retired_at"), nil
+}
+
+// ExportEquipment calls write with one row per matching equipment, in the
+// order of the list endpoint. Values line up with columns from
+// EquipmentExportColumns and are strings, numbers, booleans or nil.
+func ExportEquipment(ctx context.Context, businessID uuid.UUID, opts ExportOptions, columns []string, write func(row []any) error) error {
+	query := equipmentListQuery(ctx, businessID, opts.Filter).
+		Order(equipmentListOrder(opts.Filter.Sort)).
+		Order("id")
+
+	for offset := 0; ; offset += exportBatchSize {
+		var batch []models.Equipment
+		if err := query.Limit(exportBatchSize).Offset(offset).Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(batch))
+		for _, eq := range batch {
+			ids = append(ids, eq.ID)
+		}
+
+		var openIssues map[uuid.UUID]int64
+		if opts.OpenIssues {
+			counts, err := openIssueCounts(ctx, ids)
+			if err != nil {
+				return err
+			}
+			openIssues = counts
+		}
+
+		var inspections map[uuid.UUID]time.Time
+		if opts.LastInspection {
+			dates, err := lastInspectionDates(ctx, ids)
+			if err != nil {
+				return err
+			}
+			inspections = dates
+		}
+
+		for _, eq := range batch {
+			fields := map[string]any{}
+			if len(eq.MoreFields) > 0 {
+				_ = json.Unmarshal(eq.MoreFields, &fields)
+			}
+
+			row := make([]any, len(columns))
+			for i, column := range columns {
+				switch column {
+				case "id":
+					row[i] = eq.ID.String()
+				case "type":
+					row[i] = eq.Type
+				case "type_id":
+					if eq.TypeID != nil {
+						row[i] = eq.TypeID.String()
+					}
+				case "status":
+					row[i] = eq.Status
+				case "location":
+					row[i] = eq.Location
+				case "location_id":
+					if eq.LocationID != nil {
+						row[i] = eq.LocationID.String()
+					}
+				case "open_issues":
+					row[i] = openIssues[eq.ID]
+				case "last_inspection":
+					if inspected, ok := inspections[eq.ID]; ok {
+						row[i] = inspected.UTC().Format(time.RFC3339)
+					}
+				case "created_at":
+					row[i] = eq.CreatedAt.UTC().Format(time.RFC3339)
+				case "updated_at":
+					row[i] = eq.UpdatedAt.UTC().Format(time.RFC3339)
+				case "retired_at":
+					if eq.RetiredAt != nil {
+						row[i] = eq.RetiredAt.UTC().Format(time.RFC3339)
+					}
+				default:
+					if key, ok := strings.CutPrefix(column, "field."); ok {
+						row[i] = fields[key]
+					}
+				}
+			}
+
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func openIssueCounts(ctx context.Context, equipmentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		EquipmentID uuid.UUID
+		Count       int64
+	}
+	err := database.Conn(ctx).
+		Model(&models.Issue{}).
+		Select("equipment_id, COUNT(*) AS count").
+		Where("equipment_id IN ? AND date_completed IS NULL", equipmentIDs).
+		Group("equipment_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.EquipmentID] = row.Count
+	}
+	return counts, nil
+}
+
+// lastInspectionDates returns when each piece of equipment last had an
+// inspection completed.
+func lastInspectionDates(ctx context.Context, equipmentIDs []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	var rows []struct {
+		EquipmentID uuid.UUID
+		Last        time.Time
+	}
+	err := database.Conn(ctx).
+		Model(&models.InspectionRun{}).
+		Select("equipment_id, MAX(completed_at) AS last").
+		Where("equipment_id IN ? AND completed_at IS NOT NULL", equipmentIDs).
+		Group("equipment_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make(map[uuid.UUID]time.Time, len(rows))
+	for _, row := range rows {
+		dates[row.EquipmentID] = row.Last
+	}
+	return dates, nil
+}
@@ -0,0 +1,318 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFieldNotFound     = errors.New("field definition not found")
+	ErrFieldDuplicate    = errors.New("a field with this key already exists for this equipment type")
+	ErrFieldKeyRequired  = errors.New("field key cannot be blank")
+	ErrFieldNeedsOptions = errors.New("enum fields need at least one option")
+	ErrFieldBadDefault   = errors.New("default value does not match the field definition")
+)
+
+// maxTextFieldLength caps text values stored in MoreFields.
+const maxTextFieldLength = 1024
+
+// FieldError describes why one MoreFields entry does not match its definition.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldValidationError is returned when MoreFields does not match the schema of
+// the equipment's type.
+type FieldValidationError struct {
+	Errors []FieldError
+}
+
+func (e *FieldValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fieldErr.Field+": "+fieldErr.Message)
+	}
+	return "more_fields do not match the schema: " + strings.Join(messages, "; ")
+}
+
+// NonConformingEquipment lists the schema violations of one piece of equipment.
+type NonConformingEquipment struct {
+	EquipmentID uuid.UUID    `json:"equipmentId"`
+	Errors      []FieldError `json:"errors"`
+}
+
+func GetEquipmentFields(ctx context.Context, businessID uuid.UUID, equipmentType string) ([]models.EquipmentField, error) {
+	query := database.Conn(ctx).Where("business_id = ?", businessID)
+	if equipmentType != "" {
+		query = query.Where("equipment_type = ?", equipmentType)
+	}
+
+	var fields []models.EquipmentField
+	err := query.Order("equipment_type ASC, position ASC, key ASC").Find(&fields).Error
+	return fields, err
+}
+
+func GetEquipmentFieldByID(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.EquipmentField, error) {
+	var field models.EquipmentField
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&field).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFieldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func CreateEquipmentField(ctx context.Context, field *models.EquipmentField) error {
+	field.Key = strings.TrimSpace(field.Key)
+	if field.Key == "" {
+		return ErrFieldKeyRequired
+	}
+	if err := checkFieldDefinition(field); err != nil {
+		return err
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(field).Error
+	})
+	if uniqueViolation(err, "idx_equipment_field_key") {
+		return ErrFieldDuplicate
+	}
+	return err
+}
+
+func UpdateEquipmentField(ctx context.Context, field *models.EquipmentField) error {
+	if err := checkFieldDefinition(field); err != nil {
+		return err
+	}
+
+	return database.Conn(ctx).
+		Model(&models.EquipmentField{}).
+		Where("id = ? AND business_id = ?", field.ID, field.BusinessID).
+		Updates(map[string]any{
+			"label":    field.Label,
+			"kind":     field.Kind,
+			"unit":     field.Unit,
+			"options":  field.Options,
+			"required": field.Required,
+			"default":  field.Default,
+			"position": field.Position,
+		}).Error
+}
+
+func DeleteEquipmentField(ctx context.Context, businessID uuid.UUID, id uuid.UUID) error {
+	result := database.Conn(ctx).Where("id = ? AND business_id = ?", id, businessID).Delete(&models.EquipmentField{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrFieldNotFound
+	}
+	return nil
+}
+
+// ApplyFieldSchema validates custom fields against the schema of an equipment
+// type and fills in defaults for missing fields. Keys without a definition are
+// kept as they are, so types without a schema accept any flat object.
+func ApplyFieldSchema(ctx context.Context, businessID uuid.UUID, equipmentType string, fields map[string]any) (map[string]any, error) {
+	definitions, err := GetEquipmentFields(ctx, businessID, equipmentType)
+	if err != nil {
+		return nil, err
+	}
+	return applyFieldDefinitions(definitions, fields)
+}
+
+// applyFieldDefinitions is ApplyFieldSchema with the definitions already loaded.
+func applyFieldDefinitions(definitions []models.EquipmentField, fields map[string]any) (map[string]any, error) {
+	if fields == nil {
+		fields = map[string]any{}
+	}
+
+	var fieldErrors []FieldError
+	for _, definition := range definitions {
+		value, present := fields[definition.Key]
+		if !present || value == nil {
+			if len(definition.Default) > 0 {
+				var defaultValue any
+				if err := json.Unmarshal(definition.Default, &defaultValue); err == nil && defaultValue != nil {
+					fields[definition.Key] = defaultValue
+					continue
+				}
+			}
+		}
+
+		if fieldErr := validateFieldValue(definition, fields[definition.Key]); fieldErr != nil {
+			fieldErrors = append(fieldErrors, *fieldErr)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &FieldValidationError{Errors: fieldErrors}
+	}
+	return fields, nil
+}
+
+// CheckFieldSchemaConformance reports the equipment of a type whose stored custom
+// fields no longer match the type's schema, for example after a field was made
+// required or its kind changed. Retired equipment is left out.
+func CheckFieldSchemaConformance(ctx context.Context, businessID uuid.UUID, equipmentType string) ([]NonConformingEquipment, error) {
+	definitions, err := GetEquipmentFields(ctx, businessID, equipmentType)
+	if err != nil {
+		return nil, err
+	}
+
+	report := []NonConformingEquipment{}
+	if len(definitions) == 0 {
+		return report, nil
+	}
+
+	var equipment []models.Equipment
+	err = database.Conn(ctx).
+		Select("id", "more_fields").
+		Where("business_id = ? AND type = ? AND retired_at IS NULL", businessID, equipmentType).
+		Order("id").
+		Find(&equipment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, eq := range equipment {
+		fields := map[string]any{}
+		if len(eq.MoreFields) > 0 {
+			_ = json.Unmarshal(eq.MoreFields, &fields)
+		}
+
+		var fieldErrors []FieldError
+		for _, definition := range definitions {
+			if fieldErr := validateFieldValue(definition, fields[definition.Key]); fieldErr != nil {
+				fieldErrors = append(fieldErrors, *fieldErr)
+			}
+		}
+		if len(fieldErrors) > 0 {
+			report = append(report, NonConformingEquipment{EquipmentID: eq.ID, Errors: fieldErrors})
+		}
+	}
+
+	return report, nil
+}
+
+// ApplyFieldSchemaJSON is ApplyFieldSchema for MoreFields already encoded as JSON.
+func ApplyFieldSchemaJSON(ctx context.Context, businessID uuid.UUID, equipmentType string, raw datatypes.JSON) (datatypes.JSON, error) {
+	fields := map[string]any{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+			fields = map[string]any{}
+		}
+	}
+
+	fields, err := ApplyFieldSchema(ctx, businessID, equipmentType, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return nil, ErrInvalidMoreFields
+	}
+	return datatypes.JSON(encoded), nil
+}
+
+func checkFieldDefinition(field *models.EquipmentField) error {
+	if field.Kind == models.FieldKindEnum && len(field.Options) == 0 {
+		return ErrFieldNeedsOptions
+	}
+	if field.Kind != models.FieldKindNumber {
+		field.Unit = ""
+	}
+	if field.Kind != models.FieldKindEnum {
+		field.Options = nil
+	}
+
+	if len(field.Default) > 0 && string(field.Default) != "null" {
+		var defaultValue any
+		if err := json.Unmarshal(field.Default, &defaultValue); err != nil {
+			return ErrFieldBadDefault
+		}
+		if validateFieldValue(*field, defaultValue) != nil {
+			return ErrFieldBadDefault
+		}
+	} else {
+		field.Default = nil
+	}
+
+	return nil
+}
+
+// validateFieldValue checks a single value against its definition. A nil value
+// is only an error for required fields.
+func validateFieldValue(definition models.EquipmentField, value any) *FieldError {
+	fail := func(format string, args ...any) *FieldError {
+		return &FieldError{Field: definition.Key, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if value == nil {
+		if definition.Required {
+			return fail("is required")
+		}
+		return nil
+	}
+
+	switch definition.Kind {
+	case models.FieldKindText:
+		text, ok := value.(string)
+		if !ok {
+			return fail("must be text")
+		}
+		if definition.Required && strings.TrimSpace(text) == "" {
+			return fail("is required")
+		}
+		if len(text) > maxTextFieldLength {
+			return fail("must be at most %d characters", maxTextFieldLength)
+		}
+	case models.FieldKindNumber:
+		switch value.(type) {
+		case float64, json.Number:
+		default:
+			if definition.Unit != "" {
+				return fail("must be a number (in %s)", definition.Unit)
+			}
+			return fail("must be a number")
+		}
+	case models.FieldKindDate:
+		text, ok := value.(string)
+		if !ok {
+			return fail("must be a date (YYYY-MM-DD)")
+		}
+		if _, err := time.Parse(time.DateOnly, text); err != nil {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				return fail("must be a date (YYYY-MM-DD)")
+			}
+		}
+	case models.FieldKindEnum:
+		text, ok := value.(string)
+		if !ok || !slices.Contains(definition.Options, text) {
+			return fail("must be one of %s", strings.Join(definition.Options, ", "))
+		}
+	case models.FieldKindBoolean:
+		if _, ok := value.(bool); !ok {
+			return fail("must be true or false")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,859 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ImportModeAtomic  = "atomic"
+	ImportModeChunked = "chunked"
+
+	MaxImportRows          = 5000
+	DefaultImportChunkSize = 100
+)
+
+// Column targets an import header can be mapped to. Any other target is
+// "field.<key>", which stores the column in MoreFields.
+const (
+	importColumnIgnore     = "ignore"
+	importColumnID         = "id"
+	importColumnStatus     = "status"
+	importColumnType       = "type"
+	importColumnTypeID     = "type_id"
+	importColumnLocation   = "location"
+	importColumnLocationID = "location_id"
+	importFieldPrefix      = "field."
+)
+
+// importReadOnlyColumns are written by the export but have no meaning on import,
+// so exported files can be imported again unchanged.
+var importReadOnlyColumns = map[string]bool{
+	"created_at":      true,
+	"updated_at":      true,
+	"retired_at":      true,
+	"open_issues":     true,
+	"last_inspection": true,
+}
+
+var (
+	ErrImportEmpty        = errors.New("spreadsheet has no data rows")
+	ErrImportTooLarge     = fmt.Errorf("imports are limited to %d rows", MaxImportRows)
+	ErrImportNoType       = errors.New("spreadsheet needs a type or type_id column")
+	ErrImportBadMapping   = errors.New("invalid column mapping")
+	ErrImportInvalid      = errors.New("some rows are invalid, nothing was imported")
+	ErrImportNotFound     = errors.New("import not found")
+	ErrImportNotResumable = errors.New("import is completed or still running")
+)
+
+// ImportRow is a validated spreadsheet row ready to become equipment. Row is
+// the spreadsheet row number, counting the header as row 1. ID is set when the
+// row's id column names existing equipment, which the row then updates, so an
+// exported file can be edited and imported again.
+type ImportRow struct {
+	Row        int            `json:"row"`
+	ID         *uuid.UUID     `json:"id,omitempty"`
+	Status     string         `json:"status"`
+	Type       string         `json:"type"`
+	TypeID     *uuid.UUID     `json:"typeId,omitempty"`
+	Location   string         `json:"location"`
+	LocationID *uuid.UUID     `json:"locationId,omitempty"`
+	MoreFields map[string]any `json:"moreFields"`
+}
+
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// ImportDuplicate is a row that repeats an earlier row of the file or matches
+// equipment the business already has.
+type ImportDuplicate struct {
+	Row            int        `json:"row"`
+	DuplicateOfRow int        `json:"duplicateOfRow,omitempty"`
+	EquipmentID    *uuid.UUID `json:"equipmentId,omitempty"`
+}
+
+// ImportReport is the result of validating a spreadsheet.
+type ImportReport struct {
+	TotalRows   int               `json:"totalRows"`
+	ValidRows   int               `json:"validRows"`
+	UpdatedRows int               `json:"updatedRows"` // valid rows that update existing equipment
+	Columns     map[string]string `json:"columns"`
+	NewTypes    []string          `json:"newTypes"`
+	Errors      []ImportRowError  `json:"errors"`
+	Duplicates  []ImportDuplicate `json:"duplicates"`
+}
+
+type ImportOptions struct {
+	// Mapping overrides the target of a column by its header.
+	Mapping map[string]string
+	// UniqueField is a MoreFields key, such as a serial number, that must not repeat
+	// within the file or match existing equipment.
+	UniqueField string
+}
+
+// PrepareImport maps and validates spreadsheet rows without writing anything.
+// The returned rows are the valid ones; invalid and duplicate rows are listed in
+// the report.
+func PrepareImport(ctx context.Context, businessID uuid.UUID, rows [][]string, options ImportOptions) (*ImportReport, []ImportRow, error) {
+	if len(rows) < 2 {
+		return nil, nil, ErrImportEmpty
+	}
+	if len(rows)-1 > MaxImportRows {
+		return nil, nil, ErrImportTooLarge
+	}
+
+	header := rows[0]
+	targets, err := importColumnTargets(header, options.Mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hasType := false
+	for _, target := range targets {
+		if target == importColumnType || target == importColumnTypeID {
+			hasType = true
+		}
+	}
+	if !hasType {
+		return nil, nil, ErrImportNoType
+	}
+
+	report := &ImportReport{
+		Columns:    map[string]string{},
+		NewTypes:   []string{},
+		Errors:     []ImportRowError{},
+		Duplicates: []ImportDuplicate{},
+	}
+	for i, target := range targets {
+		if strings.TrimSpace(header[i]) != "" {
+			report.Columns[strings.TrimSpace(header[i])] = target
+		}
+	}
+
+	v, err := newImportValidator(ctx, businessID, options.UniqueField)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var valid []ImportRow
+	for index, cells := range rows[1:] {
+		rowNumber := index + 2
+
+		values := map[string]string{}
+		empty := true
+		for i, target := range targets {
+			if i >= len(cells) || target == importColumnIgnore {
+				continue
+			}
+			value := strings.TrimSpace(cells[i])
+			if value != "" {
+				values[target] = value
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+		report.TotalRows++
+
+		row, problems, err := v.validate(ctx, rowNumber, values)
+		if err != nil {
+			return nil, nil, err
+		}
+		if duplicate := v.duplicateOf(row); duplicate != nil {
+			report.Duplicates = append(report.Duplicates, *duplicate)
+			if duplicate.EquipmentID != nil {
+				problems = append(problems, "matches existing equipment "+duplicate.EquipmentID.String())
+			} else {
+				problems = append(problems, fmt.Sprintf("duplicate of row %d", duplicate.DuplicateOfRow))
+			}
+		}
+
+		if len(problems) > 0 {
+			report.Errors = append(report.Errors, ImportRowError{Row: rowNumber, Errors: problems})
+			continue
+		}
+		if row.ID != nil {
+			report.UpdatedRows++
+		}
+		valid = append(valid, row)
+	}
+
+	if report.TotalRows == 0 {
+		return nil, nil, ErrImportEmpty
+	}
+	report.ValidRows = len(valid)
+	report.NewTypes = v.newTypes
+	return report, valid, nil
+}
+
+// CommitImport saves every row in one transaction and returns the equipment IDs
+// in row order. Types that are not in the catalog yet are added first, in the
+// same transaction.
+func CommitImport(ctx context.Context, businessID uuid.UUID, actorID uuid.UUID, rows []ImportRow) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.Transaction(ctx, func(ctx context.Context) error {
+		if err := resolveImportTypes(ctx, businessID, rows); err != nil {
+			return err
+		}
+		var err error
+		ids, err = insertImportRows(database.Conn(ctx), businessID, actorID, rows)
+		return err
+	})
+	return ids, err
+}
+
+// StartChunkedImport saves the rows as an import job and creates them in the
+// background, one transaction per chunk. A failed job can be resumed where it
+// stopped with ResumeImport.
+func StartChunkedImport(ctx context.Context, businessID uuid.UUID, actorID uuid.UUID, filename string, rows []ImportRow, report *ImportReport, chunkSize int) (*models.EquipmentImport, error) {
+	if chunkSize < 1 {
+		chunkSize = DefaultImportChunkSize
+	}
+
+	encodedRows, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+	encodedReport, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.EquipmentImport{
+		BusinessID: businessID,
+		CreatedBy:  actorID,
+		Filename:   filename,
+		Status:     models.ImportStatusRunning,
+		ChunkSize:  chunkSize,
+		TotalRows:  len(rows),
+		CreatedIDs: []string{},
+		Rows:       datatypes.JSON(encodedRows),
+		Report:     datatypes.JSON(encodedReport),
+	}
+	if err := database.Conn(importJobContext()).Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	startImportRun(job.ID)
+	return &job, nil
+}
+
+func GetImport(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.EquipmentImport, error) {
+	var job models.EquipmentImport
+	err := database.Conn(ctx).Where("id = ? AND business_id = ?", id, businessID).Take(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ResumeImport continues a chunked import that stopped, for example because the
+// server restarted or the database was unavailable.
+func ResumeImport(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.EquipmentImport, error) {
+	job, err := GetImport(ctx, businessID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == models.ImportStatusCompleted {
+		return nil, ErrImportNotResumable
+	}
+	if _, running := runningImports.Load(job.ID); running {
+		return nil, ErrImportNotResumable
+	}
+
+	job.Status = models.ImportStatusRunning
+	job.Error = ""
+	err = database.Conn(importJobContext()).Model(&models.EquipmentImport{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]any{"status": job.Status, "error": ""}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	startImportRun(job.ID)
+	return job, nil
+}
+
+// runningImports holds the IDs of imports being processed by this instance.
+var runningImports sync.Map
+
+// importJobContext is used for the job records, which the background run reads
+// and writes after the request that started it has finished. They are committed
+// straight away rather than with the request's transaction.
+func importJobContext() context.Context {
+	return database.SystemContext(context.Background())
+}
+
+func startImportRun(id uuid.UUID) {
+	if _, running := runningImports.LoadOrStore(id, true); running {
+		return
+	}
+
+	go func() {
+		ctx := importJobContext()
+		defer runningImports.Delete(id)
+		if err := runImport(ctx, id); err != nil {
+			log.Printf("⚠️  Equipment import %s stopped: %v", id, err)
+			database.Conn(ctx).Model(&models.EquipmentImport{}).
+				Where("id = ?", id).
+				Updates(map[string]any{"status": models.ImportStatusFailed, "error": err.Error()})
+		}
+	}()
+}
+
+// runImport commits the remaining chunks of an import. Each chunk and the job's
+// progress are saved in the same transaction, so a chunk is never created twice.
+func runImport(ctx context.Context, id uuid.UUID) error {
+	var job models.EquipmentImport
+	if err := database.Conn(ctx).Where("id = ?", id).Take(&job).Error; err != nil {
+		return err
+	}
+
+	var rows []ImportRow
+	if err := json.Unmarshal(job.Rows, &rows); err != nil {
+		return err
+	}
+
+	for job.ProcessedRows < len(rows) {
+		end := min(job.ProcessedRows+job.ChunkSize, len(rows))
+		chunk := rows[job.ProcessedRows:end]
+
+		err := database.WithTenantScope(context.Background(), job.CreatedBy, func(ctx context.Context) error {
+			if err := database.SetTenantBusiness(ctx, job.BusinessID); err != nil {
+				return err
+			}
+
+			// Types are added with the chunk that first needs them.
+			if err := resolveImportTypes(ctx, job.BusinessID, chunk); err != nil {
+				return err
+			}
+
+			tx := database.Conn(ctx)
+			ids, err := insertImportRows(tx, job.BusinessID, job.CreatedBy, chunk)
+			if err != nil {
+				return err
+			}
+			for _, createdID := range ids {
+				job.CreatedIDs = append(job.CreatedIDs, createdID.String())
+			}
+
+			result := tx.Model(&models.EquipmentImport{}).
+				Where("id = ? AND processed_rows = ?", job.ID, job.ProcessedRows).
+				Updates(map[string]any{"processed_rows": end, "created_ids": job.CreatedIDs})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errors.New("import progress changed concurrently")
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("rows %d-%d: %w", chunk[0].Row, chunk[len(chunk)-1].Row, err)
+		}
+		job.ProcessedRows = end
+	}
+
+	return database.Conn(ctx).Model(&models.EquipmentImport{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]any{"status": models.ImportStatusCompleted, "completed_at": time.Now()}).Error
+}
+
+// insertImportRows creates the rows' new equipment and updates the equipment
+// named by rows with an ID. It returns the equipment IDs in row order.
+func insertImportRows(tx *gorm.DB, businessID uuid.UUID, actorID uuid.UUID, rows []ImportRow) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(rows))
+	equipment := make([]models.Equipment, 0, len(rows))
+	positions := make([]int, 0, len(rows))
+	for i, row := range rows {
+		moreFields, err := json.Marshal(row.MoreFields)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row.Row, ErrInvalidMoreFields)
+		}
+		locationID := row.LocationID
+		if locationID == nil {
+			if locationID, err = ensureLocationPath(tx, businessID, row.Location); err != nil {
+				return nil, fmt.Errorf("row %d: %w", row.Row, err)
+			}
+		}
+
+		if row.ID != nil {
+			if err := updateImportedEquipment(tx, businessID, actorID, row, locationID, datatypes.JSON(moreFields)); err != nil {
+				return nil, fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			ids[i] = *row.ID
+			continue
+		}
+
+		eq := models.Equipment{
+			BusinessID: businessID,
+			Status:     row.Status,
+			Type:       row.Type,
+			TypeID:     row.TypeID,
+			Location:   row.Location,
+			LocationID: locationID,
+			MoreFields: datatypes.JSON(moreFields),
+		}
+		if row.Status == models.EquipmentStatusRetired {
+			now := time.Now()
+			eq.RetiredAt = &now
+			eq.RetiredBy = &actorID
+			eq.RetirementReason = "imported"
+		}
+		equipment = append(equipment, eq)
+		positions = append(positions, i)
+	}
+
+	if len(equipment) == 0 {
+		return ids, nil
+	}
+	if err := tx.Omit(clause.Associations).CreateInBatches(&equipment, 100).Error; err != nil {
+		return nil, err
+	}
+
+	var typeIDs []uuid.UUID
+	for _, eq := range equipment {
+		if eq.TypeID != nil {
+			typeIDs = append(typeIDs, *eq.TypeID)
+		}
+	}
+	var planTypes []models.EquipmentType
+	if len(typeIDs) > 0 {
+		err := tx.Where("id IN ? AND default_maintenance_plan IS NOT NULL", typeIDs).Find(&planTypes).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	typesWithPlans := make(map[uuid.UUID]*models.EquipmentType, len(planTypes))
+	for i := range planTypes {
+		typesWithPlans[planTypes[i].ID] = &planTypes[i]
+	}
+
+	for i := range equipment {
+		if err := recordStatusEvent(tx, &equipment[i], "", equipment[i].Status, &actorID, "imported"); err != nil {
+			return nil, err
+		}
+		if equipment[i].TypeID != nil && equipment[i].RetiredAt == nil {
+			if err := createDefaultMaintenancePlan(tx, &equipment[i], typesWithPlans[*equipment[i].TypeID], &actorID); err != nil {
+				return nil, err
+			}
+		}
+		ids[positions[i]] = equipment[i].ID
+	}
+	return ids, nil
+}
+
+// updateImportedEquipment saves a row over the equipment it names. Imports are
+// admin-only, so a status change skips the business's transition rules, as
+// retiring does. Retired equipment is left untouched by rows that keep it retired.
+func updateImportedEquipment(tx *gorm.DB, businessID uuid.UUID, actorID uuid.UUID, row ImportRow, locationID *uuid.UUID, moreFields datatypes.JSON) error {
+	var eq models.Equipment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND business_id = ?", *row.ID, businessID).
+		Take(&eq).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrEquipmentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if eq.RetiredAt != nil {
+		if row.Status == models.EquipmentStatusRetired {
+			return nil
+		}
+		return ErrEquipmentRetired
+	}
+
+	updates := map[string]any{
+		"type":        row.Type,
+		"type_id":     row.TypeID,
+		"location":    row.Location,
+		"location_id": locationID,
+		"more_fields": moreFields,
+	}
+	if row.Status != eq.Status {
+		updates["status"] = row.Status
+	}
+	if row.Status == models.EquipmentStatusRetired {
+		updates["retired_at"] = time.Now()
+		updates["retired_by"] = actorID
+		updates["retirement_reason"] = "imported"
+	}
+	if err := tx.Model(&models.Equipment{}).Where("id = ?", eq.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	if row.Status != eq.Status {
+		return recordStatusEvent(tx, &eq, eq.Status, row.Status, &actorID, "imported")
+	}
+	return nil
+}
+
+// resolveImportTypes adds the rows' unknown types to the catalog and links every
+// row to its catalog entry.
+func resolveImportTypes(ctx context.Context, businessID uuid.UUID, rows []ImportRow) error {
+	resolved := map[string]*models.EquipmentType{}
+	for i := range rows {
+		if rows[i].TypeID != nil {
+			continue
+		}
+		key := normalizeTypeName(rows[i].Type)
+		equipmentType, ok := resolved[key]
+		if !ok {
+			var err error
+			equipmentType, err = ResolveEquipmentType(ctx, businessID, rows[i].Type)
+			if err != nil {
+				return err
+			}
+			resolved[key] = equipmentType
+		}
+		rows[i].Type = equipmentType.Name
+		rows[i].TypeID = &equipmentType.ID
+	}
+	return nil
+}
+
+// importColumnTargets decides what each column is imported as. Headers that are
+// not equipment attributes become MoreFields keys, so a "Serial Number" column is
+// stored as MoreFields["Serial Number"]; "field.<key>" headers, as written by the
+// export, name the key explicitly.
+func importColumnTargets(header []string, mapping map[string]string) ([]string, error) {
+	targets := make([]string, len(header))
+	used := map[string]string{}
+
+	for i, raw := range header {
+		name := strings.TrimSpace(raw)
+		target, mapped := mapping[name]
+		if !mapped {
+			target = defaultImportTarget(name)
+		}
+
+		switch {
+		case target == importColumnIgnore:
+		case target == importColumnID, target == importColumnStatus, target == importColumnType,
+			target == importColumnTypeID, target == importColumnLocation, target == importColumnLocationID:
+		case strings.HasPrefix(target, importFieldPrefix) && len(target) > len(importFieldPrefix):
+		default:
+			return nil, fmt.Errorf("%w: column %q has unknown target %q", ErrImportBadMapping, name, target)
+		}
+
+		if target != importColumnIgnore {
+			if previous, ok := used[target]; ok {
+				return nil, fmt.Errorf("%w: columns %q and %q both map to %s", ErrImportBadMapping, previous, name, target)
+			}
+			used[target] = name
+		}
+		targets[i] = target
+	}
+	return targets, nil
+}
+
+func defaultImportTarget(name string) string {
+	if name == "" {
+		return importColumnIgnore
+	}
+
+	for _, prefix := range []string{"field.", "more_fields.", "moreFields."} {
+		if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+			return importFieldPrefix + key
+		}
+	}
+
+	normalized := strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(name))
+	switch normalized {
+	case importColumnID, importColumnStatus, importColumnType, importColumnTypeID, importColumnLocation, importColumnLocationID:
+		return normalized
+	case "typeid":
+		return importColumnTypeID
+	case "locationid":
+		return importColumnLocationID
+	}
+	if importReadOnlyColumns[normalized] {
+		return importColumnIgnore
+	}
+	return importFieldPrefix + name
+}
+
+// importValidator holds what is needed to validate rows without a query per row.
+type importValidator struct {
+	businessID  uuid.UUID
+	uniqueField string
+
+	typesByName map[string]*models.EquipmentType
+	typesByID   map[uuid.UUID]*models.EquipmentType
+	definitions map[string][]models.EquipmentField // by lower-cased type name
+	locations   map[uuid.UUID]string
+	newTypes    []string
+
+	existing       map[uuid.UUID]*models.Equipment // nil when the ID is not in the business
+	seenIDs        map[uuid.UUID]int
+	existingUnique map[string]uuid.UUID
+	seenUnique     map[string]int
+	seenRows       map[string]int
+}
+
+func newImportValidator(ctx context.Context, businessID uuid.UUID, uniqueField string) (*importValidator, error) {
+	v := &importValidator{
+		businessID:     businessID,
+		uniqueField:    uniqueField,
+		typesByName:    map[string]*models.EquipmentType{},
+		typesByID:      map[uuid.UUID]*models.EquipmentType{},
+		definitions:    map[string][]models.EquipmentField{},
+		locations:      map[uuid.UUID]string{},
+		newTypes:       []string{},
+		existing:       map[uuid.UUID]*models.Equipment{},
+		seenIDs:        map[uuid.UUID]int{},
+		existingUnique: map[string]uuid.UUID{},
+		seenUnique:     map[string]int{},
+		seenRows:       map[string]int{},
+	}
+
+	var types []models.EquipmentType
+	if err := database.Conn(ctx).Where("business_id = ?", businessID).Find(&types).Error; err != nil {
+		return nil, err
+	}
+	for i := range types {
+		v.typesByName[normalizeTypeName(types[i].Name)] = &types[i]
+		v.typesByID[types[i].ID] = &types[i]
+	}
+
+	fields, err := GetEquipmentFields(ctx, businessID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		key := normalizeTypeName(field.EquipmentType)
+		v.definitions[key] = append(v.definitions[key], field)
+	}
+
+	if uniqueField != "" {
+		var existing []struct {
+			ID    uuid.UUID
+			Value string
+		}
+		err := database.Conn(ctx).
+			Model(&models.Equipment{}).
+			Select("id, more_fields ->> ? AS value", uniqueField).
+			Where("business_id = ? AND retired_at IS NULL AND more_fields ->> ? IS NOT NULL", businessID, uniqueField).
+			Scan(&existing).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range existing {
+			v.existingUnique[strings.ToLower(row.Value)] = row.ID
+		}
+	}
+
+	return v, nil
+}
+
+func (v *importValidator) validate(ctx context.Context, rowNumber int, values map[string]string) (ImportRow, []string, error) {
+	row := ImportRow{Row: rowNumber, MoreFields: map[string]any{}}
+	var problems []string
+
+	row.Status = models.EquipmentStatusInService
+	if status, ok := values[importColumnStatus]; ok {
+		row.Status = strings.ToLower(status)
+		if !ValidEquipmentStatus(row.Status) {
+			problems = append(problems, fmt.Sprintf("unknown status %q", status))
+		}
+	}
+
+	// IDs from another business, such as an export of a different account, are
+	// not an error; the row simply creates new equipment.
+	if rawID, ok := values[importColumnID]; ok {
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("invalid id %q", rawID))
+		} else {
+			eq, err := v.existingEquipment(ctx, id)
+			if err != nil {
+				return row, nil, err
+			}
+			// Retired equipment is read-only; exported rows that still say
+			// "retired" are accepted and leave it as it is.
+			if eq != nil && eq.RetiredAt != nil && row.Status != models.EquipmentStatusRetired {
+				problems = append(problems, "equipment "+id.String()+" is retired")
+			}
+			if eq != nil {
+				row.ID = &id
+			}
+		}
+	}
+
+	var equipmentType *models.EquipmentType
+	if typeID, ok := values[importColumnTypeID]; ok {
+		id, err := uuid.Parse(typeID)
+		if err != nil || v.typesByID[id] == nil {
+			problems = append(problems, fmt.Sprintf("unknown type_id %q", typeID))
+		} else {
+			equipmentType = v.typesByID[id]
+		}
+	} else if typeName, ok := values[importColumnType]; ok {
+		equipmentType = v.typesByName[normalizeTypeName(typeName)]
+		if equipmentType == nil {
+			v.newTypes = append(v.newTypes, typeName)
+			equipmentType = &models.EquipmentType{BusinessID: v.businessID, Name: typeName}
+			v.typesByName[normalizeTypeName(typeName)] = equipmentType
+		}
+	} else {
+		problems = append(problems, "type is required")
+	}
+	if equipmentType != nil {
+		row.Type = equipmentType.Name
+		if equipmentType.ID != uuid.Nil {
+			id := equipmentType.ID
+			row.TypeID = &id
+		}
+	}
+
+	row.Location = values[importColumnLocation]
+	if locationID, ok := values[importColumnLocationID]; ok {
+		id, err := uuid.Parse(locationID)
+		label, known := v.locations[id]
+		if err == nil && !known {
+			label, err = GetLocationPathLabel(ctx, v.businessID, id)
+			if err == nil {
+				v.locations[id] = label
+			}
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("unknown location_id %q", locationID))
+		} else {
+			row.LocationID = &id
+			row.Location = label
+		}
+	}
+
+	var definitions []models.EquipmentField
+	if equipmentType != nil {
+		definitions = v.definitions[normalizeTypeName(equipmentType.Name)]
+	}
+	for target, value := range values {
+		if key, ok := strings.CutPrefix(target, importFieldPrefix); ok {
+			row.MoreFields[key] = importFieldValue(definitions, key, value)
+		}
+	}
+
+	if equipmentType != nil {
+		fields, err := applyFieldDefinitions(definitions, ApplyTypeDefaults(equipmentType, row.MoreFields))
+		var fieldErr *FieldValidationError
+		if errors.As(err, &fieldErr) {
+			for _, problem := range fieldErr.Errors {
+				problems = append(problems, problem.Field+" "+problem.Message)
+			}
+		} else if err == nil {
+			row.MoreFields = fields
+		}
+	}
+
+	return row, problems, nil
+}
+
+// duplicateOf reports whether a row repeats an earlier row, by ID, by the unique
+// field or by all of its values, or matches other existing equipment by the
+// unique field. A row naming existing equipment by ID updates it and is not a
+// duplicate of it.
+func (v *importValidator) duplicateOf(row ImportRow) *ImportDuplicate {
+	if row.ID != nil {
+		if first, seen := v.seenIDs[*row.ID]; seen {
+			return &ImportDuplicate{Row: row.Row, DuplicateOfRow: first}
+		}
+		v.seenIDs[*row.ID] = row.Row
+	}
+
+	if v.uniqueField != "" {
+		if value, ok := row.MoreFields[v.uniqueField]; ok && value != nil {
+			key := strings.ToLower(fmt.Sprint(value))
+			if id, exists := v.existingUnique[key]; exists && (row.ID == nil || *row.ID != id) {
+				return &ImportDuplicate{Row: row.Row, EquipmentID: &id}
+			}
+			if first, seen := v.seenUnique[key]; seen {
+				return &ImportDuplicate{Row: row.Row, DuplicateOfRow: first}
+			}
+			v.seenUnique[key] = row.Row
+		}
+	}
+
+	if row.ID != nil {
+		return nil
+	}
+	signature, _ := json.Marshal([]any{row.Status, normalizeTypeName(row.Type), row.Location, row.LocationID, row.MoreFields})
+	if first, seen := v.seenRows[string(signature)]; seen {
+		return &ImportDuplicate{Row: row.Row, DuplicateOfRow: first}
+	}
+	v.seenRows[string(signature)] = row.Row
+	return nil
+}
+
+// existingEquipment returns the business's equipment with the given ID, or nil
+// when there is none.
+func (v *importValidator) existingEquipment(ctx context.Context, id uuid.UUID) (*models.Equipment, error) {
+	if eq, checked := v.existing[id]; checked {
+		return eq, nil
+	}
+
+	var eq models.Equipment
+	err := database.Conn(ctx).
+		Select("id", "status", "retired_at").
+		Where("id = ? AND business_id = ?", id, v.businessID).
+		Take(&eq).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		v.existing[id] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.existing[id] = &eq
+	return &eq, nil
+}
+
+// importFieldValue converts a cell to the type its field definition expects, so
+// "12.5" becomes a number and "yes" a boolean. Values that do not convert are
+// kept as text and reported by the schema check.
+func importFieldValue(definitions []models.EquipmentField, key string, value string) any {
+	for _, definition := range definitions {
+		if definition.Key != key {
+			continue
+		}
+		switch definition.Kind {
+		case models.FieldKindNumber:
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				return number
+			}
+		case models.FieldKindBoolean:
+			switch strings.ToLower(value) {
+			case "true", "yes", "y", "1":
+				return true
+			case "false", "no", "n", "0":
+				return false
+			}
+		case models.FieldKindDate:
+			// XLSX stores dates as days since 1899-12-30.
+			if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+				return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format(time.DateOnly)
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,314 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrMeterNotFound        = errors.New("meter not found")
+	ErrMeterDuplicate       = errors.New("this equipment already has a meter with this name")
+	ErrMeterReadingNotFound = errors.New("meter reading not found")
+	ErrMeterRollback        = errors.New("reading is lower than an earlier reading of this meter")
+	ErrMeterReadingFuture   = errors.New("reading time is in the future")
+	ErrInvalidUsageInterval = errors.New("interval must be day or week")
+)
+
+// meterClockSkew is how far ahead of the server clock a reading may be dated,
+// to allow for devices whose clocks run slightly fast.
+const meterClockSkew = 5 * time.Minute
+
+// Values for the interval of GetMeterUsage.
+const (
+	UsageIntervalDay  = "day"
+	UsageIntervalWeek = "week"
+)
+
+// NewMeterReading is a reading to record on one of an equipment's meters.
+type NewMeterReading struct {
+	MeterID uuid.UUID
+	Value   float64
+	ReadAt  time.Time // zero means now
+	Reset   bool
+}
+
+// MeterUsage summarises the readings of a meter within one day or week (UTC).
+// Usage is the increase since the previous reading, summed over the readings in
+// the period, so for monotonic meters it is the hours run or distance covered.
+type MeterUsage struct {
+	Start    time.Time `json:"start"`
+	Usage    float64   `json:"usage"`
+	Min      float64   `json:"min"`
+	Max      float64   `json:"max"`
+	Average  float64   `json:"average"`
+	Last     float64   `json:"last"`
+	Readings int64     `json:"readings"`
+}
+
+func ListEquipmentMeters(ctx context.Context, equipmentID uuid.UUID) ([]models.EquipmentMeter, error) {
+	var meters []models.EquipmentMeter
+	err := database.Conn(ctx).
+		Where("equipment_id = ?", equipmentID).
+		Order("name ASC").
+		Find(&meters).Error
+	return meters, err
+}
+
+func GetEquipmentMeter(ctx context.Context, equipmentID uuid.UUID, meterID uuid.UUID) (*models.EquipmentMeter, error) {
+	var meter models.EquipmentMeter
+	err := database.Conn(ctx).
+		Where("id = ? AND equipment_id = ?", meterID, equipmentID).
+		Take(&meter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMeterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &meter, nil
+}
+
+func CreateEquipmentMeter(ctx context.Context, meter *models.EquipmentMeter) error {
+	meter.Name = strings.TrimSpace(meter.Name)
+	meter.Unit = strings.TrimSpace(meter.Unit)
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(meter).Error
+	})
+	if uniqueViolation(err, "idx_meter_name") {
+		return ErrMeterDuplicate
+	}
+	return err
+}
+
+// UpdateEquipmentMeter saves the name, unit and monotonic flag of a meter.
+// Existing readings are kept as they are.
+func UpdateEquipmentMeter(ctx context.Context, meter *models.EquipmentMeter) error {
+	meter.Name = strings.TrimSpace(meter.Name)
+	meter.Unit = strings.TrimSpace(meter.Unit)
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.EquipmentMeter{}).
+			Where("id = ?", meter.ID).
+			Updates(map[string]any{
+				"name":      meter.Name,
+				"unit":      meter.Unit,
+				"monotonic": meter.Monotonic,
+			}).Error
+	})
+	if uniqueViolation(err, "idx_meter_name") {
+		return ErrMeterDuplicate
+	}
+	return err
+}
+
+// MigrateMeterNames replaces the case-sensitive unique index on meter names
+// with one on LOWER(name), so "Hours" and "hours" cannot both exist.
+func MigrateMeterNames(ctx context.Context) error {
+	return database.Conn(ctx).Exec(`
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_meter_name' AND indexdef NOT ILIKE '%lower(%') THEN
+		DROP INDEX idx_meter_name;
+	END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_meter_name ON equipment_meters (equipment_id, LOWER(name));`).Error
+}
+
+// DeleteEquipmentMeter removes a meter together with its readings.
+func DeleteEquipmentMeter(ctx context.Context, meter *models.EquipmentMeter) error {
+	return database.Conn(ctx).Delete(&models.EquipmentMeter{}, "id = ?", meter.ID).Error
+}
+
+// RecordMeterReadings validates and stores readings for meters of one piece of
+// equipment. Either all readings are stored or none. On monotonic meters a
+// reading may not be lower than the reading before it, and a backdated reading
+// may not be higher than the one after it, unless a reset lies in between.
+func RecordMeterReadings(ctx context.Context, eq *models.Equipment, readings []NewMeterReading, source string, note string, actorID *uuid.UUID) ([]models.MeterReading, error) {
+	now := time.Now()
+	stored := make([]models.MeterReading, 0, len(readings))
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, reading := range readings {
+			var meter models.EquipmentMeter
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ? AND equipment_id = ?", reading.MeterID, eq.ID).
+				Take(&meter).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMeterNotFound
+			}
+			if err != nil {
+				return err
+			}
+
+			readAt := reading.ReadAt
+			if readAt.IsZero() {
+				readAt = now
+			}
+			if readAt.After(now.Add(meterClockSkew)) {
+				return ErrMeterReadingFuture
+			}
+
+			if meter.Monotonic {
+				if err := checkMeterRollback(tx, &meter, reading.Value, readAt, reading.Reset); err != nil {
+					return err
+				}
+			}
+
+			row := models.MeterReading{
+				MeterID:     meter.ID,
+				EquipmentID: eq.ID,
+				BusinessID:  eq.BusinessID,
+				Value:       reading.Value,
+				ReadAt:      readAt,
+				Reset:       reading.Reset,
+				Source:      source,
+				Note:        strings.TrimSpace(note),
+				ActorID:     actorID,
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+
+			if meter.LastReadAt == nil || !readAt.Before(*meter.LastReadAt) {
+				err := tx.Model(&models.EquipmentMeter{}).
+					Where("id = ?", meter.ID).
+					Updates(map[string]any{"last_value": reading.Value, "last_read_at": readAt}).Error
+				if err != nil {
+					return err
+				}
+			}
+			stored = append(stored, row)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func checkMeterRollback(tx *gorm.DB, meter *models.EquipmentMeter, value float64, readAt time.Time, reset bool) error {
+	if !reset {
+		var previous models.MeterReading
+		err := tx.Where("meter_id = ? AND read_at <= ?", meter.ID, readAt).
+			Order("read_at DESC, created_at DESC").
+			Take(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && value < previous.Value {
+			return fmt.Errorf("%w: %g %s on %s", ErrMeterRollback, previous.Value, meter.Unit, previous.ReadAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	var next models.MeterReading
+	err := tx.Where("meter_id = ? AND read_at > ?", meter.ID, readAt).
+		Order("read_at ASC, created_at ASC").
+		Take(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !next.Reset && next.Value < value {
+		return fmt.Errorf("%w: a later reading is %g %s on %s", ErrMeterRollback, next.Value, meter.Unit, next.ReadAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// DeleteMeterReading removes a mistaken reading and brings the meter's last
+// value back in line with the readings that remain.
+func DeleteMeterReading(ctx context.Context, meter *models.EquipmentMeter, readingID uuid.UUID) error {
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND meter_id = ?", readingID, meter.ID).Delete(&models.MeterReading{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMeterReadingNotFound
+		}
+
+		updates := map[string]any{"last_value": nil, "last_read_at": nil}
+		var latest models.MeterReading
+		err := tx.Where("meter_id = ?", meter.ID).Order("read_at DESC, created_at DESC").Take(&latest).Error
+		if err == nil {
+			updates["last_value"] = latest.Value
+			updates["last_read_at"] = latest.ReadAt
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return tx.Model(&models.EquipmentMeter{}).Where("id = ?", meter.ID).Updates(updates).Error
+	})
+}
+
+// ListMeterReadings returns one page of a meter's readings, newest first, and
+// the total number within the optional time range.
+func ListMeterReadings(ctx context.Context, meterID uuid.UUID, from *time.Time, to *time.Time, limit int, offset int) ([]models.MeterReading, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.MeterReading{}).
+		Where("meter_id = ?", meterID)
+	if from != nil {
+		query = query.Where("read_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("read_at < ?", *to)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var readings []models.MeterReading
+	err := query.
+		Preload("Actor").
+		Order("read_at DESC, created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&readings).Error
+	return readings, total, err
+}
+
+// GetMeterUsage aggregates a meter's readings in [from, to) per day or week.
+// Usage since the reading before from counts towards the first period; periods
+// without readings are left out.
+func GetMeterUsage(ctx context.Context, meterID uuid.UUID, interval string, from time.Time, to time.Time) ([]MeterUsage, error) {
+	if interval != UsageIntervalDay && interval != UsageIntervalWeek {
+		return nil, ErrInvalidUsageInterval
+	}
+
+	usage := []MeterUsage{}
+	err := database.Conn(ctx).Raw(`
+		WITH ordered AS (
+			SELECT read_at, value, reset,
+				value - LAG(value) OVER (ORDER BY read_at, created_at) AS delta
+			FROM meter_readings
+			WHERE meter_id = @meter AND read_at < @to
+		)
+		SELECT date_trunc(@interval, read_at AT TIME ZONE 'UTC') AS start,
+			SUM(CASE WHEN reset THEN 0 ELSE COALESCE(delta, 0) END) AS usage,
+			MIN(value) AS min,
+			MAX(value) AS max,
+			AVG(value) AS average,
+			(ARRAY_AGG(value ORDER BY read_at DESC))[1] AS last,
+			COUNT(*) AS readings
+		FROM ordered
+		WHERE read_at >= @from
+		GROUP BY start
+		ORDER BY start`,
+		map[string]any{"meter": meterID, "interval": interval, "from": from, "to": to},
+	).Scan(&usage).Error
+	return usage, err
+}
@@ -0,0 +1,298 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidStatus             = errors.New("unknown equipment status")
+	ErrStatusUnchanged           = errors.New("equipment already has this status")
+	ErrStatusTransitionForbidden = errors.New("this status change is not allowed")
+	ErrStatusConflict            = errors.New("equipment status was changed by another request")
+)
+
+// defaultStatusTransitions apply to businesses that have not configured their own.
+var defaultStatusTransitions = map[string][]string{
+	models.EquipmentStatusCommissioning: {models.EquipmentStatusInService, models.EquipmentStatusRetired},
+	models.EquipmentStatusInService: {
+		models.EquipmentStatusDegraded,
+		models.EquipmentStatusOutOfService,
+		models.EquipmentStatusUnderRepair,
+		models.EquipmentStatusQuarantined,
+		models.EquipmentStatusRetired,
+	},
+	models.EquipmentStatusDegraded: {
+		models.EquipmentStatusInService,
+		models.EquipmentStatusOutOfService,
+		models.EquipmentStatusUnderRepair,
+		models.EquipmentStatusQuarantined,
+	},
+	models.EquipmentStatusOutOfService: {
+		models.EquipmentStatusInService,
+		models.EquipmentStatusUnderRepair,
+		models.EquipmentStatusQuarantined,
+		models.EquipmentStatusRetired,
+	},
+	models.EquipmentStatusUnderRepair: {
+		models.EquipmentStatusInService,
+		models.EquipmentStatusDegraded,
+		models.EquipmentStatusOutOfService,
+		models.EquipmentStatusRetired,
+	},
+	models.EquipmentStatusQuarantined: {
+		models.EquipmentStatusInService,
+		models.EquipmentStatusOutOfService,
+		models.EquipmentStatusUnderRepair,
+		models.EquipmentStatusRetired,
+	},
+	models.EquipmentStatusRetired: {models.EquipmentStatusCommissioning, models.EquipmentStatusInService},
+}
+
+// StatusTimelineEntry is a status event together with how long the equipment
+// stayed in the resulting status. Until is nil for the current status.
+type StatusTimelineEntry struct {
+	models.EquipmentStatusEvent
+	Until           *time.Time `json:"until"`
+	DurationSeconds int64      `json:"durationSeconds"`
+}
+
+func ValidEquipmentStatus(status string) bool {
+	return slices.Contains(models.EquipmentStatuses, status)
+}
+
+// GetStatusTransitions returns the transitions a business allows. The second
+// result is false when the business uses the defaults.
+func GetStatusTransitions(ctx context.Context, businessID uuid.UUID) ([]models.EquipmentStatusTransition, bool, error) {
+	var transitions []models.EquipmentStatusTransition
+	err := database.Conn(ctx).
+		Where("business_id = ?", businessID).
+		Order("from_status ASC, to_status ASC").
+		Find(&transitions).Error
+	if err != nil {
+		return nil, false, err
+	}
+	if len(transitions) > 0 {
+		return transitions, true, nil
+	}
+
+	for _, from := range models.EquipmentStatuses {
+		for _, to := range defaultStatusTransitions[from] {
+			transitions = append(transitions, models.EquipmentStatusTransition{
+				BusinessID: businessID,
+				FromStatus: from,
+				ToStatus:   to,
+			})
+		}
+	}
+	return transitions, false, nil
+}
+
+// SetStatusTransitions replaces the transitions a business allows.
+func SetStatusTransitions(ctx context.Context, businessID uuid.UUID, transitions []models.EquipmentStatusTransition) error {
+	seen := map[[2]string]bool{}
+	rows := make([]models.EquipmentStatusTransition, 0, len(transitions))
+	for _, transition := range transitions {
+		if !ValidEquipmentStatus(transition.FromStatus) || !ValidEquipmentStatus(transition.ToStatus) {
+			return ErrInvalidStatus
+		}
+		if transition.FromStatus == transition.ToStatus {
+			return ErrStatusUnchanged
+		}
+
+		key := [2]string{transition.FromStatus, transition.ToStatus}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		rows = append(rows, models.EquipmentStatusTransition{
+			BusinessID: businessID,
+			FromStatus: transition.FromStatus,
+			ToStatus:   transition.ToStatus,
+		})
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("business_id = ?", businessID).Delete(&models.EquipmentStatusTransition{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// ResetStatusTransitions goes back to the default transitions.
+func ResetStatusTransitions(ctx context.Context, businessID uuid.UUID) error {
+	return database.Conn(ctx).Where("business_id = ?", businessID).Delete(&models.EquipmentStatusTransition{}).Error
+}
+
+func StatusTransitionAllowed(ctx context.Context, businessID uuid.UUID, from string, to string) (bool, error) {
+	transitions, _, err := GetStatusTransitions(ctx, businessID)
+	if err != nil {
+		return false, err
+	}
+	for _, transition := range transitions {
+		if transition.FromStatus == from && transition.ToStatus == to {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ChangeEquipmentStatus moves equipment to a new status and records the change.
+// Moving to or from "retired" also sets or clears the retirement details. When
+// enforce is false the business's transition rules are skipped, which the
+// admin-only retire and reinstate actions rely on.
+func ChangeEquipmentStatus(ctx context.Context, eq *models.Equipment, to string, actorID *uuid.UUID, reason string, enforce bool) error {
+	if !ValidEquipmentStatus(to) {
+		return ErrInvalidStatus
+	}
+	if eq.Status == to {
+		return ErrStatusUnchanged
+	}
+
+	if enforce {
+		allowed, err := StatusTransitionAllowed(ctx, eq.BusinessID, eq.Status, to)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrStatusTransitionForbidden
+		}
+	}
+
+	updates := map[string]any{"status": to}
+	if to == models.EquipmentStatusRetired {
+		now := time.Now()
+		eq.RetiredAt = &now
+		eq.RetiredBy = actorID
+		eq.RetirementReason = reason
+		updates["retired_at"] = now
+		updates["retired_by"] = actorID
+		updates["retirement_reason"] = reason
+	} else if eq.Status == models.EquipmentStatusRetired {
+		eq.RetiredAt = nil
+		eq.RetiredBy = nil
+		eq.RetirementReason = ""
+		updates["retired_at"] = nil
+		updates["retired_by"] = nil
+		updates["retirement_reason"] = ""
+	}
+
+	from := eq.Status
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// The status the transition was checked against must still be current,
+		// otherwise a concurrent change has won and the history would be wrong.
+		result := tx.Model(&models.Equipment{}).Where("id = ? AND status = ?", eq.ID, from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusConflict
+		}
+		return recordStatusEvent(tx, eq, from, to, actorID, reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	eq.Status = to
+	return nil
+}
+
+// GetEquipmentStatusTimeline returns the status history of equipment, oldest first.
+func GetEquipmentStatusTimeline(ctx context.Context, equipmentID uuid.UUID) ([]StatusTimelineEntry, error) {
+	var events []models.EquipmentStatusEvent
+	err := database.Conn(ctx).
+		Preload("Actor").
+		Where("equipment_id = ?", equipmentID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	timeline := make([]StatusTimelineEntry, 0, len(events))
+	for i, event := range events {
+		entry := StatusTimelineEntry{EquipmentStatusEvent: event}
+		end := now
+		if i+1 < len(events) {
+			until := events[i+1].CreatedAt
+			entry.Until = &until
+			end = until
+		}
+		entry.DurationSeconds = int64(end.Sub(event.CreatedAt).Seconds())
+		timeline = append(timeline, entry)
+	}
+	return timeline, nil
+}
+
+// statusBeforeRetirement is the status equipment had when it was last retired,
+// so reinstating puts it back where it was.
+func statusBeforeRetirement(ctx context.Context, equipmentID uuid.UUID) string {
+	var event models.EquipmentStatusEvent
+	err := database.Conn(ctx).
+		Where("equipment_id = ? AND to_status = ?", equipmentID, models.EquipmentStatusRetired).
+		Order("created_at DESC").
+		Take(&event).Error
+	if err != nil || event.FromStatus == "" || event.FromStatus == models.EquipmentStatusRetired {
+		return models.EquipmentStatusInService
+	}
+	return event.FromStatus
+}
+
+func recordStatusEvent(tx *gorm.DB, eq *models.Equipment, from string, to string, actorID *uuid.UUID, reason string) error {
+	return tx.Create(&models.EquipmentStatusEvent{
+		EquipmentID: eq.ID,
+		BusinessID:  eq.BusinessID,
+		FromStatus:  from,
+		ToStatus:    to,
+		Reason:      strings.TrimSpace(reason),
+		ActorID:     actorID,
+	}).Error
+}
+
+// MigrateEquipmentStatuses replaces the original two-state status constraint with
+// the lifecycle states. "not in service" becomes "out of service", and retired
+// equipment gets the "retired" status.
+func MigrateEquipmentStatuses(ctx context.Context) error {
+	var definition string
+	err := database.Conn(ctx).Raw(`
+		SELECT COALESCE(pg_get_constraintdef(oid), '') FROM pg_constraint
+		WHERE conrelid = 'equipment'::regclass AND conname = 'chk_equipment_status'`).Scan(&definition).Error
+	if err != nil {
+		return err
+	}
+	if strings.Contains(definition, models.EquipmentStatusQuarantined) {
+		return nil
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE equipment DROP CONSTRAINT IF EXISTS chk_equipment_status").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Equipment{}).
+			Where("status = ?", "not in service").
+			Update("status", models.EquipmentStatusOutOfService).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Equipment{}).
+			Where("retired_at IS NOT NULL").
+			Update("status", models.EquipmentStatusRetired).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().CreateConstraint(&models.Equipment{}, "chk_equipment_status")
+	})
+}
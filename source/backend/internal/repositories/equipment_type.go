@@ -0,0 +1,339 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEquipmentTypeNotFound  = errors.New("equipment type not found")
+	ErrEquipmentTypeDuplicate = errors.New("an equipment type with this name already exists")
+	ErrEquipmentTypeInUse     = errors.New("equipment type is still used by equipment")
+	ErrInvalidTypeDefaults    = errors.New("invalid default_maintenance_plan")
+)
+
+// EquipmentTypeSummary is a catalog entry with the number of equipment using it.
+type EquipmentTypeSummary struct {
+	models.EquipmentType
+	EquipmentCount int64 `json:"equipmentCount"`
+}
+
+// ListEquipmentTypes returns a business's type catalog ordered by name. Retired
+// equipment is not counted.
+func ListEquipmentTypes(ctx context.Context, businessID uuid.UUID) ([]EquipmentTypeSummary, error) {
+	var types []models.EquipmentType
+	if err := database.Conn(ctx).Where("business_id = ?", businessID).Order("name ASC").Find(&types).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		TypeID uuid.UUID
+		Count  int64
+	}
+	err := database.Conn(ctx).
+		Model(&models.Equipment{}).
+		Select("type_id, COUNT(*) AS count").
+		Where("business_id = ? AND type_id IS NOT NULL AND retired_at IS NULL", businessID).
+		Group("type_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	countByType := make(map[uuid.UUID]int64, len(counts))
+	for _, row := range counts {
+		countByType[row.TypeID] = row.Count
+	}
+
+	summaries := make([]EquipmentTypeSummary, 0, len(types))
+	for _, t := range types {
+		summaries = append(summaries, EquipmentTypeSummary{EquipmentType: t, EquipmentCount: countByType[t.ID]})
+	}
+	return summaries, nil
+}
+
+func GetEquipmentTypeByID(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.EquipmentType, error) {
+	var equipmentType models.EquipmentType
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&equipmentType).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEquipmentTypeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &equipmentType, nil
+}
+
+// GetEquipmentTypeByName looks up a catalog entry ignoring case and surrounding spaces.
+func GetEquipmentTypeByName(ctx context.Context, businessID uuid.UUID, name string) (*models.EquipmentType, error) {
+	var equipmentType models.EquipmentType
+	err := database.Conn(ctx).
+		Where("business_id = ? AND LOWER(name) = ?", businessID, normalizeTypeName(name)).
+		Take(&equipmentType).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEquipmentTypeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &equipmentType, nil
+}
+
+// ResolveEquipmentType returns the catalog entry for a free-text type name,
+// adding it to the catalog when the business has no such type yet. This keeps
+// clients that only send a type name working while every piece of equipment
+// still references the catalog. Only business admins may extend the catalog, so
+// callers check that first.
+func ResolveEquipmentType(ctx context.Context, businessID uuid.UUID, name string) (*models.EquipmentType, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrEquipmentTypeNotFound
+	}
+
+	equipmentType, err := GetEquipmentTypeByName(ctx, businessID, name)
+	if !errors.Is(err, ErrEquipmentTypeNotFound) {
+		return equipmentType, err
+	}
+
+	equipmentType = &models.EquipmentType{BusinessID: businessID, Name: name}
+	if err := CreateEquipmentType(ctx, equipmentType); err != nil {
+		if errors.Is(err, ErrEquipmentTypeDuplicate) {
+			return GetEquipmentTypeByName(ctx, businessID, name)
+		}
+		return nil, err
+	}
+	return equipmentType, nil
+}
+
+func CreateEquipmentType(ctx context.Context, equipmentType *models.EquipmentType) error {
+	equipmentType.Name = strings.TrimSpace(equipmentType.Name)
+	if err := checkEquipmentTypeDefaults(equipmentType); err != nil {
+		return err
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(equipmentType).Error
+	})
+	if uniqueViolation(err, "idx_equipment_type_name") {
+		return ErrEquipmentTypeDuplicate
+	}
+	return err
+}
+
+// UpdateEquipmentType saves a catalog entry. Renaming a type also renames it on
+// its equipment and field definitions, which are keyed by the type name.
+func UpdateEquipmentType(ctx context.Context, equipmentType *models.EquipmentType, previousName string) error {
+	equipmentType.Name = strings.TrimSpace(equipmentType.Name)
+	if err := checkEquipmentTypeDefaults(equipmentType); err != nil {
+		return err
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.EquipmentType{}).
+			Where("id = ? AND business_id = ?", equipmentType.ID, equipmentType.BusinessID).
+			Updates(map[string]any{
+				"name":                     equipmentType.Name,
+				"icon":                     equipmentType.Icon,
+				"default_fields":           equipmentType.DefaultFields,
+				"default_maintenance_plan": equipmentType.DefaultMaintenancePlan,
+				"default_issue_templates":  equipmentType.DefaultIssueTemplates,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		if equipmentType.Name == previousName {
+			return nil
+		}
+
+		if err := tx.Model(&models.Equipment{}).
+			Where("business_id = ? AND type_id = ?", equipmentType.BusinessID, equipmentType.ID).
+			Update("type", equipmentType.Name).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.EquipmentField{}).
+			Where("business_id = ? AND equipment_type = ?", equipmentType.BusinessID, previousName).
+			Update("equipment_type", equipmentType.Name).Error
+	})
+	if uniqueViolation(err, "idx_equipment_type_name") {
+		return ErrEquipmentTypeDuplicate
+	}
+	return err
+}
+
+// DeleteEquipmentType removes a catalog entry and its field definitions. Types
+// that are referenced by equipment, retired or not, cannot be deleted.
+func DeleteEquipmentType(ctx context.Context, businessID uuid.UUID, id uuid.UUID) error {
+	equipmentType, err := GetEquipmentTypeByID(ctx, businessID, id)
+	if err != nil {
+		return err
+	}
+
+	var inUse int64
+	err = database.Conn(ctx).
+		Model(&models.Equipment{}).
+		Where("business_id = ? AND type_id = ?", businessID, id).
+		Count(&inUse).Error
+	if err != nil {
+		return err
+	}
+	if inUse > 0 {
+		return ErrEquipmentTypeInUse
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("business_id = ? AND equipment_type = ?", businessID, equipmentType.Name).
+			Delete(&models.EquipmentField{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.EquipmentType{}).Error
+	})
+}
+
+// ApplyTypeDefaults fills in the type's default fields that are missing from fields.
+func ApplyTypeDefaults(equipmentType *models.EquipmentType, fields map[string]any) map[string]any {
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	if equipmentType == nil || len(equipmentType.DefaultFields) == 0 {
+		return fields
+	}
+
+	var defaults map[string]any
+	if err := json.Unmarshal(equipmentType.DefaultFields, &defaults); err != nil {
+		return fields
+	}
+	for key, value := range defaults {
+		if current, ok := fields[key]; !ok || current == nil {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// MigrateEquipmentTypes consolidates free-text equipment types into the catalog.
+// Types that differ only in case or surrounding spaces become one entry, named
+// after their most common spelling, and equipment and field definitions are
+// renamed to match. Type names are unique per business, which the index created
+// first enforces for the migration as well as for the API.
+func MigrateEquipmentTypes(ctx context.Context) error {
+	if err := database.Conn(ctx).Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_equipment_type_name ON equipment_types (business_id, LOWER(name))").Error; err != nil {
+		return err
+	}
+
+	var rows []struct {
+		BusinessID uuid.UUID
+		Type       string
+		Count      int64
+	}
+	err := database.Conn(ctx).
+		Model(&models.Equipment{}).
+		Select("business_id, type, COUNT(*) AS count").
+		Where("type_id IS NULL AND TRIM(type) <> ''").
+		Group("business_id, type").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	type typeKey struct {
+		businessID uuid.UUID
+		name       string
+	}
+	spellings := map[typeKey]map[string]int64{}
+	for _, row := range rows {
+		key := typeKey{row.BusinessID, normalizeTypeName(row.Type)}
+		if spellings[key] == nil {
+			spellings[key] = map[string]int64{}
+		}
+		spellings[key][strings.TrimSpace(row.Type)] += row.Count
+	}
+
+	keys := make([]typeKey, 0, len(spellings))
+	for key := range spellings {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].businessID != keys[j].businessID {
+			return keys[i].businessID.String() < keys[j].businessID.String()
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			var equipmentType models.EquipmentType
+			err := tx.Where("business_id = ? AND LOWER(name) = ?", key.businessID, key.name).Take(&equipmentType).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				equipmentType = models.EquipmentType{BusinessID: key.businessID, Name: preferredSpelling(spellings[key])}
+				err = tx.Create(&equipmentType).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.Equipment{}).
+				Where("business_id = ? AND type_id IS NULL AND LOWER(TRIM(type)) = ?", key.businessID, key.name).
+				Updates(map[string]any{"type_id": equipmentType.ID, "type": equipmentType.Name}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.EquipmentField{}).
+				Where("business_id = ? AND LOWER(TRIM(equipment_type)) = ? AND equipment_type <> ?", key.businessID, key.name, equipmentType.Name).
+				Update("equipment_type", equipmentType.Name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func preferredSpelling(counts map[string]int64) string {
+	best := ""
+	for spelling, count := range counts {
+		if best == "" || count > counts[best] || (count == counts[best] && spelling < best) {
+			best = spelling
+		}
+	}
+	return best
+}
+
+func normalizeTypeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func checkEquipmentTypeDefaults(equipmentType *models.EquipmentType) error {
+	if len(equipmentType.DefaultFields) > 0 && string(equipmentType.DefaultFields) != "null" {
+		var defaults map[string]any
+		if err := json.Unmarshal(equipmentType.DefaultFields, &defaults); err != nil {
+			return ErrInvalidMoreFields
+		}
+		if err := ValidateMoreFields(defaults); err != nil {
+			return err
+		}
+	} else {
+		equipmentType.DefaultFields = nil
+	}
+
+	if len(equipmentType.DefaultMaintenancePlan) > 0 && string(equipmentType.DefaultMaintenancePlan) != "null" {
+		if _, err := MaintenancePlanFromTemplate(equipmentType.DefaultMaintenancePlan); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTypeDefaults, err)
+		}
+	} else {
+		equipmentType.DefaultMaintenancePlan = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,547 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/s3"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInspectionTemplateNotFound  = errors.New("inspection template not found")
+	ErrInspectionTemplateAmbiguous = errors.New("this equipment type has several checklists; choose a template_id")
+	ErrInspectionTemplateMismatch  = errors.New("this checklist is for a different equipment type")
+	ErrInvalidInspectionItems      = errors.New("invalid checklist items")
+	ErrInspectionNotFound          = errors.New("inspection not found")
+	ErrInspectionCompleted         = errors.New("inspection is already completed")
+	ErrInvalidInspectionAnswer     = errors.New("invalid answer")
+	ErrInspectionIncomplete        = errors.New("required checklist items are unanswered")
+)
+
+// maxInspectionItems caps how many items a checklist can have.
+const maxInspectionItems = 100
+
+// InspectionAnswer is an operator's answer to one checklist item. Which of
+// Passed, Value, Text and PhotoKey are needed depends on the item's kind;
+// Passed may also be set to false to fail a text or photo item.
+type InspectionAnswer struct {
+	ItemKey  string
+	Passed   *bool
+	Value    *float64
+	Text     string
+	PhotoKey string
+	Note     string
+}
+
+type InspectionRunFilter struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+// InspectionRunSummary is a run in an equipment's inspection history with how
+// many of its items failed.
+type InspectionRunSummary struct {
+	models.InspectionRun
+	FailedItems int64 `json:"failedItems"`
+}
+
+func ListInspectionTemplates(ctx context.Context, businessID uuid.UUID, typeID *uuid.UUID, activeOnly bool) ([]models.InspectionTemplate, error) {
+	query := database.Conn(ctx).Where("business_id = ?", businessID)
+	if typeID != nil {
+		query = query.Where("equipment_type_id = ?", *typeID)
+	}
+	if activeOnly {
+		query = query.Where("active")
+	}
+
+	var templates []models.InspectionTemplate
+	err := query.Order("name ASC").Find(&templates).Error
+	return templates, err
+}
+
+func GetInspectionTemplate(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.InspectionTemplate, error) {
+	var template models.InspectionTemplate
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInspectionTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func CreateInspectionTemplate(ctx context.Context, template *models.InspectionTemplate) error {
+	if _, err := GetEquipmentTypeByID(ctx, template.BusinessID, template.EquipmentTypeID); err != nil {
+		return err
+	}
+	if err := checkInspectionItems(template.Items); err != nil {
+		return err
+	}
+
+	template.Name = strings.TrimSpace(template.Name)
+	template.Version = 1
+	return database.Conn(ctx).Create(template).Error
+}
+
+// UpdateInspectionTemplate saves a checklist. Changing its items starts a new
+// version; runs already started keep the items they began with.
+func UpdateInspectionTemplate(ctx context.Context, template *models.InspectionTemplate, itemsChanged bool) error {
+	if itemsChanged {
+		if err := checkInspectionItems(template.Items); err != nil {
+			return err
+		}
+		template.Version++
+	}
+
+	template.Name = strings.TrimSpace(template.Name)
+	return database.Conn(ctx).
+		Model(&models.InspectionTemplate{}).
+		Where("id = ?", template.ID).
+		Updates(map[string]any{
+			"name":        template.Name,
+			"description": template.Description,
+			"items":       template.Items,
+			"version":     template.Version,
+			"active":      template.Active,
+		}).Error
+}
+
+// DeleteInspectionTemplate removes a checklist. Runs made with it stay in the
+// equipment's history.
+func DeleteInspectionTemplate(ctx context.Context, template *models.InspectionTemplate) error {
+	return database.Conn(ctx).Delete(&models.InspectionTemplate{}, "id = ?", template.ID).Error
+}
+
+// ResolveInspectionTemplate picks the checklist for an inspection of eq: the
+// given template, or else the only active checklist of the equipment's type.
+func ResolveInspectionTemplate(ctx context.Context, eq *models.Equipment, templateID *uuid.UUID) (*models.InspectionTemplate, error) {
+	if templateID != nil {
+		template, err := GetInspectionTemplate(ctx, eq.BusinessID, *templateID)
+		if err != nil {
+			return nil, err
+		}
+		if !template.Active {
+			return nil, ErrInspectionTemplateNotFound
+		}
+		if eq.TypeID == nil || template.EquipmentTypeID != *eq.TypeID {
+			return nil, ErrInspectionTemplateMismatch
+		}
+		return template, nil
+	}
+
+	if eq.TypeID == nil {
+		return nil, ErrInspectionTemplateNotFound
+	}
+	templates, err := ListInspectionTemplates(ctx, eq.BusinessID, eq.TypeID, true)
+	if err != nil {
+		return nil, err
+	}
+	switch len(templates) {
+	case 0:
+		return nil, ErrInspectionTemplateNotFound
+	case 1:
+		return &templates[0], nil
+	default:
+		return nil, ErrInspectionTemplateAmbiguous
+	}
+}
+
+// StartInspection opens a run of template on eq with one unanswered result per
+// checklist item.
+func StartInspection(ctx context.Context, eq *models.Equipment, template *models.InspectionTemplate, source string, inspectorID uuid.UUID) (*models.InspectionRun, error) {
+	if eq.RetiredAt != nil {
+		return nil, ErrEquipmentRetired
+	}
+
+	run := models.InspectionRun{
+		BusinessID:      eq.BusinessID,
+		EquipmentID:     eq.ID,
+		TemplateID:      &template.ID,
+		TemplateName:    template.Name,
+		TemplateVersion: template.Version,
+		Status:          models.InspectionInProgress,
+		Source:          source,
+		InspectorID:     &inspectorID,
+		StartedAt:       time.Now(),
+	}
+	for i, item := range template.Items {
+		run.Results = append(run.Results, models.InspectionResult{
+			BusinessID:   eq.BusinessID,
+			ItemKey:      item.Key,
+			Position:     i,
+			Label:        item.Label,
+			Kind:         item.Kind,
+			Required:     item.Required,
+			Min:          item.Min,
+			Max:          item.Max,
+			Unit:         item.Unit,
+			CreatesIssue: item.CreatesIssue,
+		})
+	}
+
+	if err := database.Conn(ctx).Create(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func GetInspectionRun(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.InspectionRun, error) {
+	var run models.InspectionRun
+	err := database.Conn(ctx).
+		Preload("Results", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Inspector").
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInspectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RecordInspectionAnswers stores answers on a run that is still in progress.
+// Answering an item again replaces the earlier answer. Nothing is stored if
+// any answer is invalid, including a photo that was never uploaded.
+func RecordInspectionAnswers(ctx context.Context, run *models.InspectionRun, answers []InspectionAnswer) error {
+	if run.Status != models.InspectionInProgress {
+		return ErrInspectionCompleted
+	}
+
+	byKey := make(map[string]*models.InspectionResult, len(run.Results))
+	for i := range run.Results {
+		byKey[run.Results[i].ItemKey] = &run.Results[i]
+	}
+
+	now := time.Now()
+	updated := make([]*models.InspectionResult, 0, len(answers))
+	for _, answer := range answers {
+		result, ok := byKey[answer.ItemKey]
+		if !ok {
+			return fmt.Errorf("%w: %q is not on this checklist", ErrInvalidInspectionAnswer, answer.ItemKey)
+		}
+		if err := applyInspectionAnswer(result, answer); err != nil {
+			return err
+		}
+		if result.Kind == models.InspectionItemPhoto {
+			exists, err := s3.ObjectExists(ctx, utils.AppConfig.MinioBucket, result.PhotoKey)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("%w: %s photo was not found", ErrInvalidInspectionAnswer, result.Label)
+			}
+		}
+		result.AnsweredAt = &now
+		updated = append(updated, result)
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the run so answers cannot land after a concurrent completion.
+		var current models.InspectionRun
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", run.ID).Take(&current).Error
+		if err != nil {
+			return err
+		}
+		if current.Status != models.InspectionInProgress {
+			return ErrInspectionCompleted
+		}
+
+		for _, result := range updated {
+			err := tx.Model(&models.InspectionResult{}).
+				Where("id = ?", result.ID).
+				Updates(map[string]any{
+					"answered":    true,
+					"passed":      result.Passed,
+					"value":       result.Value,
+					"text":        result.Text,
+					"photo_key":   result.PhotoKey,
+					"note":        result.Note,
+					"failed":      result.Failed,
+					"answered_at": result.AnsweredAt,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applyInspectionAnswer checks an answer against its item and works out
+// whether the item passed.
+func applyInspectionAnswer(result *models.InspectionResult, answer InspectionAnswer) error {
+	invalid := func(message string) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidInspectionAnswer, result.Label, message)
+	}
+
+	passed := answer.Passed
+	switch result.Kind {
+	case models.InspectionItemPassFail:
+		if passed == nil {
+			return invalid("needs a pass or fail")
+		}
+	case models.InspectionItemNumeric:
+		if answer.Value == nil {
+			return invalid("needs a value")
+		}
+		within := (result.Min == nil || *answer.Value >= *result.Min) && (result.Max == nil || *answer.Value <= *result.Max)
+		if !within || passed == nil {
+			passed = &within
+		}
+	case models.InspectionItemText:
+		if strings.TrimSpace(answer.Text) == "" {
+			return invalid("needs an answer")
+		}
+	case models.InspectionItemPhoto:
+		if strings.TrimSpace(answer.PhotoKey) == "" {
+			return invalid("needs a photo")
+		}
+		if !strings.HasPrefix(strings.TrimSpace(answer.PhotoKey), s3.BusinessPrefix(result.BusinessID)) {
+			return invalid("photo was not uploaded to this business")
+		}
+	}
+
+	result.Answered = true
+	result.Passed = passed
+	result.Value = answer.Value
+	result.Text = strings.TrimSpace(answer.Text)
+	result.PhotoKey = strings.TrimSpace(answer.PhotoKey)
+	result.Note = strings.TrimSpace(answer.Note)
+	result.Failed = passed != nil && !*passed
+	return nil
+}
+
+// CompleteInspection closes a run once every required item is answered. The
+// run fails if any item failed, and failed items that are set to create issues
+// open one each on the equipment, assigned to the inspector.
+func CompleteInspection(ctx context.Context, run *models.InspectionRun, actorID uuid.UUID, note string) error {
+	if run.Status != models.InspectionInProgress {
+		return ErrInspectionCompleted
+	}
+
+	var eq models.Equipment
+	if err := database.Conn(ctx).Where("id = ?", run.EquipmentID).Take(&eq).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	status := models.InspectionPassed
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.InspectionRun
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", run.ID).Take(&current).Error
+		if err != nil {
+			return err
+		}
+		if current.Status != models.InspectionInProgress {
+			return ErrInspectionCompleted
+		}
+
+		// Answers are only recorded while holding the run's lock, so the results
+		// read now are final.
+		var results []models.InspectionResult
+		err = tx.Where("run_id = ?", run.ID).Order("position ASC").Find(&results).Error
+		if err != nil {
+			return err
+		}
+		run.Results = results
+
+		var missing []string
+		for _, result := range run.Results {
+			if result.Required && !result.Answered {
+				missing = append(missing, result.Label)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%w: %s", ErrInspectionIncomplete, strings.Join(missing, ", "))
+		}
+
+		for i := range run.Results {
+			result := &run.Results[i]
+			if !result.Failed {
+				continue
+			}
+			status = models.InspectionFailed
+			if !result.CreatesIssue || result.IssueID != nil {
+				continue
+			}
+
+			issue := models.Issue{
+				Title:         inspectionIssueTitle(result),
+				Description:   inspectionIssueDescription(run, result),
+				EquipmentID:   eq.ID,
+				AssigneeID:    actorID,
+				TeamID:        eq.ResponsibleTeamID,
+				DateSubmitted: now,
+			}
+			if err := tx.Omit(clause.Associations).Create(&issue).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.InspectionResult{}).Where("id = ?", result.ID).Update("issue_id", issue.ID).Error; err != nil {
+				return err
+			}
+			result.IssueID = &issue.ID
+		}
+
+		run.Status = status
+		run.CompletedAt = &now
+		run.Note = strings.TrimSpace(note)
+		return tx.Model(&models.InspectionRun{}).
+			Where("id = ?", run.ID).
+			Updates(map[string]any{"status": run.Status, "completed_at": now, "note": run.Note}).Error
+	})
+}
+
+func inspectionIssueTitle(result *models.InspectionResult) string {
+	title := "Failed inspection: " + result.Label
+	if runes := []rune(title); len(runes) > 128 {
+		title = string(runes[:125]) + "..."
+	}
+	return title
+}
+
+func inspectionIssueDescription(run *models.InspectionRun, result *models.InspectionResult) string {
+	lines := []string{fmt.Sprintf("%s failed in the %s inspection of %s.", result.Label, run.TemplateName, run.StartedAt.Format("2006-01-02 15:04"))}
+	if result.Value != nil {
+		reading := strings.TrimSpace(fmt.Sprintf("Reading: %g %s", *result.Value, result.Unit))
+		switch {
+		case result.Min != nil && result.Max != nil:
+			reading += fmt.Sprintf(" (allowed %g to %g)", *result.Min, *result.Max)
+		case result.Min != nil:
+			reading += fmt.Sprintf(" (minimum %g)", *result.Min)
+		case result.Max != nil:
+			reading += fmt.Sprintf(" (maximum %g)", *result.Max)
+		}
+		lines = append(lines, reading)
+	}
+	if result.Text != "" {
+		lines = append(lines, "Answer: "+result.Text)
+	}
+	if result.Note != "" {
+		lines = append(lines, "Note: "+result.Note)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DeleteInspectionRun discards a run, e.g. one that was started by mistake.
+// Issues it opened are kept.
+func DeleteInspectionRun(ctx context.Context, run *models.InspectionRun) error {
+	return database.Conn(ctx).Delete(&models.InspectionRun{}, "id = ?", run.ID).Error
+}
+
+// ListEquipmentInspections returns one page of an equipment's inspection
+// history, newest first, and the total number of runs.
+func ListEquipmentInspections(ctx context.Context, equipmentID uuid.UUID, filter InspectionRunFilter) ([]InspectionRunSummary, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.InspectionRun{}).
+		Where("equipment_id = ?", equipmentID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.InspectionRun
+	err := query.
+		Preload("Inspector").
+		Order("started_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&runs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(runs))
+	for _, run := range runs {
+		ids = append(ids, run.ID)
+	}
+	var failures []struct {
+		RunID uuid.UUID
+		Count int64
+	}
+	if len(ids) > 0 {
+		err = database.Conn(ctx).
+			Model(&models.InspectionResult{}).
+			Select("run_id, COUNT(*) AS count").
+			Where("run_id IN ? AND failed", ids).
+			Group("run_id").
+			Scan(&failures).Error
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	failedByRun := make(map[uuid.UUID]int64, len(failures))
+	for _, row := range failures {
+		failedByRun[row.RunID] = row.Count
+	}
+
+	summaries := make([]InspectionRunSummary, 0, len(runs))
+	for _, run := range runs {
+		summaries = append(summaries, InspectionRunSummary{InspectionRun: run, FailedItems: failedByRun[run.ID]})
+	}
+	return summaries, total, nil
+}
+
+func checkInspectionItems(items []models.InspectionItem) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidInspectionItems, fmt.Sprintf(format, args...))
+	}
+
+	if len(items) == 0 || len(items) > maxInspectionItems {
+		return invalid("a checklist needs 1 to %d items", maxInspectionItems)
+	}
+
+	seen := make(map[string]bool, len(items))
+	for i := range items {
+		item := &items[i]
+		item.Label = strings.TrimSpace(item.Label)
+		item.Key = strings.TrimSpace(item.Key)
+		item.Unit = strings.TrimSpace(item.Unit)
+		if item.Key == "" {
+			item.Key = uuid.NewString()[:8]
+		}
+
+		if item.Label == "" {
+			return invalid("item %d needs a label", i+1)
+		}
+		if len(item.Key) > 64 || seen[item.Key] {
+			return invalid("item keys must be unique and at most 64 characters (%q)", item.Key)
+		}
+		seen[item.Key] = true
+
+		switch item.Kind {
+		case models.InspectionItemNumeric:
+			if item.Min != nil && item.Max != nil && *item.Min > *item.Max {
+				return invalid("%s: min is greater than max", item.Label)
+			}
+			if len(item.Unit) > 16 {
+				return invalid("%s: unit is longer than 16 characters", item.Label)
+			}
+		case models.InspectionItemPassFail, models.InspectionItemText, models.InspectionItemPhoto:
+			item.Min, item.Max, item.Unit = nil, nil, ""
+		default:
+			return invalid("%s: kind must be pass_fail, numeric, text or photo", item.Label)
+		}
+	}
+	return nil
+}
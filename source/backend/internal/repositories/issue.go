@@ -1,24 +1,27 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/EquipQR/equipqr/backend/internal/database"
 	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func GetIssueByID(id string) (*models.Issue, error) {
+func GetIssueByID(ctx context.Context, id string) (*models.Issue, error) {
 	var issue models.Issue
-	if err := database.DB.First(&issue, "id = ?", id).Error; err != nil {
+	if err := database.Conn(ctx).First(&issue, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &issue, nil
 }
 
-func CreateIssueFromRequest(req utils.CreateIssueRequest, assigneeID uuid.UUID) (*models.Issue, error) {
+func CreateIssueFromRequest(ctx context.Context, req utils.CreateIssueRequest, assigneeID uuid.UUID) (*models.Issue, error) {
 	fmt.Printf("[CreateIssue] 🛠️ Received request: %+v\n", req)
 
 	equipmentID, err := uuid.Parse(req.EquipmentID)
@@ -27,16 +30,26 @@ func CreateIssueFromRequest(req utils.CreateIssueRequest, assigneeID uuid.UUID)
 		return nil, errors.New("invalid equipment_id")
 	}
 
+	equipment, err := GetEquipmentByID(ctx, equipmentID.String())
+	if err != nil {
+		return nil, errors.New("equipment not found")
+	}
+	if equipment.RetiredAt != nil {
+		return nil, ErrEquipmentRetired
+	}
+
 	issue := models.Issue{
-		Title:       req.Title,
-		Description: req.Description,
-		EquipmentID: equipmentID,
-		AssigneeID:  assigneeID,
+		Title:         req.Title,
+		Description:   req.Description,
+		EquipmentID:   equipmentID,
+		AssigneeID:    assigneeID,
+		TeamID:        equipment.ResponsibleTeamID, // route to the equipment's default team
+		DateSubmitted: time.Now(),
 	}
 
 	fmt.Printf("[CreateIssue] ✅ Creating issue in DB: %+v\n", issue)
 
-	if err := database.DB.Create(&issue).Error; err != nil {
+	if err := database.Conn(ctx).Create(&issue).Error; err != nil {
 		fmt.Printf("[CreateIssue] ❌ Failed to create issue: %v\n", err)
 		return nil, err
 	}
@@ -44,3 +57,27 @@ func CreateIssueFromRequest(req utils.CreateIssueRequest, assigneeID uuid.UUID)
 	fmt.Printf("[CreateIssue] ✅ Successfully created issue with ID: %s\n", issue.ID)
 	return &issue, nil
 }
+
+// MigrateIssueEquipmentConstraint replaces the old ON DELETE CASCADE foreign key
+// from issues to equipment with the RESTRICT one declared on the model, so
+// removing equipment can no longer silently delete its issue history.
+// AutoMigrate does not alter existing constraints, hence this start-up step.
+func MigrateIssueEquipmentConstraint(ctx context.Context) error {
+	var cascading int64
+	err := database.Conn(ctx).Raw(`
+		SELECT COUNT(*) FROM pg_constraint
+		WHERE contype = 'f'
+		  AND conrelid = 'issues'::regclass
+		  AND confrelid = 'equipment'::regclass
+		  AND confdeltype = 'c'`).Scan(&cascading).Error
+	if err != nil || cascading == 0 {
+		return err
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropConstraint(&models.Issue{}, "Equipment"); err != nil {
+			return err
+		}
+		return tx.Migrator().CreateConstraint(&models.Issue{}, "Equipment")
+	})
+}
@@ -0,0 +1,379 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLocationNotFound  = errors.New("location not found")
+	ErrLocationDuplicate = errors.New("a location with this name already exists here")
+	ErrLocationCycle     = errors.New("a location cannot be moved beneath itself")
+	ErrLocationInUse     = errors.New("location still has child locations, equipment, parts in stock or stock history")
+)
+
+// locationSubtreeSQL selects the IDs of a location and all of its descendants.
+const locationSubtreeSQL = `
+WITH RECURSIVE subtree AS (
+	SELECT id FROM locations WHERE id = ? AND business_id = ?
+	UNION ALL
+	SELECT l.id FROM locations l JOIN subtree s ON l.parent_id = s.id
+)
+SELECT id FROM subtree`
+
+func GetLocationsForBusiness(ctx context.Context, businessID uuid.UUID) ([]models.Location, error) {
+	var locations []models.Location
+	err := database.Conn(ctx).
+		Where("business_id = ?", businessID).
+		Order("name ASC").
+		Find(&locations).Error
+	return locations, err
+}
+
+func GetLocationByID(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.Location, error) {
+	var location models.Location
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&location).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+func CreateLocation(ctx context.Context, location *models.Location) error {
+	if location.ParentID != nil {
+		if _, err := GetLocationByID(ctx, location.BusinessID, *location.ParentID); err != nil {
+			return err
+		}
+	}
+
+	if err := ensureUniqueSibling(ctx, location.BusinessID, location.ParentID, location.Name, uuid.Nil); err != nil {
+		return err
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(location).Error
+	})
+	if uniqueViolation(err, "idx_location_name") {
+		return ErrLocationDuplicate
+	}
+	return err
+}
+
+// UpdateLocation renames or moves a location. Moving a node beneath one of its
+// own descendants is rejected. The location labels of equipment anywhere in the
+// subtree are refreshed in the same transaction.
+func UpdateLocation(ctx context.Context, location *models.Location) error {
+	if location.ParentID != nil {
+		if _, err := GetLocationByID(ctx, location.BusinessID, *location.ParentID); err != nil {
+			return err
+		}
+
+		subtree, err := GetLocationSubtreeIDs(ctx, location.BusinessID, location.ID)
+		if err != nil {
+			return err
+		}
+		for _, id := range subtree {
+			if id == *location.ParentID {
+				return ErrLocationCycle
+			}
+		}
+	}
+
+	if err := ensureUniqueSibling(ctx, location.BusinessID, location.ParentID, location.Name, location.ID); err != nil {
+		return err
+	}
+
+	return database.Transaction(ctx, func(ctx context.Context) error {
+		err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+			return tx.Model(&models.Location{}).
+				Where("id = ? AND business_id = ?", location.ID, location.BusinessID).
+				Updates(map[string]any{
+					"name":      location.Name,
+					"kind":      location.Kind,
+					"parent_id": location.ParentID,
+				}).Error
+		})
+		if uniqueViolation(err, "idx_location_name") {
+			return ErrLocationDuplicate
+		}
+		if err != nil {
+			return err
+		}
+
+		label, err := GetLocationPathLabel(ctx, location.BusinessID, location.ID)
+		if err != nil {
+			return err
+		}
+		return database.Conn(ctx).Exec(locationLabelsSQL, label, location.ID, location.BusinessID, location.BusinessID).Error
+	})
+}
+
+// locationLabelsSQL rewrites the denormalised location label of equipment placed
+// at a location or beneath it, given the location's own path label.
+const locationLabelsSQL = `
+WITH RECURSIVE subtree AS (
+	SELECT id, ?::text AS label FROM locations WHERE id = ? AND business_id = ?
+	UNION ALL
+	SELECT l.id, s.label || ' / ' || l.name FROM locations l JOIN subtree s ON l.parent_id = s.id
+)
+UPDATE equipment SET location = subtree.label
+FROM subtree
+WHERE equipment.location_id = subtree.id AND equipment.business_id = ?`
+
+func DeleteLocation(ctx context.Context, businessID uuid.UUID, id uuid.UUID) error {
+	if _, err := GetLocationByID(ctx, businessID, id); err != nil {
+		return err
+	}
+
+	var children int64
+	if err := database.Conn(ctx).Model(&models.Location{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+		return err
+	}
+
+	var equipment int64
+	if err := database.Conn(ctx).Model(&models.Equipment{}).Where("location_id = ?", id).Count(&equipment).Error; err != nil {
+		return err
+	}
+
+	// Stock movements are history and keep their location.
+	var stock int64
+	if err := database.Conn(ctx).Model(&models.PartStock{}).Where("location_id = ? AND quantity > 0", id).Count(&stock).Error; err != nil {
+		return err
+	}
+	var movements int64
+	if err := database.Conn(ctx).Model(&models.PartMovement{}).Where("location_id = ?", id).Count(&movements).Error; err != nil {
+		return err
+	}
+
+	if children > 0 || equipment > 0 || stock > 0 || movements > 0 {
+		return ErrLocationInUse
+	}
+
+	return database.Conn(ctx).Where("id = ? AND business_id = ?", id, businessID).Delete(&models.Location{}).Error
+}
+
+// MigrateLocationNames adds the unique index that keeps sibling locations from
+// sharing a name regardless of case. Top-level locations count as siblings.
+func MigrateLocationNames(ctx context.Context) error {
+	return database.Conn(ctx).Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_location_name ON locations
+	(business_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), LOWER(name))`).Error
+}
+
+func GetLocationSubtreeIDs(ctx context.Context, businessID uuid.UUID, rootID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := database.Conn(ctx).Raw(locationSubtreeSQL, rootID, businessID).Scan(&ids).Error
+	return ids, err
+}
+
+// GetEquipmentInLocationSubtree returns all equipment placed at the given location
+// or anywhere beneath it.
+func GetEquipmentInLocationSubtree(ctx context.Context, businessID uuid.UUID, rootID uuid.UUID) ([]models.Equipment, error) {
+	if _, err := GetLocationByID(ctx, businessID, rootID); err != nil {
+		return nil, err
+	}
+
+	var equipment []models.Equipment
+	err := database.Conn(ctx).
+		Preload("LocationNode").
+		Where("business_id = ? AND location_id IN (?)", businessID, database.Conn(ctx).Raw(locationSubtreeSQL, rootID, businessID)).
+		Find(&equipment).Error
+	return equipment, err
+}
+
+// GetLocationPathLabel renders a location as "Site / Building / Area".
+func GetLocationPathLabel(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (string, error) {
+	var names []string
+	current := &id
+	for current != nil {
+		location, err := GetLocationByID(ctx, businessID, *current)
+		if err != nil {
+			return "", err
+		}
+		names = append([]string{location.Name}, names...)
+		current = location.ParentID
+	}
+	return strings.Join(names, " / "), nil
+}
+
+func ensureUniqueSibling(ctx context.Context, businessID uuid.UUID, parentID *uuid.UUID, name string, excludeID uuid.UUID) error {
+	query := database.Conn(ctx).
+		Model(&models.Location{}).
+		Where("business_id = ? AND id <> ?", businessID, excludeID)
+
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+
+	var siblings []models.Location
+	if err := query.Find(&siblings).Error; err != nil {
+		return err
+	}
+
+	key := normalizeLocationName(name)
+	for _, sibling := range siblings {
+		if normalizeLocationName(sibling.Name) == key {
+			return ErrLocationDuplicate
+		}
+	}
+	return nil
+}
+
+// normalizeLocationName reduces a name to lowercase letters and digits so that
+// "Bay 3", "bay3" and "BAY-3" are treated as the same place.
+func normalizeLocationName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// splitLocationText breaks a legacy free-text location such as
+// "Building A / Bay 3" or "Plant 2 > Line 1" into its path segments.
+func splitLocationText(text string) []string {
+	parts := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '/' || r == '>' || r == '\\' || r == '|'
+	})
+
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.Join(strings.Fields(part), " ")
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+// MigrateEquipmentLocations converts the free-text Location of equipment that is
+// not yet linked to a location node into nodes, reusing existing nodes whose
+// normalised names match. It only touches unlinked rows, so it is safe to run on
+// every start-up.
+func MigrateEquipmentLocations(ctx context.Context) error {
+	var equipment []models.Equipment
+	err := database.Conn(ctx).
+		Where("location_id IS NULL AND location IS NOT NULL AND TRIM(location) <> ''").
+		Find(&equipment).Error
+	if err != nil {
+		return err
+	}
+
+	if len(equipment) == 0 {
+		return nil
+	}
+
+	migrated := 0
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, eq := range equipment {
+			locationID, err := ensureLocationPath(tx, eq.BusinessID, eq.Location)
+			if err != nil {
+				return err
+			}
+			if locationID == nil {
+				continue
+			}
+
+			if err := tx.Model(&models.Equipment{}).Where("id = ?", eq.ID).Update("location_id", *locationID).Error; err != nil {
+				return err
+			}
+			migrated++
+		}
+
+		log.Printf("Linked %d equipment record(s) to location nodes", migrated)
+		return nil
+	})
+}
+
+// ensureLocationPath returns the node for a free-text location such as
+// "Building A / Bay 3", reusing nodes whose normalised names match and creating
+// the missing ones. It returns nil when the text names no location.
+func ensureLocationPath(tx *gorm.DB, businessID uuid.UUID, text string) (*uuid.UUID, error) {
+	segments := splitLocationText(text)
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	var parentID *uuid.UUID
+	for i, segment := range segments {
+		query := tx.Where("business_id = ?", businessID)
+		if parentID == nil {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *parentID)
+		}
+
+		var siblings []models.Location
+		if err := query.Find(&siblings).Error; err != nil {
+			return nil, err
+		}
+
+		var match *uuid.UUID
+		key := normalizeLocationName(segment)
+		for _, sibling := range siblings {
+			if normalizeLocationName(sibling.Name) == key {
+				match = &sibling.ID
+				break
+			}
+		}
+
+		if match == nil {
+			node := models.Location{
+				BusinessID: businessID,
+				ParentID:   parentID,
+				Name:       segment,
+				Kind:       legacyLocationKind(i, len(segments)),
+			}
+			// A concurrent request may have created the node since the siblings
+			// were read; the unique index then names the one to reuse.
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				return tx.Create(&node).Error
+			})
+			if uniqueViolation(err, "idx_location_name") {
+				existing := tx.Where("business_id = ? AND LOWER(name) = LOWER(?)", businessID, segment)
+				if parentID == nil {
+					existing = existing.Where("parent_id IS NULL")
+				} else {
+					existing = existing.Where("parent_id = ?", *parentID)
+				}
+				node = models.Location{}
+				err = existing.Take(&node).Error
+			}
+			if err != nil {
+				return nil, err
+			}
+			match = &node.ID
+		}
+		parentID = match
+	}
+	return parentID, nil
+}
+
+func legacyLocationKind(depth int, total int) string {
+	switch {
+	case total == 1:
+		return "area"
+	case depth == 0:
+		return "site"
+	case depth == total-1:
+		return "area"
+	default:
+		return "building"
+	}
+}
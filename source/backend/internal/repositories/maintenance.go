@@ -0,0 +1,736 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrMaintenancePlanNotFound  = errors.New("maintenance plan not found")
+	ErrMaintenanceNotFound      = errors.New("maintenance work not found")
+	ErrMaintenanceClosed        = errors.New("maintenance work is already closed")
+	ErrInvalidMaintenancePlan   = errors.New("invalid maintenance plan")
+	ErrMaintenanceMeterRequired = errors.New("a meter value is needed to complete meter-based maintenance")
+)
+
+type MaintenanceOccurrenceFilter struct {
+	Status      string
+	EquipmentID *uuid.UUID
+	PlanID      *uuid.UUID
+	Limit       int
+	Offset      int
+}
+
+type ComplianceFilter struct {
+	From        time.Time
+	To          time.Time
+	EquipmentID *uuid.UUID
+	PlanID      *uuid.UUID
+}
+
+// MaintenanceCompliance counts the maintenance due within a period by outcome.
+// Overdue is the open work already past its due date. Rate is the share of
+// closed work that was done on time, or nil when nothing was closed.
+type MaintenanceCompliance struct {
+	OnTime  int64    `json:"onTime"`
+	Late    int64    `json:"late"`
+	Skipped int64    `json:"skipped"`
+	Open    int64    `json:"open"`
+	Overdue int64    `json:"overdue"`
+	Rate    *float64 `json:"rate"`
+}
+
+// EquipmentMaintenance is a plan's schedule on one piece of equipment together
+// with the work currently open for it, if any.
+type EquipmentMaintenance struct {
+	models.MaintenanceSchedule
+	OpenWork *models.MaintenanceOccurrence `json:"openWork"`
+}
+
+// MaintenancePlanFromTemplate reads an EquipmentType.DefaultMaintenancePlan,
+// which uses the JSON shape of a plan without its target.
+func MaintenancePlanFromTemplate(template []byte) (*models.MaintenancePlan, error) {
+	var plan models.MaintenancePlan
+	decoder := json.NewDecoder(bytes.NewReader(template))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&plan); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMaintenancePlan, err)
+	}
+
+	plan.ID = uuid.Nil
+	plan.BusinessID = uuid.Nil
+	plan.EquipmentID = nil
+	plan.EquipmentTypeID = nil
+	plan.CreatedBy = nil
+	plan.Active = true
+	if err := checkMaintenancePlan(&plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func ListMaintenancePlans(ctx context.Context, businessID uuid.UUID, equipmentID *uuid.UUID, typeID *uuid.UUID) ([]models.MaintenancePlan, error) {
+	query := database.Conn(ctx).Where("business_id = ?", businessID)
+	if equipmentID != nil {
+		query = query.Where("equipment_id = ?", *equipmentID)
+	}
+	if typeID != nil {
+		query = query.Where("equipment_type_id = ?", *typeID)
+	}
+
+	var plans []models.MaintenancePlan
+	err := query.Order("name ASC, created_at ASC").Find(&plans).Error
+	return plans, err
+}
+
+func GetMaintenancePlan(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.MaintenancePlan, error) {
+	var plan models.MaintenancePlan
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMaintenancePlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// CreateMaintenancePlan saves a plan for one piece of equipment or for an
+// equipment type and schedules it straight away, opening work that is already
+// within its lead time.
+func CreateMaintenancePlan(ctx context.Context, plan *models.MaintenancePlan) error {
+	if (plan.EquipmentID == nil) == (plan.EquipmentTypeID == nil) {
+		return fmt.Errorf("%w: choose either equipment or an equipment type", ErrInvalidMaintenancePlan)
+	}
+	if plan.EquipmentID != nil {
+		if _, err := GetEquipmentInBusiness(ctx, plan.BusinessID, *plan.EquipmentID); err != nil {
+			return err
+		}
+	}
+	if plan.EquipmentTypeID != nil {
+		if _, err := GetEquipmentTypeByID(ctx, plan.BusinessID, *plan.EquipmentTypeID); err != nil {
+			return err
+		}
+	}
+	if err := checkMaintenancePlan(plan); err != nil {
+		return err
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(plan).Error; err != nil {
+			return err
+		}
+		_, err := runMaintenancePlan(tx, plan, time.Now())
+		return err
+	})
+}
+
+// UpdateMaintenancePlan saves a plan's settings. Due dates already scheduled are
+// kept; a changed interval applies from the next completion.
+func UpdateMaintenancePlan(ctx context.Context, plan *models.MaintenancePlan) error {
+	if err := checkMaintenancePlan(plan); err != nil {
+		return err
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.MaintenancePlan{}).
+			Where("id = ?", plan.ID).
+			Updates(map[string]any{
+				"name":           plan.Name,
+				"description":    plan.Description,
+				"trigger":        plan.Trigger,
+				"interval_days":  plan.IntervalDays,
+				"first_due_at":   plan.FirstDueAt,
+				"lead_days":      plan.LeadDays,
+				"grace_days":     plan.GraceDays,
+				"meter_name":     plan.MeterName,
+				"meter_interval": plan.MeterInterval,
+				"lead_usage":     plan.LeadUsage,
+				"grace_usage":    plan.GraceUsage,
+				"fixed_schedule": plan.FixedSchedule,
+				"active":         plan.Active,
+			}).Error
+		if err != nil {
+			return err
+		}
+		_, err = runMaintenancePlan(tx, plan, time.Now())
+		return err
+	})
+}
+
+// DeleteMaintenancePlan removes a plan with its schedules and history. Issues
+// already opened for it are left as they are.
+func DeleteMaintenancePlan(ctx context.Context, plan *models.MaintenancePlan) error {
+	return database.Conn(ctx).Delete(&models.MaintenancePlan{}, "id = ?", plan.ID).Error
+}
+
+// GetEquipmentMaintenance returns every maintenance schedule of equipment, soonest
+// due first, with the work open for each.
+func GetEquipmentMaintenance(ctx context.Context, equipmentID uuid.UUID) ([]EquipmentMaintenance, error) {
+	var schedules []models.MaintenanceSchedule
+	err := database.Conn(ctx).
+		Preload("Plan").
+		Where("equipment_id = ?", equipmentID).
+		Order("next_due_at ASC NULLS LAST, created_at ASC").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var open []models.MaintenanceOccurrence
+	err = database.Conn(ctx).
+		Where("equipment_id = ? AND status = ?", equipmentID, models.MaintenanceOpen).
+		Find(&open).Error
+	if err != nil {
+		return nil, err
+	}
+	openBySchedule := make(map[uuid.UUID]*models.MaintenanceOccurrence, len(open))
+	for i := range open {
+		openBySchedule[open[i].ScheduleID] = &open[i]
+	}
+
+	result := make([]EquipmentMaintenance, 0, len(schedules))
+	for _, schedule := range schedules {
+		result = append(result, EquipmentMaintenance{
+			MaintenanceSchedule: schedule,
+			OpenWork:            openBySchedule[schedule.ID],
+		})
+	}
+	return result, nil
+}
+
+// ListMaintenanceOccurrences returns one page of a business's maintenance work,
+// soonest due first, and the total number of matches.
+func ListMaintenanceOccurrences(ctx context.Context, businessID uuid.UUID, filter MaintenanceOccurrenceFilter) ([]models.MaintenanceOccurrence, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.MaintenanceOccurrence{}).
+		Where("business_id = ?", businessID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EquipmentID != nil {
+		query = query.Where("equipment_id = ?", *filter.EquipmentID)
+	}
+	if filter.PlanID != nil {
+		query = query.Where("plan_id = ?", *filter.PlanID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var occurrences []models.MaintenanceOccurrence
+	err := query.
+		Preload("Plan").
+		Order("due_at ASC NULLS LAST, created_at ASC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&occurrences).Error
+	return occurrences, total, err
+}
+
+func GetMaintenanceOccurrence(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.MaintenanceOccurrence, error) {
+	var occurrence models.MaintenanceOccurrence
+	err := database.Conn(ctx).
+		Preload("Plan").
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&occurrence).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMaintenanceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &occurrence, nil
+}
+
+// CompleteMaintenance closes open work as done, judges whether it was on time
+// and schedules the next round. For meter plans meterValue is the meter at
+// completion; it defaults to the meter's latest reading.
+func CompleteMaintenance(ctx context.Context, occurrence *models.MaintenanceOccurrence, actorID uuid.UUID, meterValue *float64, note string) error {
+	return closeMaintenance(ctx, occurrence, models.MaintenanceCompleted, actorID, meterValue, note)
+}
+
+// SkipMaintenance closes open work without doing it. It counts against
+// compliance, and the next round is due one interval after the skipped one.
+func SkipMaintenance(ctx context.Context, occurrence *models.MaintenanceOccurrence, actorID uuid.UUID, note string) error {
+	return closeMaintenance(ctx, occurrence, models.MaintenanceSkipped, actorID, nil, note)
+}
+
+func closeMaintenance(ctx context.Context, occurrence *models.MaintenanceOccurrence, status string, actorID uuid.UUID, meterValue *float64, note string) error {
+	now := time.Now()
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.MaintenanceOccurrence
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", occurrence.ID).
+			Take(&current).Error
+		if err != nil {
+			return err
+		}
+		if current.Status != models.MaintenanceOpen {
+			return ErrMaintenanceClosed
+		}
+
+		plan := occurrence.Plan
+		var schedule models.MaintenanceSchedule
+		if err := tx.Where("id = ?", occurrence.ScheduleID).Take(&schedule).Error; err != nil {
+			return err
+		}
+
+		var currentMeter *float64
+		if plan.Trigger == models.MaintenanceTriggerMeter {
+			meters, err := plannedMeters(tx, &plan, []uuid.UUID{occurrence.EquipmentID})
+			if err != nil {
+				return err
+			}
+			if meter, ok := meters[occurrence.EquipmentID]; ok {
+				currentMeter = meter.LastValue
+			}
+			if meterValue == nil {
+				meterValue = currentMeter
+			}
+			if status == models.MaintenanceCompleted && meterValue == nil {
+				return ErrMaintenanceMeterRequired
+			}
+		}
+
+		outcome := models.MaintenanceOutcomeSkipped
+		if status == models.MaintenanceCompleted {
+			outcome = maintenanceOutcome(&plan, occurrence, now, meterValue)
+		}
+
+		updates := map[string]any{
+			"status":          status,
+			"outcome":         outcome,
+			"closed_at":       now,
+			"closed_by":       actorID,
+			"completed_meter": meterValue,
+			"note":            strings.TrimSpace(note),
+		}
+		if err := tx.Model(&models.MaintenanceOccurrence{}).Where("id = ?", occurrence.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if occurrence.IssueID != nil {
+			err := tx.Model(&models.Issue{}).
+				Where("id = ? AND date_completed IS NULL", *occurrence.IssueID).
+				Updates(map[string]any{"date_completed": now, "progress": models.IssueProgressResolved}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		scheduleUpdates := map[string]any{}
+		switch plan.Trigger {
+		case models.MaintenanceTriggerCalendar:
+			base := now
+			if (plan.FixedSchedule || status == models.MaintenanceSkipped) && occurrence.DueAt != nil {
+				base = *occurrence.DueAt
+			}
+			next := base.AddDate(0, 0, plan.IntervalDays)
+			for !next.After(now) {
+				next = next.AddDate(0, 0, plan.IntervalDays)
+			}
+			scheduleUpdates["next_due_at"] = next
+		case models.MaintenanceTriggerMeter:
+			reached := 0.0
+			if meterValue != nil {
+				reached = *meterValue
+			}
+			base := reached
+			if (plan.FixedSchedule || status == models.MaintenanceSkipped) && occurrence.DueMeter != nil {
+				base = *occurrence.DueMeter
+			}
+			// The first multiple of the interval past the reached value. Computed
+			// rather than stepped to, since a huge reading would take forever.
+			steps := math.Max(1, math.Floor((reached-base)/plan.MeterInterval)+1)
+			scheduleUpdates["next_due_meter"] = base + steps*plan.MeterInterval
+		}
+		if status == models.MaintenanceCompleted {
+			scheduleUpdates["last_completed_at"] = now
+			scheduleUpdates["last_completed_meter"] = meterValue
+		}
+		if err := tx.Model(&models.MaintenanceSchedule{}).Where("id = ?", schedule.ID).Updates(scheduleUpdates).Error; err != nil {
+			return err
+		}
+
+		occurrence.Status = status
+		occurrence.Outcome = outcome
+		occurrence.ClosedAt = &now
+		occurrence.ClosedBy = &actorID
+		occurrence.CompletedMeter = meterValue
+		occurrence.Note = strings.TrimSpace(note)
+		return nil
+	})
+}
+
+// maintenanceOutcome decides whether completed work was on time: by the due date
+// plus GraceDays for calendar plans, by the due meter value plus GraceUsage for
+// meter plans.
+func maintenanceOutcome(plan *models.MaintenancePlan, occurrence *models.MaintenanceOccurrence, completedAt time.Time, meterValue *float64) string {
+	switch plan.Trigger {
+	case models.MaintenanceTriggerCalendar:
+		if occurrence.DueAt != nil && completedAt.After(occurrence.DueAt.AddDate(0, 0, plan.GraceDays)) {
+			return models.MaintenanceOutcomeLate
+		}
+	case models.MaintenanceTriggerMeter:
+		if occurrence.DueMeter != nil && meterValue != nil && *meterValue > *occurrence.DueMeter+plan.GraceUsage {
+			return models.MaintenanceOutcomeLate
+		}
+	}
+	return models.MaintenanceOutcomeOnTime
+}
+
+// GetMaintenanceCompliance summarises the maintenance that came due in
+// [From, To). Meter-based work counts by when it was opened.
+func GetMaintenanceCompliance(ctx context.Context, businessID uuid.UUID, filter ComplianceFilter) (*MaintenanceCompliance, error) {
+	query := database.Conn(ctx).
+		Model(&models.MaintenanceOccurrence{}).
+		Select(`
+			COUNT(*) FILTER (WHERE outcome = ?) AS on_time,
+			COUNT(*) FILTER (WHERE outcome = ?) AS late,
+			COUNT(*) FILTER (WHERE outcome = ?) AS skipped,
+			COUNT(*) FILTER (WHERE status = ?) AS open,
+			COUNT(*) FILTER (WHERE status = ? AND due_at < NOW()) AS overdue`,
+			models.MaintenanceOutcomeOnTime,
+			models.MaintenanceOutcomeLate,
+			models.MaintenanceOutcomeSkipped,
+			models.MaintenanceOpen,
+			models.MaintenanceOpen,
+		).
+		Where("business_id = ?", businessID).
+		Where("COALESCE(due_at, created_at) >= ? AND COALESCE(due_at, created_at) < ?", filter.From, filter.To)
+	if filter.EquipmentID != nil {
+		query = query.Where("equipment_id = ?", *filter.EquipmentID)
+	}
+	if filter.PlanID != nil {
+		query = query.Where("plan_id = ?", *filter.PlanID)
+	}
+
+	var compliance MaintenanceCompliance
+	if err := query.Scan(&compliance).Error; err != nil {
+		return nil, err
+	}
+
+	if closed := compliance.OnTime + compliance.Late + compliance.Skipped; closed > 0 {
+		rate := float64(compliance.OnTime) / float64(closed)
+		compliance.Rate = &rate
+	}
+	return &compliance, nil
+}
+
+// GenerateMaintenanceWork brings every active plan up to date, or only those of
+// one business, and returns how many issues were opened. Plans of suspended and
+// deleted businesses are left alone. Each plan runs in its own transaction so one
+// failing plan does not hold up the rest.
+func GenerateMaintenanceWork(ctx context.Context, businessID *uuid.UUID) (int, error) {
+	query := database.Conn(ctx).
+		Where("active").
+		Where("business_id IN (?)", database.Conn(ctx).Model(&models.Business{}).Select("id").Where("status = ?", models.BusinessStatusActive))
+	if businessID != nil {
+		query = query.Where("business_id = ?", *businessID)
+	}
+
+	var plans []models.MaintenancePlan
+	if err := query.Find(&plans).Error; err != nil {
+		return 0, err
+	}
+
+	opened := 0
+	var errs []error
+	for i := range plans {
+		err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+			count, err := runMaintenancePlan(tx, &plans[i], time.Now())
+			opened += count
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plan %s: %w", plans[i].ID, err))
+		}
+	}
+	return opened, errors.Join(errs...)
+}
+
+// runMaintenancePlan adds schedules for equipment the plan newly applies to and
+// opens an issue for every schedule that has come within its lead time. Retired
+// equipment is skipped.
+func runMaintenancePlan(tx *gorm.DB, plan *models.MaintenancePlan, now time.Time) (int, error) {
+	if !plan.Active {
+		return 0, nil
+	}
+
+	equipmentQuery := tx.Where("business_id = ? AND retired_at IS NULL", plan.BusinessID)
+	if plan.EquipmentID != nil {
+		equipmentQuery = equipmentQuery.Where("id = ?", *plan.EquipmentID)
+	} else {
+		equipmentQuery = equipmentQuery.Where("type_id = ?", *plan.EquipmentTypeID)
+	}
+	var equipment []models.Equipment
+	if err := equipmentQuery.Find(&equipment).Error; err != nil {
+		return 0, err
+	}
+	if len(equipment) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(equipment))
+	for _, eq := range equipment {
+		ids = append(ids, eq.ID)
+	}
+
+	var meters map[uuid.UUID]models.EquipmentMeter
+	if plan.Trigger == models.MaintenanceTriggerMeter {
+		var err error
+		if meters, err = plannedMeters(tx, plan, ids); err != nil {
+			return 0, err
+		}
+	}
+
+	var schedules []models.MaintenanceSchedule
+	if err := tx.Where("plan_id = ? AND equipment_id IN ?", plan.ID, ids).Find(&schedules).Error; err != nil {
+		return 0, err
+	}
+	scheduled := make(map[uuid.UUID]*models.MaintenanceSchedule, len(schedules))
+	for i := range schedules {
+		scheduled[schedules[i].EquipmentID] = &schedules[i]
+	}
+
+	var openIDs []uuid.UUID
+	err := tx.Model(&models.MaintenanceOccurrence{}).
+		Where("plan_id = ? AND status = ?", plan.ID, models.MaintenanceOpen).
+		Pluck("schedule_id", &openIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	hasOpenWork := make(map[uuid.UUID]bool, len(openIDs))
+	for _, id := range openIDs {
+		hasOpenWork[id] = true
+	}
+
+	opened := 0
+	for i := range equipment {
+		eq := &equipment[i]
+		schedule := scheduled[eq.ID]
+		if schedule == nil {
+			schedule = &models.MaintenanceSchedule{
+				BusinessID:  plan.BusinessID,
+				PlanID:      plan.ID,
+				EquipmentID: eq.ID,
+			}
+			if plan.Trigger == models.MaintenanceTriggerCalendar {
+				due := now.AddDate(0, 0, plan.IntervalDays)
+				if plan.FirstDueAt != nil {
+					due = *plan.FirstDueAt
+				}
+				schedule.NextDueAt = &due
+			}
+			if err := tx.Create(schedule).Error; err != nil {
+				return opened, err
+			}
+		}
+
+		// A meter plan starts counting from the meter's value when it is first seen.
+		if plan.Trigger == models.MaintenanceTriggerMeter && schedule.NextDueMeter == nil {
+			meter, ok := meters[eq.ID]
+			if !ok {
+				continue
+			}
+			due := plan.MeterInterval
+			if meter.LastValue != nil {
+				due += *meter.LastValue
+			}
+			schedule.NextDueMeter = &due
+			if err := tx.Model(schedule).Update("next_due_meter", due).Error; err != nil {
+				return opened, err
+			}
+		}
+
+		if hasOpenWork[schedule.ID] || !maintenanceComingDue(plan, schedule, meters[eq.ID], now) {
+			continue
+		}
+		// A concurrent run may have opened the work since it was listed; the
+		// savepoint keeps the plan's transaction usable when it has.
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return openMaintenanceWork(tx, plan, schedule, eq, now)
+		})
+		if uniqueViolation(err, "idx_open_maintenance") {
+			continue
+		}
+		if err != nil {
+			return opened, err
+		}
+		opened++
+	}
+	return opened, nil
+}
+
+func maintenanceComingDue(plan *models.MaintenancePlan, schedule *models.MaintenanceSchedule, meter models.EquipmentMeter, now time.Time) bool {
+	switch plan.Trigger {
+	case models.MaintenanceTriggerCalendar:
+		return schedule.NextDueAt != nil && !now.Before(schedule.NextDueAt.AddDate(0, 0, -plan.LeadDays))
+	case models.MaintenanceTriggerMeter:
+		return schedule.NextDueMeter != nil && meter.LastValue != nil && *meter.LastValue >= *schedule.NextDueMeter-plan.LeadUsage
+	}
+	return false
+}
+
+// openMaintenanceWork opens an issue for the next round of a schedule. It is
+// assigned to whoever created the plan, or else to a business admin, and routed
+// to the equipment's responsible team.
+func openMaintenanceWork(tx *gorm.DB, plan *models.MaintenancePlan, schedule *models.MaintenanceSchedule, eq *models.Equipment, now time.Time) error {
+	assigneeID, err := maintenanceAssignee(tx, plan)
+	if err != nil {
+		return err
+	}
+
+	description := plan.Description
+	if schedule.NextDueAt != nil {
+		description = strings.TrimSpace(fmt.Sprintf("Preventive maintenance due %s.\n\n%s", schedule.NextDueAt.Format("2006-01-02"), description))
+	} else if schedule.NextDueMeter != nil {
+		description = strings.TrimSpace(fmt.Sprintf("Preventive maintenance due at %g on %s.\n\n%s", *schedule.NextDueMeter, plan.MeterName, description))
+	}
+
+	issue := models.Issue{
+		Title:         plan.Name,
+		Description:   description,
+		EquipmentID:   eq.ID,
+		Progress:      models.IssueProgressNew,
+		AssigneeID:    assigneeID,
+		TeamID:        eq.ResponsibleTeamID,
+		DateSubmitted: now,
+	}
+	if err := tx.Omit(clause.Associations).Create(&issue).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.MaintenanceOccurrence{
+		BusinessID:  plan.BusinessID,
+		PlanID:      plan.ID,
+		ScheduleID:  schedule.ID,
+		EquipmentID: eq.ID,
+		IssueID:     &issue.ID,
+		DueAt:       schedule.NextDueAt,
+		DueMeter:    schedule.NextDueMeter,
+		Status:      models.MaintenanceOpen,
+	}).Error
+}
+
+func maintenanceAssignee(tx *gorm.DB, plan *models.MaintenancePlan) (uuid.UUID, error) {
+	if plan.CreatedBy != nil {
+		return *plan.CreatedBy, nil
+	}
+
+	var membership models.UserBusiness
+	err := tx.
+		Where("business_id = ? AND is_admin AND deactivated_at IS NULL", plan.BusinessID).
+		Order("id ASC").
+		Take(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, errors.New("business has no admin to assign maintenance to")
+	}
+	return membership.UserID, err
+}
+
+// plannedMeters finds, per piece of equipment, the meter a meter plan follows.
+// Meters are matched by name without regard to case.
+func plannedMeters(tx *gorm.DB, plan *models.MaintenancePlan, equipmentIDs []uuid.UUID) (map[uuid.UUID]models.EquipmentMeter, error) {
+	var meters []models.EquipmentMeter
+	err := tx.
+		Where("equipment_id IN ? AND LOWER(name) = LOWER(?)", equipmentIDs, plan.MeterName).
+		Find(&meters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byEquipment := make(map[uuid.UUID]models.EquipmentMeter, len(meters))
+	for _, meter := range meters {
+		byEquipment[meter.EquipmentID] = meter
+	}
+	return byEquipment, nil
+}
+
+// createDefaultMaintenancePlan gives new equipment its own copy of its type's
+// default maintenance plan, if the type has one.
+func createDefaultMaintenancePlan(tx *gorm.DB, eq *models.Equipment, equipmentType *models.EquipmentType, actorID *uuid.UUID) error {
+	if equipmentType == nil || len(equipmentType.DefaultMaintenancePlan) == 0 {
+		return nil
+	}
+
+	plan, err := MaintenancePlanFromTemplate(equipmentType.DefaultMaintenancePlan)
+	if err != nil {
+		return err
+	}
+	plan.BusinessID = eq.BusinessID
+	plan.EquipmentID = &eq.ID
+	plan.CreatedBy = actorID
+
+	if err := tx.Create(plan).Error; err != nil {
+		return err
+	}
+	_, err = runMaintenancePlan(tx, plan, time.Now())
+	return err
+}
+
+func checkMaintenancePlan(plan *models.MaintenancePlan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	plan.MeterName = strings.TrimSpace(plan.MeterName)
+
+	invalid := func(message string) error {
+		return fmt.Errorf("%w: %s", ErrInvalidMaintenancePlan, message)
+	}
+
+	if plan.Name == "" || len(plan.Name) > 128 {
+		return invalid("name must be 1 to 128 characters")
+	}
+	if plan.LeadDays < 0 || plan.GraceDays < 0 || plan.LeadUsage < 0 || plan.GraceUsage < 0 {
+		return invalid("lead and grace values cannot be negative")
+	}
+
+	switch plan.Trigger {
+	case models.MaintenanceTriggerCalendar:
+		if plan.IntervalDays < 1 || plan.IntervalDays > 3650 {
+			return invalid("intervalDays must be between 1 and 3650")
+		}
+		if plan.LeadDays >= plan.IntervalDays {
+			return invalid("leadDays must be shorter than the interval")
+		}
+		plan.MeterName = ""
+		plan.MeterInterval = 0
+		plan.LeadUsage = 0
+		plan.GraceUsage = 0
+	case models.MaintenanceTriggerMeter:
+		if plan.MeterName == "" {
+			return invalid("meterName is required for meter plans")
+		}
+		if plan.MeterInterval <= 0 {
+			return invalid("meterInterval must be greater than zero")
+		}
+		if plan.LeadUsage >= plan.MeterInterval {
+			return invalid("leadUsage must be smaller than the interval")
+		}
+		plan.IntervalDays = 0
+		plan.FirstDueAt = nil
+		plan.LeadDays = 0
+		plan.GraceDays = 0
+	default:
+		return invalid("trigger must be calendar or meter")
+	}
+	return nil
+}
@@ -0,0 +1,525 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPartNotFound         = errors.New("part not found")
+	ErrPartNumberDuplicate  = errors.New("a part with this part number already exists")
+	ErrPartNotFitted        = errors.New("part is not linked to this equipment")
+	ErrPartInUse            = errors.New("part has stock history and cannot be deleted")
+	ErrInsufficientStock    = errors.New("not enough stock")
+	ErrInvalidPartQuantity  = errors.New("quantity must be greater than zero")
+	ErrPartAdjustmentNoOp   = errors.New("adjustment does not change the stock level")
+	ErrInvalidPartMovement  = errors.New("invalid stock movement kind")
+	ErrNoPartsToConsume     = errors.New("no parts to consume")
+	ErrPartConsumptionSplit = errors.New("each part and location may appear only once")
+)
+
+type PartFilter struct {
+	// Query matches part numbers and names.
+	Query       string
+	EquipmentID *uuid.UUID
+	VendorID    *uuid.UUID
+}
+
+// PartSummary is a catalogue entry with its stock summed over all locations.
+// Low is set when any location is below its minimum.
+type PartSummary struct {
+	models.Part
+	OnHand float64 `json:"onHand"`
+	Low    bool    `json:"low"`
+}
+
+// PartConsumption is a quantity of a part taken from stock at a location.
+type PartConsumption struct {
+	PartID     uuid.UUID
+	LocationID uuid.UUID
+	Quantity   float64
+}
+
+// LowStockItem is a stock level below its minimum.
+type LowStockItem struct {
+	PartID       uuid.UUID `json:"partId"`
+	PartNumber   string    `json:"partNumber"`
+	Name         string    `json:"name"`
+	Unit         string    `json:"unit"`
+	LocationID   uuid.UUID `json:"locationId"`
+	LocationName string    `json:"locationName"`
+	Quantity     float64   `json:"quantity"`
+	MinQuantity  float64   `json:"minQuantity"`
+	Shortfall    float64   `json:"shortfall"`
+}
+
+// ListParts returns a business's parts catalogue by part number with the stock
+// on hand.
+func ListParts(ctx context.Context, businessID uuid.UUID, filter PartFilter) ([]PartSummary, error) {
+	query := database.Conn(ctx).Where("business_id = ?", businessID)
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(part_number) LIKE ? OR LOWER(name) LIKE ?", pattern, pattern)
+	}
+	if filter.EquipmentID != nil {
+		query = query.Where("id IN (?)", database.Conn(ctx).Model(&models.PartFitment{}).Select("part_id").Where("equipment_id = ?", *filter.EquipmentID))
+	}
+	if filter.VendorID != nil {
+		query = query.Where("vendor_id = ?", *filter.VendorID)
+	}
+
+	var parts []models.Part
+	if err := query.Order("part_number ASC").Find(&parts).Error; err != nil {
+		return nil, err
+	}
+
+	var totals []struct {
+		PartID uuid.UUID
+		OnHand float64
+		Low    bool
+	}
+	err := database.Conn(ctx).
+		Model(&models.PartStock{}).
+		Select("part_id, SUM(quantity) AS on_hand, BOOL_OR(quantity < min_quantity) AS low").
+		Where("business_id = ?", businessID).
+		Group("part_id").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	onHand := make(map[uuid.UUID]float64, len(totals))
+	low := make(map[uuid.UUID]bool, len(totals))
+	for _, row := range totals {
+		onHand[row.PartID] = row.OnHand
+		low[row.PartID] = row.Low
+	}
+
+	summaries := make([]PartSummary, 0, len(parts))
+	for _, part := range parts {
+		summaries = append(summaries, PartSummary{Part: part, OnHand: onHand[part.ID], Low: low[part.ID]})
+	}
+	return summaries, nil
+}
+
+func GetPart(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.Part, error) {
+	var part models.Part
+	err := database.Conn(ctx).
+		Preload("Vendor").
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&part).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &part, nil
+}
+
+func CreatePart(ctx context.Context, part *models.Part) error {
+	if err := checkPart(ctx, part, uuid.Nil); err != nil {
+		return err
+	}
+	return database.Conn(ctx).Omit(clause.Associations).Create(part).Error
+}
+
+func UpdatePart(ctx context.Context, part *models.Part) error {
+	if err := checkPart(ctx, part, part.ID); err != nil {
+		return err
+	}
+	return database.Conn(ctx).
+		Model(&models.Part{}).
+		Where("id = ?", part.ID).
+		Updates(map[string]any{
+			"part_number": part.PartNumber,
+			"name":        part.Name,
+			"description": part.Description,
+			"unit":        part.Unit,
+			"unit_cost":   part.UnitCost,
+			"vendor_id":   part.VendorID,
+		}).Error
+}
+
+// DeletePart removes a part with its stock levels and fitments. Parts that have
+// been received, counted or used keep their history and cannot be deleted.
+func DeletePart(ctx context.Context, part *models.Part) error {
+	var movements int64
+	err := database.Conn(ctx).
+		Model(&models.PartMovement{}).
+		Where("part_id = ?", part.ID).
+		Count(&movements).Error
+	if err != nil {
+		return err
+	}
+	if movements > 0 {
+		return ErrPartInUse
+	}
+	return database.Conn(ctx).Delete(&models.Part{}, "id = ?", part.ID).Error
+}
+
+func checkPart(ctx context.Context, part *models.Part, excludeID uuid.UUID) error {
+	part.PartNumber = strings.TrimSpace(part.PartNumber)
+	part.Name = strings.TrimSpace(part.Name)
+	part.Unit = strings.TrimSpace(part.Unit)
+	if part.Unit == "" {
+		part.Unit = "each"
+	}
+
+	var existing int64
+	err := database.Conn(ctx).
+		Model(&models.Part{}).
+		Where("business_id = ? AND LOWER(part_number) = ? AND id <> ?", part.BusinessID, strings.ToLower(part.PartNumber), excludeID).
+		Count(&existing).Error
+	if err != nil {
+		return err
+	}
+	if existing > 0 {
+		return ErrPartNumberDuplicate
+	}
+
+	return checkVendors(ctx, part.BusinessID, part.VendorID)
+}
+
+// GetPartStock returns a part's stock level at each location it is kept.
+func GetPartStock(ctx context.Context, partID uuid.UUID) ([]models.PartStock, error) {
+	var stock []models.PartStock
+	err := database.Conn(ctx).
+		Preload("Location").
+		Where("part_id = ?", partID).
+		Order("quantity DESC").
+		Find(&stock).Error
+	return stock, err
+}
+
+// SetPartMinimum sets the minimum stock of a part at a location.
+func SetPartMinimum(ctx context.Context, part *models.Part, locationID uuid.UUID, minQuantity float64) (*models.PartStock, error) {
+	if _, err := GetLocationByID(ctx, part.BusinessID, locationID); err != nil {
+		return nil, err
+	}
+
+	var stock *models.PartStock
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if stock, err = lockPartStock(tx, part, locationID); err != nil {
+			return err
+		}
+		stock.MinQuantity = minQuantity
+		return tx.Model(stock).Update("min_quantity", minQuantity).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stock, nil
+}
+
+// AdjustPartStock records a delivery (a positive receipt) or a correction after
+// a stock count (an adjustment of either sign) at a location.
+func AdjustPartStock(ctx context.Context, part *models.Part, locationID uuid.UUID, kind string, quantity float64, actorID uuid.UUID, note string) (*models.PartStock, error) {
+	switch kind {
+	case models.PartMovementReceipt:
+		if quantity <= 0 {
+			return nil, ErrInvalidPartQuantity
+		}
+	case models.PartMovementAdjustment:
+		if quantity == 0 {
+			return nil, ErrPartAdjustmentNoOp
+		}
+	default:
+		return nil, ErrInvalidPartMovement
+	}
+	if _, err := GetLocationByID(ctx, part.BusinessID, locationID); err != nil {
+		return nil, err
+	}
+
+	var stock *models.PartStock
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if stock, err = lockPartStock(tx, part, locationID); err != nil {
+			return err
+		}
+		return movePartStock(tx, stock, &models.PartMovement{
+			Kind:     kind,
+			Quantity: quantity,
+			ActorID:  &actorID,
+			Note:     strings.TrimSpace(note),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stock, nil
+}
+
+// ConsumeParts takes parts used on an issue out of stock. Either every line is
+// taken or, when any location runs short, none is.
+func ConsumeParts(ctx context.Context, businessID uuid.UUID, issue *models.Issue, lines []PartConsumption, actorID uuid.UUID, note string) ([]models.PartMovement, error) {
+	if len(lines) == 0 {
+		return nil, ErrNoPartsToConsume
+	}
+
+	// Stock rows are locked in one order, so two consumptions that share rows
+	// cannot deadlock.
+	lines = slices.Clone(lines)
+	slices.SortFunc(lines, func(a, b PartConsumption) int {
+		if c := bytes.Compare(a.PartID[:], b.PartID[:]); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.LocationID[:], b.LocationID[:])
+	})
+
+	seen := make(map[[2]uuid.UUID]bool, len(lines))
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidPartQuantity
+		}
+		key := [2]uuid.UUID{line.PartID, line.LocationID}
+		if seen[key] {
+			return nil, ErrPartConsumptionSplit
+		}
+		seen[key] = true
+	}
+
+	ids := make([]uuid.UUID, 0, len(lines))
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, line := range lines {
+			part, err := GetPart(ctx, businessID, line.PartID)
+			if err != nil {
+				return err
+			}
+			if _, err := GetLocationByID(ctx, businessID, line.LocationID); err != nil {
+				return err
+			}
+
+			stock, err := lockPartStock(tx, part, line.LocationID)
+			if err != nil {
+				return err
+			}
+			movement := models.PartMovement{
+				Kind:        models.PartMovementConsumption,
+				Quantity:    -line.Quantity,
+				IssueID:     &issue.ID,
+				EquipmentID: &issue.EquipmentID,
+				ActorID:     &actorID,
+				Note:        strings.TrimSpace(note),
+			}
+			if err := movePartStock(tx, stock, &movement); err != nil {
+				return fmt.Errorf("%s: %w", part.PartNumber, err)
+			}
+			ids = append(ids, movement.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var movements []models.PartMovement
+	err = database.Conn(ctx).
+		Preload("Part").
+		Preload("Location").
+		Where("id IN ?", ids).
+		Order("created_at ASC").
+		Find(&movements).Error
+	return movements, err
+}
+
+// GetIssuePartUsage returns the parts consumed on an issue, oldest first.
+func GetIssuePartUsage(ctx context.Context, issueID uuid.UUID) ([]models.PartMovement, error) {
+	var movements []models.PartMovement
+	err := database.Conn(ctx).
+		Preload("Part").
+		Preload("Location").
+		Preload("Actor").
+		Where("issue_id = ?", issueID).
+		Order("created_at ASC").
+		Find(&movements).Error
+	return movements, err
+}
+
+// GetPartMovements returns one page of a part's stock movements, newest first,
+// and the total number of movements.
+func GetPartMovements(ctx context.Context, partID uuid.UUID, limit int, offset int) ([]models.PartMovement, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.PartMovement{}).
+		Where("part_id = ?", partID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var movements []models.PartMovement
+	err := query.
+		Preload("Location").
+		Preload("Actor").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&movements).Error
+	return movements, total, err
+}
+
+// lockPartStock locks the stock row of a part at a location, creating an empty
+// one when the part has not been kept there before.
+func lockPartStock(tx *gorm.DB, part *models.Part, locationID uuid.UUID) (*models.PartStock, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Omit(clause.Associations).
+		Create(&models.PartStock{
+			BusinessID: part.BusinessID,
+			PartID:     part.ID,
+			LocationID: locationID,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var stock models.PartStock
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("part_id = ? AND location_id = ?", part.ID, locationID).
+		Take(&stock).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+// movePartStock applies a movement to a locked stock row and records it. Stock
+// never goes below zero. Quantities are kept to the three decimals the columns store.
+func movePartStock(tx *gorm.DB, stock *models.PartStock, movement *models.PartMovement) error {
+	movement.Quantity = roundPartQuantity(movement.Quantity)
+	quantity := roundPartQuantity(stock.Quantity + movement.Quantity)
+	if quantity < 0 {
+		return fmt.Errorf("%w: %g on hand", ErrInsufficientStock, stock.Quantity)
+	}
+
+	if err := tx.Model(stock).Update("quantity", quantity).Error; err != nil {
+		return err
+	}
+	stock.Quantity = quantity
+
+	movement.BusinessID = stock.BusinessID
+	movement.PartID = stock.PartID
+	movement.LocationID = stock.LocationID
+	return tx.Omit(clause.Associations).Create(movement).Error
+}
+
+func roundPartQuantity(quantity float64) float64 {
+	return math.Round(quantity*1000) / 1000
+}
+
+// ListEquipmentParts returns the parts that fit equipment, by part number.
+func ListEquipmentParts(ctx context.Context, equipmentID uuid.UUID) ([]models.PartFitment, error) {
+	var fitments []models.PartFitment
+	err := database.Conn(ctx).
+		Joins("Part").
+		Where("part_fitments.equipment_id = ?", equipmentID).
+		Order(`"Part".part_number ASC`).
+		Find(&fitments).Error
+	return fitments, err
+}
+
+// FitPart links a part to equipment, or updates the quantity of an existing link.
+func FitPart(ctx context.Context, eq *models.Equipment, part *models.Part, quantity float64, note string) (*models.PartFitment, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidPartQuantity
+	}
+
+	fitment := models.PartFitment{
+		BusinessID:  eq.BusinessID,
+		PartID:      part.ID,
+		EquipmentID: eq.ID,
+		Quantity:    quantity,
+		Note:        strings.TrimSpace(note),
+	}
+	err := database.Conn(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "part_id"}, {Name: "equipment_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "note"}),
+		}).
+		Omit(clause.Associations).
+		Create(&fitment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.Conn(ctx).
+		Preload("Part").
+		Where("part_id = ? AND equipment_id = ?", part.ID, eq.ID).
+		Take(&fitment).Error
+	return &fitment, err
+}
+
+func UnfitPart(ctx context.Context, equipmentID uuid.UUID, partID uuid.UUID) error {
+	result := database.Conn(ctx).
+		Where("equipment_id = ? AND part_id = ?", equipmentID, partID).
+		Delete(&models.PartFitment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPartNotFitted
+	}
+	return nil
+}
+
+// MigratePartMovementConstraints replaces ON DELETE CASCADE foreign keys from
+// stock movements to parts and locations with the RESTRICT ones declared on the
+// model, as MigrateIssueEquipmentConstraint does for issues.
+func MigratePartMovementConstraints(ctx context.Context) error {
+	var cascading int64
+	err := database.Conn(ctx).Raw(`
+		SELECT COUNT(*) FROM pg_constraint
+		WHERE contype = 'f'
+		  AND conrelid = 'part_movements'::regclass
+		  AND confrelid IN ('parts'::regclass, 'locations'::regclass)
+		  AND confdeltype = 'c'`).Scan(&cascading).Error
+	if err != nil || cascading == 0 {
+		return err
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, relation := range []string{"Part", "Location"} {
+			if err := tx.Migrator().DropConstraint(&models.PartMovement{}, relation); err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateConstraint(&models.PartMovement{}, relation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetLowStockReport returns every stock level below its minimum, optionally at
+// one location and those beneath it, largest shortfall first.
+func GetLowStockReport(ctx context.Context, businessID uuid.UUID, locationID *uuid.UUID) ([]LowStockItem, error) {
+	query := database.Conn(ctx).
+		Table("part_stocks s").
+		Select(`s.part_id, p.part_number, p.name, p.unit, s.location_id, l.name AS location_name,
+			s.quantity, s.min_quantity, s.min_quantity - s.quantity AS shortfall`).
+		Joins("JOIN parts p ON p.id = s.part_id").
+		Joins("JOIN locations l ON l.id = s.location_id").
+		Where("s.business_id = ? AND s.quantity < s.min_quantity", businessID)
+	if locationID != nil {
+		query = query.Where("s.location_id IN (?)", database.Conn(ctx).Raw(locationSubtreeSQL, *locationID, businessID))
+	}
+
+	items := []LowStockItem{}
+	err := query.
+		Order("s.min_quantity - s.quantity DESC, p.part_number ASC").
+		Scan(&items).Error
+	return items, err
+}
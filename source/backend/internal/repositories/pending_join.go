@@ -1,70 +1,332 @@
 package repositories
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"html"
+	"log"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/EquipQR/equipqr/backend/internal/database"
 	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func GetAllPendingJoinsForBusiness(businessID uuid.UUID) ([]models.PendingJoinRequest, error) {
+var (
+	ErrJoinRequestNotFound  = errors.New("join request not found")
+	ErrJoinRequestDuplicate = errors.New("a join request for this business is already pending")
+	ErrJoinRequestClosed    = errors.New("join request has already been decided")
+	ErrAlreadyMember        = errors.New("user is already a member of this business")
+	ErrBusinessClosed       = errors.New("business is not accepting join requests")
+)
+
+// CreatePendingJoinRequest files a join request unless the user is already a
+// member or already has an open request for the business. Admins of the
+// business are notified by email.
+func CreatePendingJoinRequest(ctx context.Context, userID uuid.UUID, businessID uuid.UUID, message string) (*models.PendingJoinRequest, error) {
+	business, err := GetBusinessByID(ctx, businessID.String())
+	if err != nil {
+		return nil, errors.New("business not found")
+	}
+	if business.Status != models.BusinessStatusActive || !business.UserCanRegister {
+		return nil, ErrBusinessClosed
+	}
+
+	return openJoinRequest(ctx, userID, businessID, message)
+}
+
+// openJoinRequest files a join request without checking whether the business
+// accepts self-registration; callers decide whether that applies.
+func openJoinRequest(ctx context.Context, userID uuid.UUID, businessID uuid.UUID, message string) (*models.PendingJoinRequest, error) {
+	if _, err := GetUserBusinessMembership(ctx, userID, businessID); err == nil {
+		return nil, ErrAlreadyMember
+	}
+
+	req := models.PendingJoinRequest{
+		UserID:     userID,
+		BusinessID: businessID,
+		Status:     models.JoinStatusPending,
+		Message:    message,
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&req).Error; err != nil {
+			return err
+		}
+		return recordJoinRequestEvent(tx, req.ID, models.JoinStatusPending, &userID, "")
+	})
+	if uniqueViolation(err, "idx_open_join_request") {
+		return nil, ErrJoinRequestDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go notifyAdminsOfJoinRequest(req)
+	return &req, nil
+}
+
+// GetAllPendingJoinsForBusiness lists join requests for a business. An empty
+// status returns every request, including decided ones.
+func GetAllPendingJoinsForBusiness(ctx context.Context, businessID uuid.UUID, status string) ([]models.PendingJoinRequest, error) {
+	query := database.Conn(ctx).
+		Preload("User").
+		Where("business_id = ?", businessID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
 	var requests []models.PendingJoinRequest
-	err := database.DB.Where("business_id = ?", businessID).Find(&requests).Error
+	err := query.Order("created_at DESC").Find(&requests).Error
 	return requests, err
 }
 
-func ApprovePendingJoin(requestID uuid.UUID) error {
-	req, err := GetPendingJoinRequestByID(requestID)
+// ApprovePendingJoin adds the applicant to the business and closes the request
+// in one transaction. The request row is locked, so concurrent approvals cannot
+// both add the member.
+func ApprovePendingJoin(ctx context.Context, requestID uuid.UUID, actorID uuid.UUID) error {
+	var req *models.PendingJoinRequest
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if req, err = lockPendingJoin(tx, requestID); err != nil {
+			return err
+		}
+		if err := addMembership(tx, req.UserID, req.BusinessID, false); err != nil {
+			return err
+		}
+		return decidePendingJoin(tx, req, models.JoinStatusApproved, &actorID, "")
+	})
 	if err != nil {
 		return err
 	}
 
-	if err := AddUserToBusiness(req.UserID.String(), req.BusinessID.String(), false); err != nil {
-		return err
-	}
+	go notifyApplicantOfDecision(*req)
+	return nil
+}
 
-	if err := DeletePendingJoinRequestByID(requestID); err != nil {
+func DenyPendingJoin(ctx context.Context, requestID uuid.UUID, actorID uuid.UUID, reason string) error {
+	var req *models.PendingJoinRequest
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if req, err = lockPendingJoin(tx, requestID); err != nil {
+			return err
+		}
+		return decidePendingJoin(tx, req, models.JoinStatusDenied, &actorID, reason)
+	})
+	if err != nil {
 		return err
 	}
 
+	go notifyApplicantOfDecision(*req)
 	return nil
 }
 
-func DenyPendingJoin(requestID uuid.UUID) error {
-	_, err := GetPendingJoinRequestByID(requestID)
+// MigrateJoinRequestUniqueness expires all but the oldest of duplicate pending
+// requests and then adds the partial unique index that allows a user one open
+// request per business.
+func MigrateJoinRequestUniqueness(ctx context.Context) error {
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			UPDATE pending_join_requests SET status = ?, decision_reason = 'duplicate request', decided_at = NOW()
+			WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, business_id ORDER BY created_at, id) AS n
+					FROM pending_join_requests
+					WHERE status = ?
+				) ranked
+				WHERE n > 1
+			)`, models.JoinStatusExpired, models.JoinStatusPending).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_open_join_request ON pending_join_requests (user_id, business_id) WHERE status = 'pending'").Error
+	})
+}
+
+// ExpireStalePendingJoins closes requests that have been pending for longer than maxAge.
+func ExpireStalePendingJoins(ctx context.Context, maxAge time.Duration) (int, error) {
+	var stale []models.PendingJoinRequest
+	err := database.Conn(ctx).
+		Where("status = ? AND created_at < ?", models.JoinStatusPending, time.Now().Add(-maxAge)).
+		Find(&stale).Error
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if err := DeletePendingJoinRequestByID(requestID); err != nil {
-		return err
+	for i := range stale {
+		err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+			return decidePendingJoin(tx, &stale[i], models.JoinStatusExpired, nil, "request expired")
+		})
+		if err != nil {
+			return i, err
+		}
 	}
 
-	return nil
+	return len(stale), nil
 }
 
-func GetPendingJoinRequestByID(id uuid.UUID) (*models.PendingJoinRequest, error) {
+func GetPendingJoinRequestByID(ctx context.Context, id uuid.UUID) (*models.PendingJoinRequest, error) {
 	var req models.PendingJoinRequest
-	err := database.DB.First(&req, "id = ?", id).Error
+	err := database.Conn(ctx).First(&req, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJoinRequestNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &req, nil
 }
 
-func DeletePendingJoinRequestByID(id uuid.UUID) error {
-	return database.DB.
-		Where("id = ?", id).
-		Delete(&models.PendingJoinRequest{}).Error
+func GetJoinRequestHistory(ctx context.Context, requestID uuid.UUID) ([]models.JoinRequestEvent, error) {
+	var events []models.JoinRequestEvent
+	err := database.Conn(ctx).
+		Where("request_id = ?", requestID).
+		Order("created_at ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// lockPendingJoin loads a join request that is still pending and locks it until
+// the transaction ends.
+func lockPendingJoin(tx *gorm.DB, id uuid.UUID) (*models.PendingJoinRequest, error) {
+	var req models.PendingJoinRequest
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJoinRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != models.JoinStatusPending {
+		return nil, ErrJoinRequestClosed
+	}
+	return &req, nil
+}
+
+// decidePendingJoin closes a pending request inside tx and records the decision.
+func decidePendingJoin(tx *gorm.DB, req *models.PendingJoinRequest, status string, actorID *uuid.UUID, reason string) error {
+	now := time.Now()
+
+	result := tx.Model(&models.PendingJoinRequest{}).
+		Where("id = ? AND status = ?", req.ID, models.JoinStatusPending).
+		Updates(map[string]any{
+			"status":          status,
+			"decision_reason": reason,
+			"decided_by":      actorID,
+			"decided_at":      now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJoinRequestClosed
+	}
+
+	req.Status = status
+	req.DecisionReason = reason
+	req.DecidedBy = actorID
+	req.DecidedAt = &now
+
+	return recordJoinRequestEvent(tx, req.ID, status, actorID, reason)
+}
+
+func recordJoinRequestEvent(tx *gorm.DB, requestID uuid.UUID, status string, actorID *uuid.UUID, reason string) error {
+	return tx.Create(&models.JoinRequestEvent{
+		RequestID: requestID,
+		Status:    status,
+		ActorID:   actorID,
+		Reason:    reason,
+	}).Error
+}
+
+func notifyAdminsOfJoinRequest(req models.PendingJoinRequest) {
+	if !utils.AppConfig.Email_Enabled {
+		return
+	}
+
+	// Runs after the request has finished, outside its tenant scope.
+	ctx := database.SystemContext(context.Background())
+
+	applicant, err := GetUserByID(ctx, req.UserID.String())
+	if err != nil {
+		log.Printf("join request %s: could not load applicant: %v", req.ID, err)
+		return
+	}
+
+	business, err := GetBusinessByID(ctx, req.BusinessID.String())
+	if err != nil {
+		log.Printf("join request %s: could not load business: %v", req.ID, err)
+		return
+	}
+
+	admins, err := GetBusinessAdmins(ctx, req.BusinessID)
+	if err != nil {
+		log.Printf("join request %s: could not load admins: %v", req.ID, err)
+		return
+	}
+
+	subject := fmt.Sprintf("New request to join %s", business.BusinessName)
+	body := fmt.Sprintf(
+		"<p><strong>%s</strong> (%s) has asked to join <strong>%s</strong>.</p>",
+		html.EscapeString(applicant.Username), html.EscapeString(applicant.Email), html.EscapeString(business.BusinessName),
+	)
+	if req.Message != "" {
+		body += fmt.Sprintf("<blockquote>%s</blockquote>", html.EscapeString(req.Message))
+	}
+	body += fmt.Sprintf("<p>Review pending requests at %s/admin.</p>", utils.AppConfig.BaseURL)
+
+	for _, admin := range admins {
+		if err := utils.SendEmail(admin.Email, subject, body); err != nil {
+			log.Printf("join request %s: could not notify %s: %v", req.ID, admin.Email, err)
+		}
+	}
+}
+
+func notifyApplicantOfDecision(req models.PendingJoinRequest) {
+	if !utils.AppConfig.Email_Enabled {
+		return
+	}
+
+	ctx := database.SystemContext(context.Background())
+
+	applicant, err := GetUserByID(ctx, req.UserID.String())
+	if err != nil {
+		log.Printf("join request %s: could not load applicant: %v", req.ID, err)
+		return
+	}
+
+	business, err := GetBusinessByID(ctx, req.BusinessID.String())
+	if err != nil {
+		log.Printf("join request %s: could not load business: %v", req.ID, err)
+		return
+	}
+
+	var subject, body string
+	if req.Status == models.JoinStatusApproved {
+		subject = fmt.Sprintf("You have joined %s", business.BusinessName)
+		body = fmt.Sprintf("<p>Your request to join <strong>%s</strong> was approved.</p>", html.EscapeString(business.BusinessName))
+	} else {
+		subject = fmt.Sprintf("Your request to join %s", business.BusinessName)
+		body = fmt.Sprintf("<p>Your request to join <strong>%s</strong> was not approved.</p>", html.EscapeString(business.BusinessName))
+		if req.DecisionReason != "" {
+			body += fmt.Sprintf("<p>Reason: %s</p>", html.EscapeString(req.DecisionReason))
+		}
+	}
+
+	if err := utils.SendEmail(applicant.Email, subject, body); err != nil {
+		log.Printf("join request %s: could not notify applicant: %v", req.ID, err)
+	}
 }
 
 func GenerateInviteLinkWithEmail(businessID uuid.UUID, email string, secret string, baseURL string, expiryMinutes int) (string, error) {
@@ -87,7 +349,9 @@ func GenerateInviteLinkWithEmail(businessID uuid.UUID, email string, secret stri
 	return link, nil
 }
 
-func ProcessInvite(params utils.InviteParams, userID string) error {
+// ProcessInvite adds the user to the business named by a signed invite link.
+// The invite must be addressed to the user's email and the business active.
+func ProcessInvite(ctx context.Context, params utils.InviteParams, userID string) error {
 	exp, err := strconv.ParseInt(params.Expiry, 10, 64)
 	if err != nil || time.Now().Unix() > exp {
 		return errors.New("invite expired or invalid expiry format")
@@ -107,7 +371,23 @@ func ProcessInvite(params utils.InviteParams, userID string) error {
 		return errors.New("invalid business ID")
 	}
 
-	if err := AddUserToBusiness(userID, businessID.String(), false); err != nil {
+	user, err := GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !strings.EqualFold(strings.TrimSpace(user.Email), strings.TrimSpace(params.Email)) {
+		return errors.New("invite was sent to a different email address")
+	}
+
+	business, err := GetBusinessForLifecycle(ctx, businessID)
+	if err != nil {
+		return errors.New("business not found")
+	}
+	if business.Status != models.BusinessStatusActive {
+		return ErrBusinessClosed
+	}
+
+	if err := AddUserToBusiness(ctx, userID, businessID.String(), false); err != nil {
 		return errors.New("failed to add user to business")
 	}
 
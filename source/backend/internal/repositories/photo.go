@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPhotoNotFound        = errors.New("photo not found")
+	ErrPrimaryPhotoConflict = errors.New("the primary photo was changed at the same time, try again")
+)
+
+// CreateEquipmentPhoto records an uploaded photo. The first photo of a piece of
+// equipment becomes its primary photo, as does one uploaded with IsPrimary set.
+func CreateEquipmentPhoto(ctx context.Context, photo *models.EquipmentPhoto) error {
+	photo.Caption = strings.TrimSpace(photo.Caption)
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockEquipmentPhotos(tx, photo.EquipmentID); err != nil {
+			return err
+		}
+		if photo.IsPrimary {
+			if err := clearPrimaryPhoto(tx, photo.EquipmentID); err != nil {
+				return err
+			}
+		} else {
+			var primaries int64
+			err := tx.Model(&models.EquipmentPhoto{}).
+				Where("equipment_id = ? AND is_primary", photo.EquipmentID).
+				Count(&primaries).Error
+			if err != nil {
+				return err
+			}
+			photo.IsPrimary = primaries == 0
+		}
+		return tx.Omit(clause.Associations).Create(photo).Error
+	})
+	if uniqueViolation(err, "idx_primary_photo") {
+		return ErrPrimaryPhotoConflict
+	}
+	return err
+}
+
+func GetEquipmentPhoto(ctx context.Context, equipmentID uuid.UUID, id uuid.UUID) (*models.EquipmentPhoto, error) {
+	var photo models.EquipmentPhoto
+	err := database.Conn(ctx).
+		Where("id = ? AND equipment_id = ?", id, equipmentID).
+		Take(&photo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// ListEquipmentPhotos returns equipment's photos, primary first and then oldest
+// first. Thumbnails are left out; they are fetched per photo.
+func ListEquipmentPhotos(ctx context.Context, equipmentID uuid.UUID) ([]models.EquipmentPhoto, error) {
+	var photos []models.EquipmentPhoto
+	err := database.Conn(ctx).
+		Omit("thumbnail").
+		Where("equipment_id = ?", equipmentID).
+		Order("is_primary DESC, created_at ASC").
+		Find(&photos).Error
+	return photos, err
+}
+
+// SetPrimaryPhoto makes photo the primary photo of its equipment.
+func SetPrimaryPhoto(ctx context.Context, photo *models.EquipmentPhoto) error {
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockEquipmentPhotos(tx, photo.EquipmentID); err != nil {
+			return err
+		}
+		if err := clearPrimaryPhoto(tx, photo.EquipmentID); err != nil {
+			return err
+		}
+		return tx.Model(&models.EquipmentPhoto{}).
+			Where("id = ?", photo.ID).
+			Update("is_primary", true).Error
+	})
+	if uniqueViolation(err, "idx_primary_photo") {
+		return ErrPrimaryPhotoConflict
+	}
+	if err != nil {
+		return err
+	}
+	photo.IsPrimary = true
+	return nil
+}
+
+func UpdatePhotoCaption(ctx context.Context, photo *models.EquipmentPhoto, caption string) error {
+	photo.Caption = strings.TrimSpace(caption)
+	return database.Conn(ctx).
+		Model(&models.EquipmentPhoto{}).
+		Where("id = ?", photo.ID).
+		Update("caption", photo.Caption).Error
+}
+
+// DeleteEquipmentPhoto removes a photo's record; the caller removes the stored
+// variants. When the primary photo is deleted the oldest remaining photo takes
+// its place.
+func DeleteEquipmentPhoto(ctx context.Context, photo *models.EquipmentPhoto) error {
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockEquipmentPhotos(tx, photo.EquipmentID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.EquipmentPhoto{}, "id = ?", photo.ID).Error; err != nil {
+			return err
+		}
+		if !photo.IsPrimary {
+			return nil
+		}
+
+		var next models.EquipmentPhoto
+		err := tx.Select("id").
+			Where("equipment_id = ?", photo.EquipmentID).
+			Order("created_at ASC").
+			Take(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.EquipmentPhoto{}).
+			Where("id = ?", next.ID).
+			Update("is_primary", true).Error
+	})
+}
+
+// lockEquipmentPhotos serialises changes to the photos of one piece of equipment,
+// so two requests cannot both pick a primary photo and trip idx_primary_photo.
+func lockEquipmentPhotos(tx *gorm.DB, equipmentID uuid.UUID) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", "equipment_photos:"+equipmentID.String()).Error
+}
+
+func clearPrimaryPhoto(tx *gorm.DB, equipmentID uuid.UUID) error {
+	return tx.Model(&models.EquipmentPhoto{}).
+		Where("equipment_id = ? AND is_primary", equipmentID).
+		Update("is_primary", false).Error
+}
+
+// primaryThumbnails returns the thumbnail of each piece of equipment's primary
+// photo as a data URI.
+func primaryThumbnails(ctx context.Context, equipmentIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	var rows []struct {
+		EquipmentID uuid.UUID
+		Thumbnail   []byte
+	}
+	err := database.Conn(ctx).
+		Model(&models.EquipmentPhoto{}).
+		Select("equipment_id, thumbnail").
+		Where("equipment_id IN ? AND is_primary", equipmentIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnails := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		thumbnails[row.EquipmentID] = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(row.Thumbnail)
+	}
+	return thumbnails, nil
+}
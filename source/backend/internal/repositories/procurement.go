@@ -0,0 +1,522 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVendorNotFound          = errors.New("vendor not found")
+	ErrVendorDuplicate         = errors.New("a vendor with this name already exists")
+	ErrVendorInUse             = errors.New("vendor still has service contracts")
+	ErrInvalidVendorKind       = errors.New("invalid vendor kind")
+	ErrServiceContractNotFound = errors.New("service contract not found")
+	ErrInvalidWarrantyPeriod   = errors.New("warranty must end after it starts")
+	ErrInvalidContractPeriod   = errors.New("service contract must end after it starts")
+)
+
+// Warranty and service contract coverage as shown on badges. Coverage is
+// expiring when it ends within coverageExpiringDays.
+const (
+	CoverageActive   = "active"
+	CoverageExpiring = "expiring"
+	CoverageExpired  = "expired"
+	CoveragePending  = "pending"
+
+	coverageExpiringDays = 30
+)
+
+// WarrantyBadge summarises equipment's warranty for lists and detail pages.
+type WarrantyBadge struct {
+	Status        string    `json:"status"`
+	EndsOn        time.Time `json:"endsOn"`
+	DaysRemaining int       `json:"daysRemaining"`
+}
+
+// ServiceContractDetail is a service contract with the equipment it covers.
+type ServiceContractDetail struct {
+	models.ServiceContract
+	Status       string      `json:"status"`
+	EquipmentIDs []uuid.UUID `json:"equipmentIds"`
+}
+
+type ServiceContractFilter struct {
+	VendorID    *uuid.UUID
+	EquipmentID *uuid.UUID
+	// ExpiringWithin selects contracts that are in force and end within that many
+	// days, soonest first.
+	ExpiringWithin *int
+}
+
+// ValidVendorKind reports whether kind is one of the vendor kinds.
+func ValidVendorKind(kind string) bool {
+	switch kind {
+	case models.VendorKindManufacturer,
+		models.VendorKindSupplier,
+		models.VendorKindService,
+		models.VendorKindOther:
+		return true
+	}
+	return false
+}
+
+// ListVendors returns a business's vendors by name, optionally of one kind.
+func ListVendors(ctx context.Context, businessID uuid.UUID, kind string) ([]models.Vendor, error) {
+	if kind != "" && !ValidVendorKind(kind) {
+		return nil, ErrInvalidVendorKind
+	}
+
+	query := database.Conn(ctx).Where("business_id = ?", businessID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var vendors []models.Vendor
+	err := query.Order("name ASC").Find(&vendors).Error
+	return vendors, err
+}
+
+func GetVendor(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.Vendor, error) {
+	var vendor models.Vendor
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&vendor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVendorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &vendor, nil
+}
+
+// CreateVendor adds a vendor. Names are unique per business regardless of case,
+// which idx_vendor_name enforces.
+func CreateVendor(ctx context.Context, vendor *models.Vendor) error {
+	vendor.Name = strings.TrimSpace(vendor.Name)
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Omit(clause.Associations).Create(vendor).Error
+	})
+	if uniqueViolation(err, "idx_vendor_name") {
+		return ErrVendorDuplicate
+	}
+	return err
+}
+
+func UpdateVendor(ctx context.Context, vendor *models.Vendor) error {
+	vendor.Name = strings.TrimSpace(vendor.Name)
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.Vendor{}).
+			Where("id = ?", vendor.ID).
+			Updates(map[string]any{
+				"name":         vendor.Name,
+				"kind":         vendor.Kind,
+				"contact_name": vendor.ContactName,
+				"email":        vendor.Email,
+				"phone":        vendor.Phone,
+				"website":      vendor.Website,
+				"notes":        vendor.Notes,
+			}).Error
+	})
+	if uniqueViolation(err, "idx_vendor_name") {
+		return ErrVendorDuplicate
+	}
+	return err
+}
+
+// DeleteVendor removes a vendor. Procurement records keep their other details;
+// vendors with service contracts cannot be deleted.
+func DeleteVendor(ctx context.Context, vendor *models.Vendor) error {
+	var contracts int64
+	err := database.Conn(ctx).
+		Model(&models.ServiceContract{}).
+		Where("vendor_id = ?", vendor.ID).
+		Count(&contracts).Error
+	if err != nil {
+		return err
+	}
+	if contracts > 0 {
+		return ErrVendorInUse
+	}
+	return database.Conn(ctx).Delete(&models.Vendor{}, "id = ?", vendor.ID).Error
+}
+
+// MigrateVendorNames makes vendor names unique per business regardless of case.
+// Databases created before the index was case-insensitive have a plain index of
+// the same name, which is replaced.
+func MigrateVendorNames(ctx context.Context) error {
+	return database.Conn(ctx).Exec(`
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_vendor_name' AND indexdef NOT ILIKE '%lower(%') THEN
+		DROP INDEX idx_vendor_name;
+	END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vendor_name ON vendors (business_id, LOWER(name));`).Error
+}
+
+// checkVendors makes sure the given vendors belong to the business.
+func checkVendors(ctx context.Context, businessID uuid.UUID, ids ...*uuid.UUID) error {
+	for _, id := range ids {
+		if id == nil {
+			continue
+		}
+		if _, err := GetVendor(ctx, businessID, *id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetEquipmentProcurement returns equipment's procurement record with its
+// vendors, or nil when none has been recorded.
+func GetEquipmentProcurement(ctx context.Context, equipmentID uuid.UUID) (*models.EquipmentProcurement, error) {
+	var procurement models.EquipmentProcurement
+	err := database.Conn(ctx).
+		Preload("Manufacturer").
+		Preload("Supplier").
+		Where("equipment_id = ?", equipmentID).
+		Take(&procurement).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &procurement, nil
+}
+
+// SaveEquipmentProcurement creates or replaces equipment's procurement and
+// warranty record.
+func SaveEquipmentProcurement(ctx context.Context, procurement *models.EquipmentProcurement) (*models.EquipmentProcurement, error) {
+	if procurement.WarrantyStart != nil && procurement.WarrantyEnd != nil && !procurement.WarrantyEnd.After(*procurement.WarrantyStart) {
+		return nil, ErrInvalidWarrantyPeriod
+	}
+	if err := checkVendors(ctx, procurement.BusinessID, procurement.ManufacturerID, procurement.SupplierID); err != nil {
+		return nil, err
+	}
+	procurement.PurchaseOrder = strings.TrimSpace(procurement.PurchaseOrder)
+	procurement.Currency = strings.ToUpper(strings.TrimSpace(procurement.Currency))
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.EquipmentProcurement
+		err := tx.Select("id").Where("equipment_id = ?", procurement.EquipmentID).Take(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Omit(clause.Associations).Create(procurement).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		procurement.ID = existing.ID
+		return tx.Model(&models.EquipmentProcurement{}).
+			Where("id = ?", existing.ID).
+			Updates(map[string]any{
+				"manufacturer_id": procurement.ManufacturerID,
+				"supplier_id":     procurement.SupplierID,
+				"purchase_order":  procurement.PurchaseOrder,
+				"purchase_date":   procurement.PurchaseDate,
+				"purchase_cost":   procurement.PurchaseCost,
+				"currency":        procurement.Currency,
+				"warranty_start":  procurement.WarrantyStart,
+				"warranty_end":    procurement.WarrantyEnd,
+				"warranty_terms":  procurement.WarrantyTerms,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetEquipmentProcurement(ctx, procurement.EquipmentID)
+}
+
+func DeleteEquipmentProcurement(ctx context.Context, equipmentID uuid.UUID) error {
+	return database.Conn(ctx).Delete(&models.EquipmentProcurement{}, "equipment_id = ?", equipmentID).Error
+}
+
+// NewWarrantyBadge describes a warranty ending on endsOn as of now. A warranty
+// that starts later, when startsOn is known, is pending.
+func NewWarrantyBadge(startsOn *time.Time, endsOn time.Time, now time.Time) *WarrantyBadge {
+	days := daysUntil(endsOn, now)
+	return &WarrantyBadge{
+		Status:        coverageStatus(startsOn, days),
+		EndsOn:        endsOn,
+		DaysRemaining: days,
+	}
+}
+
+// warrantyBadges returns the warranty badge of each piece of equipment that has
+// a warranty end date recorded.
+func warrantyBadges(ctx context.Context, equipmentIDs []uuid.UUID) (map[uuid.UUID]*WarrantyBadge, error) {
+	var rows []struct {
+		EquipmentID   uuid.UUID
+		WarrantyStart *time.Time
+		WarrantyEnd   time.Time
+	}
+	err := database.Conn(ctx).
+		Model(&models.EquipmentProcurement{}).
+		Select("equipment_id, warranty_start, warranty_end").
+		Where("equipment_id IN ? AND warranty_end IS NOT NULL", equipmentIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	badges := make(map[uuid.UUID]*WarrantyBadge, len(rows))
+	for _, row := range rows {
+		badges[row.EquipmentID] = NewWarrantyBadge(row.WarrantyStart, row.WarrantyEnd, now)
+	}
+	return badges, nil
+}
+
+// ListExpiringWarranties returns the procurement records of active equipment
+// whose warranty ends within the given number of days, soonest first.
+func ListExpiringWarranties(ctx context.Context, businessID uuid.UUID, withinDays int) ([]models.EquipmentProcurement, error) {
+	today := startOfDay(time.Now())
+
+	var procurements []models.EquipmentProcurement
+	err := database.Conn(ctx).
+		Preload("Equipment").
+		Preload("Manufacturer").
+		Preload("Supplier").
+		Where("business_id = ? AND warranty_end BETWEEN ? AND ?", businessID, today, today.AddDate(0, 0, withinDays)).
+		Where("equipment_id IN (?)", database.Conn(ctx).Model(&models.Equipment{}).Select("id").Where("retired_at IS NULL")).
+		Order("warranty_end ASC").
+		Find(&procurements).Error
+	return procurements, err
+}
+
+// ListServiceContracts returns a business's service contracts, latest ending
+// first unless ExpiringWithin is set.
+func ListServiceContracts(ctx context.Context, businessID uuid.UUID, filter ServiceContractFilter) ([]ServiceContractDetail, error) {
+	query := database.Conn(ctx).
+		Preload("Vendor").
+		Where("business_id = ?", businessID)
+	if filter.VendorID != nil {
+		query = query.Where("vendor_id = ?", *filter.VendorID)
+	}
+	if filter.EquipmentID != nil {
+		query = query.Where("id IN (?)", database.Conn(ctx).Model(&models.ServiceContractEquipment{}).Select("contract_id").Where("equipment_id = ?", *filter.EquipmentID))
+	}
+	if filter.ExpiringWithin != nil {
+		today := startOfDay(time.Now())
+		query = query.
+			Where("starts_on <= ? AND ends_on BETWEEN ? AND ?", today, today, today.AddDate(0, 0, *filter.ExpiringWithin)).
+			Order("ends_on ASC")
+	} else {
+		query = query.Order("ends_on DESC")
+	}
+
+	var contracts []models.ServiceContract
+	if err := query.Order("title ASC").Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+	return serviceContractDetails(ctx, contracts)
+}
+
+func GetServiceContract(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*ServiceContractDetail, error) {
+	var contract models.ServiceContract
+	err := database.Conn(ctx).
+		Preload("Vendor").
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&contract).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrServiceContractNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := serviceContractDetails(ctx, []models.ServiceContract{contract})
+	if err != nil {
+		return nil, err
+	}
+	return &details[0], nil
+}
+
+// CreateServiceContract records a contract and the equipment it covers.
+func CreateServiceContract(ctx context.Context, contract *models.ServiceContract, equipmentIDs []uuid.UUID) (*ServiceContractDetail, error) {
+	if err := checkServiceContract(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(contract).Error; err != nil {
+			return err
+		}
+		return setContractEquipment(tx, contract, equipmentIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetServiceContract(ctx, contract.BusinessID, contract.ID)
+}
+
+// UpdateServiceContract saves a contract. A non-nil equipmentIDs replaces the
+// equipment it covers.
+func UpdateServiceContract(ctx context.Context, contract *models.ServiceContract, equipmentIDs *[]uuid.UUID) (*ServiceContractDetail, error) {
+	if err := checkServiceContract(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.ServiceContract{}).
+			Where("id = ?", contract.ID).
+			Updates(map[string]any{
+				"vendor_id":     contract.VendorID,
+				"title":         contract.Title,
+				"reference":     contract.Reference,
+				"starts_on":     contract.StartsOn,
+				"ends_on":       contract.EndsOn,
+				"terms":         contract.Terms,
+				"response_time": contract.ResponseTime,
+				"cost":          contract.Cost,
+				"currency":      contract.Currency,
+			}).Error
+		if err != nil || equipmentIDs == nil {
+			return err
+		}
+		return setContractEquipment(tx, contract, *equipmentIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetServiceContract(ctx, contract.BusinessID, contract.ID)
+}
+
+func DeleteServiceContract(ctx context.Context, contract *models.ServiceContract) error {
+	return database.Conn(ctx).Delete(&models.ServiceContract{}, "id = ?", contract.ID).Error
+}
+
+func checkServiceContract(ctx context.Context, contract *models.ServiceContract) error {
+	contract.Title = strings.TrimSpace(contract.Title)
+	contract.Reference = strings.TrimSpace(contract.Reference)
+	contract.Currency = strings.ToUpper(strings.TrimSpace(contract.Currency))
+
+	if !contract.EndsOn.After(contract.StartsOn) {
+		return ErrInvalidContractPeriod
+	}
+	return checkVendors(ctx, contract.BusinessID, &contract.VendorID)
+}
+
+// setContractEquipment replaces the equipment a contract covers. Every piece
+// must belong to the contract's business.
+func setContractEquipment(tx *gorm.DB, contract *models.ServiceContract, equipmentIDs []uuid.UUID) error {
+	ids := make([]uuid.UUID, 0, len(equipmentIDs))
+	seen := make(map[uuid.UUID]bool, len(equipmentIDs))
+	for _, id := range equipmentIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) > 0 {
+		var found int64
+		err := tx.Model(&models.Equipment{}).
+			Where("id IN ? AND business_id = ?", ids, contract.BusinessID).
+			Count(&found).Error
+		if err != nil {
+			return err
+		}
+		if found != int64(len(ids)) {
+			return ErrEquipmentNotFound
+		}
+	}
+
+	if err := tx.Where("contract_id = ?", contract.ID).Delete(&models.ServiceContractEquipment{}).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	links := make([]models.ServiceContractEquipment, len(ids))
+	for i, id := range ids {
+		links[i] = models.ServiceContractEquipment{
+			BusinessID:  contract.BusinessID,
+			ContractID:  contract.ID,
+			EquipmentID: id,
+		}
+	}
+	return tx.Omit(clause.Associations).Create(&links).Error
+}
+
+func serviceContractDetails(ctx context.Context, contracts []models.ServiceContract) ([]ServiceContractDetail, error) {
+	details := make([]ServiceContractDetail, len(contracts))
+	if len(contracts) == 0 {
+		return details, nil
+	}
+
+	ids := make([]uuid.UUID, len(contracts))
+	for i, contract := range contracts {
+		ids[i] = contract.ID
+	}
+
+	var links []models.ServiceContractEquipment
+	err := database.Conn(ctx).
+		Where("contract_id IN ?", ids).
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+
+	covered := make(map[uuid.UUID][]uuid.UUID, len(contracts))
+	for _, link := range links {
+		covered[link.ContractID] = append(covered[link.ContractID], link.EquipmentID)
+	}
+
+	now := time.Now()
+	for i, contract := range contracts {
+		equipmentIDs := covered[contract.ID]
+		if equipmentIDs == nil {
+			equipmentIDs = []uuid.UUID{}
+		}
+		details[i] = ServiceContractDetail{
+			ServiceContract: contract,
+			Status:          coverageStatus(&contract.StartsOn, daysUntil(contract.EndsOn, now)),
+			EquipmentIDs:    equipmentIDs,
+		}
+	}
+	return details, nil
+}
+
+// coverageStatus classifies coverage that ends daysRemaining days from today
+// and, when known, starts on startsOn.
+func coverageStatus(startsOn *time.Time, daysRemaining int) string {
+	switch {
+	case daysRemaining < 0:
+		return CoverageExpired
+	case startsOn != nil && startsOn.After(startOfDay(time.Now())):
+		return CoveragePending
+	case daysRemaining <= coverageExpiringDays:
+		return CoverageExpiring
+	default:
+		return CoverageActive
+	}
+}
+
+// daysUntil counts whole days from now's date to a date; coverage ending today
+// has 0 days remaining.
+func daysUntil(date time.Time, now time.Time) int {
+	end := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(startOfDay(now)).Hours() / 24)
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -3,17 +3,18 @@ package repositories
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"fmt"
 
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 )
 
-func GenerateQRCodeZipBytes(equipmentIDs []string) ([]byte, error) {
+func GenerateQRCodeZipBytes(ctx context.Context, equipmentIDs []string) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	zipWriter := zip.NewWriter(buf)
 
 	for _, id := range equipmentIDs {
-		equipment, err := GetEquipmentByID(id)
+		equipment, err := GetEquipmentByID(ctx, id)
 		if err != nil {
 			return nil, fmt.Errorf("equipment with ID %s not found: %w", id, err)
 		}
@@ -41,8 +42,8 @@ func GenerateQRCodeZipBytes(equipmentIDs []string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func GenerateSingleQRCodeBytes(equipmentID string) ([]byte, string, error) {
-	equipment, err := GetEquipmentByID(equipmentID)
+func GenerateSingleQRCodeBytes(ctx context.Context, equipmentID string) ([]byte, string, error) {
+	equipment, err := GetEquipmentByID(ctx, equipmentID)
 	if err != nil {
 		return nil, "", fmt.Errorf("equipment with ID %s not found: %w", equipmentID, err)
 	}
@@ -0,0 +1,510 @@
+package repositories
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/EquipQR/equipqr/backend/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// scimTokenPrefix makes provisioning tokens recognisable in logs and secret scanners.
+const scimTokenPrefix = "eqscim_"
+
+var (
+	ErrSCIMTokenInvalid  = errors.New("invalid or revoked SCIM token")
+	ErrSCIMUserNotFound  = errors.New("user not found")
+	ErrSCIMUserConflict  = errors.New("userName is already in use")
+	ErrSCIMGroupNotFound = errors.New("group not found")
+)
+
+// SCIMUserInput is the subset of a SCIM User resource EquipQR stores.
+type SCIMUserInput struct {
+	UserName   string
+	Email      string
+	ExternalID string
+	Active     bool
+	IsAdmin    bool
+}
+
+// SCIMGroupInput is the subset of a SCIM Group resource EquipQR stores. Groups map to teams.
+type SCIMGroupInput struct {
+	DisplayName string
+	ExternalID  string
+	MemberIDs   []uuid.UUID
+}
+
+// CreateSCIMToken issues a new provisioning token. The plaintext token is only
+// returned here; afterwards only its hash is known.
+func CreateSCIMToken(ctx context.Context, businessID uuid.UUID, name string, createdBy uuid.UUID) (string, *models.SCIMToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	plaintext := scimTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	token := models.SCIMToken{
+		BusinessID: businessID,
+		Name:       name,
+		TokenHash:  hashSCIMToken(plaintext),
+		CreatedBy:  createdBy,
+	}
+	if err := database.Conn(ctx).Create(&token).Error; err != nil {
+		return "", nil, err
+	}
+
+	return plaintext, &token, nil
+}
+
+func GetSCIMTokens(ctx context.Context, businessID uuid.UUID) ([]models.SCIMToken, error) {
+	var tokens []models.SCIMToken
+	err := database.Conn(ctx).
+		Where("business_id = ?", businessID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func RevokeSCIMToken(ctx context.Context, businessID uuid.UUID, id uuid.UUID) error {
+	result := database.Conn(ctx).
+		Model(&models.SCIMToken{}).
+		Where("id = ? AND business_id = ? AND revoked_at IS NULL", id, businessID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSCIMTokenInvalid
+	}
+	return nil
+}
+
+// AuthenticateSCIMToken resolves a bearer token to the business it provisions.
+func AuthenticateSCIMToken(ctx context.Context, plaintext string) (*models.SCIMToken, error) {
+	if !strings.HasPrefix(plaintext, scimTokenPrefix) {
+		return nil, ErrSCIMTokenInvalid
+	}
+
+	var token models.SCIMToken
+	err := database.Conn(ctx).
+		Where("token_hash = ? AND revoked_at IS NULL", hashSCIMToken(plaintext)).
+		Take(&token).Error
+	if err != nil {
+		return nil, ErrSCIMTokenInvalid
+	}
+
+	now := time.Now()
+	token.LastUsedAt = &now
+	database.Conn(ctx).Model(&models.SCIMToken{}).Where("id = ?", token.ID).Update("last_used_at", now)
+
+	return &token, nil
+}
+
+// ListSCIMUsers returns the business's memberships, including deactivated ones,
+// optionally narrowed to a single userName or email address.
+func ListSCIMUsers(ctx context.Context, businessID uuid.UUID, userName string, offset int, limit int) ([]models.UserBusiness, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.UserBusiness{}).
+		Joins("JOIN users ON users.id = user_businesses.user_id").
+		Where("user_businesses.business_id = ?", businessID)
+
+	if userName != "" {
+		query = query.Where("LOWER("+scimUserNameSQL+") = LOWER(?) OR LOWER(users.email) = LOWER(?)", userName, userName)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var memberships []models.UserBusiness
+	err := query.
+		Preload("User").
+		Order(scimUserNameSQL + " ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&memberships).Error
+	return memberships, total, err
+}
+
+// scimUserNameSQL is the userName a membership is listed under: the directory's
+// own name for the member, or the account's username for members it did not provision.
+const scimUserNameSQL = "COALESCE(NULLIF(user_businesses.user_name, ''), users.username)"
+
+func GetSCIMUser(ctx context.Context, businessID uuid.UUID, userID uuid.UUID) (*models.UserBusiness, error) {
+	var membership models.UserBusiness
+	err := database.Conn(ctx).
+		Preload("User").
+		Where("user_id = ? AND business_id = ?", userID, businessID).
+		Take(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSCIMUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ProvisionSCIMUser adds a member to the business. An existing account is only
+// linked when its email address is on a domain the business has verified, since
+// the business then controls that mailbox; otherwise a new password-less account
+// is created. The new account's address only counts as verified when it is on
+// one of those domains. Accounts are never matched by username, and an address
+// that belongs to an account the business cannot claim is reported as a conflict.
+func ProvisionSCIMUser(ctx context.Context, businessID uuid.UUID, input SCIMUserInput) (*models.UserBusiness, error) {
+	var userID uuid.UUID
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkSCIMUserName(tx, businessID, uuid.Nil, input.UserName); err != nil {
+			return err
+		}
+
+		var user models.User
+		err := tx.Where("LOWER(email) = LOWER(?)", input.Email).Take(&user).Error
+		switch {
+		case err == nil:
+			claimed, err := businessVerifiedDomain(tx, businessID, emailDomain(user.Email))
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				return ErrSCIMUserConflict
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			var taken int64
+			if err := tx.Model(&models.User{}).Where("LOWER(username) = LOWER(?)", input.UserName).Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				return ErrSCIMUserConflict
+			}
+
+			claimed, err := businessVerifiedDomain(tx, businessID, emailDomain(input.Email))
+			if err != nil {
+				return err
+			}
+			password, err := unusablePasswordHash()
+			if err != nil {
+				return err
+			}
+			user = models.User{
+				Username: input.UserName,
+				Email:    input.Email,
+				Password: password,
+				IsActive: true,
+			}
+			if claimed {
+				now := time.Now()
+				user.EmailVerifiedAt = &now // asserted by the business's identity provider
+			}
+			err = tx.Transaction(func(tx *gorm.DB) error {
+				return tx.Create(&user).Error
+			})
+			if uniqueViolation(err, "idx_user_email") {
+				return ErrSCIMUserConflict
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.UserBusiness{}).Where("user_id = ? AND business_id = ?", user.ID, businessID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrSCIMUserConflict
+		}
+
+		membership := models.UserBusiness{
+			UserID:     user.ID,
+			BusinessID: businessID,
+			IsAdmin:    input.IsAdmin,
+			ExternalID: input.ExternalID,
+			UserName:   input.UserName,
+		}
+		if !input.Active {
+			now := time.Now()
+			membership.DeactivatedAt = &now
+		}
+		if err := tx.Omit(clause.Associations).Create(&membership).Error; err != nil {
+			return err
+		}
+
+		userID = user.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetSCIMUser(ctx, businessID, userID)
+}
+
+// UpdateSCIMUser applies a full replacement of the SCIM attributes EquipQR stores.
+// Changes only touch the membership: the directory's userName is kept on it and
+// deactivation ends the membership, while the account, which other businesses
+// may share, keeps its name, email address and ability to sign in.
+func UpdateSCIMUser(ctx context.Context, businessID uuid.UUID, userID uuid.UUID, input SCIMUserInput) (*models.UserBusiness, error) {
+	membership, err := GetSCIMUser(ctx, businessID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkSCIMUserName(tx, businessID, userID, input.UserName); err != nil {
+			return err
+		}
+
+		var deactivatedAt *time.Time
+		if !input.Active {
+			deactivatedAt = membership.DeactivatedAt
+			if deactivatedAt == nil {
+				now := time.Now()
+				deactivatedAt = &now
+			}
+		}
+
+		return tx.Model(&models.UserBusiness{}).
+			Where("id = ?", membership.ID).
+			Updates(map[string]any{
+				"is_admin":       input.IsAdmin,
+				"external_id":    input.ExternalID,
+				"user_name":      input.UserName,
+				"deactivated_at": deactivatedAt,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetSCIMUser(ctx, businessID, userID)
+}
+
+// RemoveSCIMUser deprovisions a member: the membership and its team memberships
+// are removed. The account itself is left alone.
+func RemoveSCIMUser(ctx context.Context, businessID uuid.UUID, userID uuid.UUID) error {
+	membership, err := GetSCIMUser(ctx, businessID, userID)
+	if err != nil {
+		return err
+	}
+
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("user_id = ? AND team_id IN (?)", userID, tx.Model(&models.Team{}).Select("id").Where("business_id = ?", businessID)).
+			Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.UserBusiness{}, "id = ?", membership.ID).Error
+	})
+}
+
+// checkSCIMUserName rejects a userName another member of the business is
+// already listed under.
+func checkSCIMUserName(tx *gorm.DB, businessID uuid.UUID, excludeUserID uuid.UUID, userName string) error {
+	var taken int64
+	err := tx.Model(&models.UserBusiness{}).
+		Joins("JOIN users ON users.id = user_businesses.user_id").
+		Where("user_businesses.business_id = ? AND user_businesses.user_id <> ?", businessID, excludeUserID).
+		Where("LOWER("+scimUserNameSQL+") = LOWER(?)", userName).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrSCIMUserConflict
+	}
+	return nil
+}
+
+// businessVerifiedDomain reports whether the business holds a verified claim on
+// the email domain.
+func businessVerifiedDomain(tx *gorm.DB, businessID uuid.UUID, domain string) (bool, error) {
+	if domain == "" {
+		return false, nil
+	}
+	var verified int64
+	err := tx.Model(&models.BusinessEmailDomain{}).
+		Where("business_id = ? AND domain = ? AND verified_at IS NOT NULL", businessID, domain).
+		Count(&verified).Error
+	return verified > 0, err
+}
+
+func ListSCIMGroups(ctx context.Context, businessID uuid.UUID, displayName string, offset int, limit int) ([]models.Team, int64, error) {
+	query := database.Conn(ctx).
+		Model(&models.Team{}).
+		Where("business_id = ?", businessID)
+
+	if displayName != "" {
+		query = query.Where("LOWER(name) = LOWER(?)", displayName)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var teams []models.Team
+	err := query.Order("name ASC").Offset(offset).Limit(limit).Find(&teams).Error
+	return teams, total, err
+}
+
+func GetSCIMGroup(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.Team, error) {
+	team, err := GetTeamByID(ctx, businessID, id)
+	if errors.Is(err, ErrTeamNotFound) {
+		return nil, ErrSCIMGroupNotFound
+	}
+	return team, err
+}
+
+func CreateSCIMGroup(ctx context.Context, businessID uuid.UUID, input SCIMGroupInput) (*models.Team, error) {
+	team := models.Team{
+		BusinessID: businessID,
+		Name:       input.DisplayName,
+		ExternalID: input.ExternalID,
+	}
+
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&team).Error; err != nil {
+			return err
+		}
+		return addSCIMGroupMembers(tx, &team, input.MemberIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// ReplaceSCIMGroup renames a group and replaces its member list.
+func ReplaceSCIMGroup(ctx context.Context, team *models.Team, input SCIMGroupInput) error {
+	return database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if input.DisplayName != "" {
+			team.Name = input.DisplayName
+		}
+		team.ExternalID = input.ExternalID
+		if err := tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(map[string]any{
+			"name":        team.Name,
+			"external_id": team.ExternalID,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return addSCIMGroupMembers(tx, team, input.MemberIDs)
+	})
+}
+
+// SCIMGroupChange is one operation of a group PATCH. Members are removed before
+// they are added, so ReplaceMembers with AddMembers sets the member list.
+type SCIMGroupChange struct {
+	DisplayName    *string
+	ExternalID     *string
+	ReplaceMembers bool
+	RemoveMembers  []uuid.UUID
+	AddMembers     []uuid.UUID
+}
+
+// PatchSCIMGroup applies the operations of a group PATCH in order, in a single
+// transaction, so a failing operation leaves the group unchanged.
+func PatchSCIMGroup(ctx context.Context, team *models.Team, changes []SCIMGroupChange) error {
+	updated := *team
+	err := database.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			if change.DisplayName != nil {
+				updated.Name = *change.DisplayName
+			}
+			if change.ExternalID != nil {
+				updated.ExternalID = *change.ExternalID
+			}
+
+			switch {
+			case change.ReplaceMembers:
+				if err := tx.Where("team_id = ?", team.ID).Delete(&models.TeamMember{}).Error; err != nil {
+					return err
+				}
+			case len(change.RemoveMembers) > 0:
+				err := tx.Where("team_id = ? AND user_id IN ?", team.ID, change.RemoveMembers).Delete(&models.TeamMember{}).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := addSCIMGroupMembers(tx, team, change.AddMembers); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(map[string]any{
+			"name":        updated.Name,
+			"external_id": updated.ExternalID,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	*team = updated
+	return nil
+}
+
+// addSCIMGroupMembers adds members to a team, ignoring users who are not active
+// members of the business and users already on the team.
+func addSCIMGroupMembers(tx *gorm.DB, team *models.Team, userIDs []uuid.UUID) error {
+	for _, userID := range userIDs {
+		var eligible int64
+		if err := tx.Model(&models.UserBusiness{}).
+			Where("user_id = ? AND business_id = ? AND deactivated_at IS NULL", userID, team.BusinessID).
+			Count(&eligible).Error; err != nil {
+			return err
+		}
+		if eligible == 0 {
+			continue
+		}
+
+		var existing int64
+		if err := tx.Model(&models.TeamMember{}).
+			Where("team_id = ? AND user_id = ?", team.ID, userID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			continue
+		}
+
+		if err := tx.Create(&models.TeamMember{TeamID: team.ID, UserID: userID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashSCIMToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// unusablePasswordHash returns a valid hash of a random secret so provisioned
+// accounts cannot sign in with a password until one is set.
+func unusablePasswordHash() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return utils.GeneratePasswordHash(base64.RawStdEncoding.EncodeToString(secret), utils.DefaultArgon2Config)
+}
@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/google/uuid"
+)
+
+// Values for SearchFilter.Kind and SearchResult.Kind.
+const (
+	SearchKindEquipment = "equipment"
+	SearchKindIssue     = "issue"
+)
+
+// trigramSearch is set by MigrateSearchIndexes when pg_trgm is installed. Without
+// it search still works but does not tolerate typos.
+var trigramSearch bool
+
+var searchWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// searchIndexSQL adds generated tsvector columns and their indexes. Equipment is
+// indexed by type, location and the values of MoreFields; issues by title and
+// description. The 'simple' configuration is used because most of what people
+// search for are names, models and serial numbers rather than English prose.
+const searchIndexSQL = `
+ALTER TABLE equipment ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple'::regconfig, COALESCE(type, '')), 'A') ||
+	setweight(to_tsvector('simple'::regconfig, COALESCE(location, '')), 'B') ||
+	setweight(jsonb_to_tsvector('simple'::regconfig, COALESCE(more_fields, '{}'::jsonb), '["string", "numeric", "boolean"]'), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_equipment_search ON equipment USING GIN (search_vector);
+
+ALTER TABLE issues ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple'::regconfig, COALESCE(title, '')), 'A') ||
+	setweight(to_tsvector('simple'::regconfig, COALESCE(description, '')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_issues_search ON issues USING GIN (search_vector);
+`
+
+const trigramIndexSQL = `
+CREATE INDEX IF NOT EXISTS idx_equipment_type_trgm ON equipment USING GIN (type gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_equipment_location_trgm ON equipment USING GIN (location gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_issues_title_trgm ON issues USING GIN (title gin_trgm_ops);
+`
+
+type SearchFilter struct {
+	Query  string
+	Kind   string            // "" searches equipment and issues
+	Fields map[string]string // MoreFields key → exact value of the (issue's) equipment
+	Limit  int
+	Offset int
+}
+
+// SearchResult is one ranked match. For issues EquipmentID is the equipment the
+// issue belongs to and Subtitle is that equipment's type. Snippet is safe HTML:
+// the matched text is HTML-escaped and matched words are wrapped in <mark></mark>.
+type SearchResult struct {
+	Kind        string    `json:"kind"`
+	ID          uuid.UUID `json:"id"`
+	EquipmentID uuid.UUID `json:"equipmentId"`
+	Title       string    `json:"title"`
+	Subtitle    string    `json:"subtitle"`
+	Status      string    `json:"status"`
+	Rank        float64   `json:"rank"`
+	Snippet     string    `json:"snippet"`
+	Total       int64     `json:"-"`
+}
+
+// escapeHTMLSQL wraps a text expression so its HTML special characters are
+// escaped, for text that ts_headline then marks up.
+func escapeHTMLSQL(expr string) string {
+	for _, r := range []struct{ from, to string }{
+		{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&quot;"}, {"'", "&#39;"},
+	} {
+		expr = fmt.Sprintf("replace(%s, '%s', '%s')", expr, strings.ReplaceAll(r.from, "'", "''"), r.to)
+	}
+	return expr
+}
+
+// MigrateSearchIndexes installs the full-text search columns and, when the
+// database allows it, pg_trgm for typo-tolerant matching.
+func MigrateSearchIndexes(ctx context.Context) error {
+	if err := database.Conn(ctx).Exec(searchIndexSQL).Error; err != nil {
+		return err
+	}
+
+	if err := database.Conn(ctx).Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return fmt.Errorf("pg_trgm unavailable, search will not tolerate typos: %w", err)
+	}
+	if err := database.Conn(ctx).Exec(trigramIndexSQL).Error; err != nil {
+		return err
+	}
+	trigramSearch = true
+	return nil
+}
+
+// SearchWords splits a query into the words used for matching.
+func SearchWords(query string) []string {
+	words := searchWordPattern.FindAllString(strings.ToLower(query), -1)
+	if len(words) > 16 {
+		words = words[:16]
+	}
+	return words
+}
+
+// Search ranks a business's equipment and issues against a free-text query.
+// A result matches when any query word (or word prefix) matches; results that
+// match every word, and close spellings when pg_trgm is available, rank higher.
+// Issues also match on their equipment's text, so "genie lift hydraulic leak"
+// finds the leak reported on the Genie lift.
+func Search(ctx context.Context, businessID uuid.UUID, filter SearchFilter) ([]SearchResult, int64, error) {
+	words := SearchWords(filter.Query)
+	if len(words) == 0 {
+		return []SearchResult{}, 0, nil
+	}
+
+	prefixes := make([]string, 0, len(words))
+	for _, word := range words {
+		prefixes = append(prefixes, word+":*")
+	}
+
+	args := map[string]any{
+		"business": businessID,
+		"term":     strings.Join(words, " "),
+		"any":      strings.Join(prefixes, " | "),
+		"all":      strings.Join(prefixes, " & "),
+		"limit":    filter.Limit,
+		"offset":   filter.Offset,
+	}
+
+	keys := make([]string, 0, len(filter.Fields))
+	for key := range filter.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fieldConditions := ""
+	for i, key := range keys {
+		args[fmt.Sprintf("field_key_%d", i)] = key
+		args[fmt.Sprintf("field_value_%d", i)] = filter.Fields[key]
+		fieldConditions += fmt.Sprintf(" AND e.more_fields ->> @field_key_%d = @field_value_%d", i, i)
+	}
+
+	similarity := func(columns ...string) string {
+		if !trigramSearch {
+			return "0"
+		}
+		parts := make([]string, 0, len(columns))
+		for _, column := range columns {
+			parts = append(parts, "word_similarity(@term, COALESCE("+column+", ''))")
+		}
+		return "GREATEST(" + strings.Join(parts, ", ") + ")"
+	}
+	closeSpelling := func(columns ...string) string {
+		if !trigramSearch {
+			return ""
+		}
+		parts := ""
+		for _, column := range columns {
+			parts += " OR @term <% " + column
+		}
+		return parts
+	}
+
+	var branches []string
+	if filter.Kind == "" || filter.Kind == SearchKindEquipment {
+		branches = append(branches, `
+			SELECT 'equipment' AS kind, e.id, e.id AS equipment_id, e.type AS title, e.location AS subtitle, e.status,
+				e.search_vector AS document,
+				concat_ws(' ', e.type, e.location, (SELECT string_agg(value, ' ') FROM jsonb_each_text(COALESCE(e.more_fields, '{}'::jsonb)))) AS body,
+				`+similarity("e.type", "e.location", "e.more_fields::text")+` AS similarity
+			FROM equipment e, q
+			WHERE e.business_id = @business AND e.retired_at IS NULL`+fieldConditions+`
+				AND (e.search_vector @@ q.any_words`+closeSpelling("e.type", "e.location")+`)`)
+	}
+	if filter.Kind == "" || filter.Kind == SearchKindIssue {
+		branches = append(branches, `
+			SELECT 'issue' AS kind, i.id, i.equipment_id, i.title, e.type AS subtitle, i.progress AS status,
+				i.search_vector || setweight(e.search_vector, 'D') AS document,
+				concat_ws(' ', i.title, i.description) AS body,
+				`+similarity("i.title", "i.description")+` AS similarity
+			FROM issues i JOIN equipment e ON e.id = i.equipment_id, q
+			WHERE e.business_id = @business`+fieldConditions+`
+				AND ((i.search_vector || e.search_vector) @@ q.any_words`+closeSpelling("i.title")+`)`)
+	}
+
+	sql := `
+		WITH q AS (
+			SELECT to_tsquery('simple', @any) AS any_words, to_tsquery('simple', @all) AS all_words
+		), matches AS (` + strings.Join(branches, "\n\t\t\tUNION ALL") + `
+		), ranked AS (
+			SELECT matches.*,
+				ts_rank_cd(document, q.any_words)
+					+ CASE WHEN document @@ q.all_words THEN 1 ELSE 0 END
+					+ similarity * 0.5 AS rank,
+				COUNT(*) OVER () AS total
+			FROM matches, q
+			ORDER BY rank DESC, kind, id
+			LIMIT @limit OFFSET @offset
+		)
+		SELECT kind, id, equipment_id, title, subtitle, status, rank, total,
+			ts_headline('simple', ` + escapeHTMLSQL("body") + `, q.any_words,
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=24, MinWords=8, MaxFragments=2') AS snippet
+		FROM ranked, q
+		ORDER BY rank DESC, kind, id`
+
+	var results []SearchResult
+	if err := database.Conn(ctx).Raw(sql, args).Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if len(results) > 0 {
+		total = results[0].Total
+	} else if filter.Offset > 0 {
+		// The window count is only available on a non-empty page.
+		countArgs := map[string]any{}
+		for key, value := range args {
+			countArgs[key] = value
+		}
+		countArgs["offset"] = 0
+		countArgs["limit"] = 1
+		var first []SearchResult
+		if err := database.Conn(ctx).Raw(sql, countArgs).Scan(&first).Error; err != nil {
+			return nil, 0, err
+		}
+		if len(first) > 0 {
+			total = first[0].Total
+		}
+	}
+
+	if results == nil {
+		results = []SearchResult{}
+	}
+	return results, total, nil
+}
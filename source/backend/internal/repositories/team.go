@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/EquipQR/equipqr/backend/internal/database"
+	"github.com/EquipQR/equipqr/backend/internal/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTeamNotFound     = errors.New("team not found")
+	ErrNotTeamMember    = errors.New("user is not a member of this team")
+	ErrNotBusinessUser  = errors.New("user is not a member of this business")
+	ErrIssueNotFound    = errors.New("issue not found")
+	ErrIssueHasNoTeam   = errors.New("issue is not assigned to a team")
+	ErrIssueAlreadyDone = errors.New("issue is already completed")
+	ErrIssueClaimed     = errors.New("issue has already been claimed")
+)
+
+func GetTeamsForBusiness(ctx context.Context, businessID uuid.UUID) ([]models.Team, error) {
+	var teams []models.Team
+	err := database.Conn(ctx).
+		Where("business_id = ?", businessID).
+		Order("name ASC").
+		Find(&teams).Error
+	return teams, err
+}
+
+func GetTeamByID(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.Team, error) {
+	var team models.Team
+	err := database.Conn(ctx).
+		Where("id = ? AND business_id = ?", id, businessID).
+		Take(&team).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func CreateTeam(ctx context.Context, team *models.Team) error {
+	return database.Conn(ctx).Create(team).Error
+}
+
+func UpdateTeam(ctx context.Context, team *models.Team) error {
+	return database.Conn(ctx).
+		Model(&models.Team{}).
+		Where("id = ? AND business_id = ?", team.ID, team.BusinessID).
+		Updates(map[string]any{
+			"name":        team.Name,
+			"description": team.Description,
+		}).Error
+}
+
+func DeleteTeam(ctx context.Context, businessID uuid.UUID, id uuid.UUID) error {
+	result := database.Conn(ctx).Where("id = ? AND business_id = ?", id, businessID).Delete(&models.Team{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTeamNotFound
+	}
+	return nil
+}
+
+func GetTeamMembers(ctx context.Context, teamID uuid.UUID) ([]models.TeamMember, error) {
+	var members []models.TeamMember
+	err := database.Conn(ctx).
+		Preload("User").
+		Where("team_id = ?", teamID).
+		Find(&members).Error
+	return members, err
+}
+
+func IsTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := database.Conn(ctx).
+		Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// AddTeamMember adds a business member to a team. Adding an existing member is a no-op.
+func AddTeamMember(ctx context.Context, team *models.Team, userID uuid.UUID) error {
+	if _, err := GetUserBusinessMembership(ctx, userID, team.BusinessID); err != nil {
+		return ErrNotBusinessUser
+	}
+
+	member, err := IsTeamMember(ctx, team.ID, userID)
+	if err != nil || member {
+		return err
+	}
+
+	return database.Conn(ctx).Create(&models.TeamMember{
+		TeamID: team.ID,
+		UserID: userID,
+	}).Error
+}
+
+func RemoveTeamMember(ctx context.Context, teamID uuid.UUID, userID uuid.UUID) error {
+	result := database.Conn(ctx).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Delete(&models.TeamMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotTeamMember
+	}
+	return nil
+}
+
+// GetTeamQueue returns the open issues routed to a team, oldest first.
+// When unclaimedOnly is set, issues already claimed by a member are left out.
+func GetTeamQueue(ctx context.Context, teamID uuid.UUID, unclaimedOnly bool) ([]models.Issue, error) {
+	query := database.Conn(ctx).
+		Preload("Equipment").
+		Where("team_id = ? AND date_completed IS NULL", teamID)
+
+	if unclaimedOnly {
+		query = query.Where("claimed_at IS NULL")
+	}
+
+	var issues []models.Issue
+	err := query.Order("date_submitted ASC").Find(&issues).Error
+	return issues, err
+}
+
+// GetIssueInBusiness loads an issue only if its equipment belongs to the business.
+func GetIssueInBusiness(ctx context.Context, businessID uuid.UUID, id uuid.UUID) (*models.Issue, error) {
+	var issue models.Issue
+	err := database.Conn(ctx).
+		Joins("Equipment").
+		Where("issues.id = ? AND \"Equipment\".business_id = ?", id, businessID).
+		Take(&issue).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrIssueNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// AssignIssueToTeam routes an issue to a team, releasing any previous claim.
+// A nil teamID removes the team assignment.
+func AssignIssueToTeam(ctx context.Context, issue *models.Issue, teamID *uuid.UUID) error {
+	issue.TeamID = teamID
+	issue.ClaimedAt = nil
+	return database.Conn(ctx).
+		Model(&models.Issue{}).
+		Where("id = ?", issue.ID).
+		Updates(map[string]any{
+			"team_id":    teamID,
+			"claimed_at": nil,
+		}).Error
+}
+
+// ClaimIssue assigns an issue to a member of the team it is routed to.
+func ClaimIssue(ctx context.Context, issue *models.Issue, userID uuid.UUID) error {
+	if issue.TeamID == nil {
+		return ErrIssueHasNoTeam
+	}
+	if issue.DateCompleted != nil {
+		return ErrIssueAlreadyDone
+	}
+
+	member, err := IsTeamMember(ctx, *issue.TeamID, userID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrNotTeamMember
+	}
+
+	// The claimed_at guard makes the first of two concurrent claims win.
+	now := time.Now()
+	result := database.Conn(ctx).
+		Model(&models.Issue{}).
+		Where("id = ? AND claimed_at IS NULL", issue.ID).
+		Updates(map[string]any{
+			"assignee_id": userID,
+			"claimed_at":  now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIssueClaimed
+	}
+
+	issue.AssigneeID = userID
+	issue.ClaimedAt = &now
+	return nil
+}
+
+func SetEquipmentResponsibleTeam(ctx context.Context, equipmentID uuid.UUID, teamID *uuid.UUID) error {
+	return database.Conn(ctx).
+		Model(&models.Equipment{}).
+		Where("id = ?", equipmentID).
+		Update("responsible_team_id", teamID).Error
+}
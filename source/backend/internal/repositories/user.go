@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"log"
 
 	"github.com/EquipQR/equipqr/backend/internal/database"
 	"github.com/EquipQR/equipqr/backend/internal/database/models"
@@ -9,20 +11,22 @@ import (
 	"github.com/lib/pq"
 )
 
-func GetUserByID(id string) (*models.User, error) {
+var ErrEmailTaken = errors.New("an account with this email address already exists")
+
+func GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	var user models.User
 
-	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
+	if err := database.Conn(ctx).First(&user, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 
 	return &user, nil
 }
 
-func GetUserByEmail(email string) (*models.User, error) {
+func GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 
-	err := database.DB.
+	err := database.Conn(ctx).
 		Preload("Credentials").
 		Where("email = ?", email).
 		First(&user).Error
@@ -34,11 +38,18 @@ func GetUserByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
-func CreateUser(user *models.User) error {
-	return database.DB.Create(user).Error
+func CreateUser(ctx context.Context, user *models.User) error {
+	return database.Conn(ctx).Create(user).Error
+}
+
+// MigrateUserEmailUniqueness adds the unique index that keeps two accounts from
+// sharing an email address regardless of case. Existing duplicates have to be
+// resolved by hand before it can be created.
+func MigrateUserEmailUniqueness(ctx context.Context) error {
+	return database.Conn(ctx).Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_user_email ON users (LOWER(email))").Error
 }
 
-func RegisterNewUser(req utils.CreateUserRequest) (*models.User, *models.Business, string, error) {
+func RegisterNewUser(ctx context.Context, req utils.CreateUserRequest) (*models.User, *models.Business, string, error) {
 	if err := utils.ValidatePasswordStrength(req.Password); err != nil {
 		return nil, nil, "", err
 	}
@@ -48,6 +59,27 @@ func RegisterNewUser(req utils.CreateUserRequest) (*models.User, *models.Busines
 		return nil, nil, "", errors.New("failed to hash password")
 	}
 
+	// The registration is checked before the user is created so a rejected one
+	// leaves no account behind.
+	var joinBusiness *models.Business
+	switch {
+	case req.BusinessID != "":
+		joinBusiness, err = GetBusinessByID(ctx, req.BusinessID)
+		if err != nil {
+			return nil, nil, "", errors.New("invalid business ID")
+		}
+		if joinBusiness.Status != models.BusinessStatusActive || !joinBusiness.UserCanRegister {
+			return nil, nil, "", ErrBusinessClosed
+		}
+	case req.BusinessName == "" && req.BusinessType == "":
+		// Users on a verified business domain join once they confirm their email.
+		if rule, err := FindEmailDomainRule(ctx, req.Email); err != nil || rule == nil {
+			return nil, nil, "", errors.New("business name and type required for new business creation")
+		}
+	case req.BusinessName == "" || req.BusinessType == "":
+		return nil, nil, "", errors.New("business name and type required for new business creation")
+	}
+
 	user := &models.User{
 		Username: req.Username,
 		Email:    req.Email,
@@ -55,48 +87,62 @@ func RegisterNewUser(req utils.CreateUserRequest) (*models.User, *models.Busines
 		IsActive: true,
 	}
 
-	if err := CreateUser(user); err != nil {
-		return nil, nil, "", errors.New("failed to create user")
-	}
-
-	if req.BusinessID != "" {
-		business, err := GetBusinessByID(req.BusinessID)
+	var business *models.Business
+	err = database.Transaction(ctx, func(ctx context.Context) error {
+		err := CreateUser(ctx, user)
+		if uniqueViolation(err, "idx_user_email") {
+			return ErrEmailTaken
+		}
 		if err != nil {
-			return user, nil, "", errors.New("invalid business ID")
+			return errors.New("failed to create user")
 		}
 
-		if err := CreatePendingJoinRequest(user.ID, business.ID); err != nil {
-			return user, nil, "", errors.New("failed to request business approval")
+		if joinBusiness != nil || req.BusinessName == "" {
+			return nil
 		}
 
-		return user, nil, "", nil
-	}
+		business = &models.Business{
+			BusinessName:    req.BusinessName,
+			BusinessEmail:   req.BusinessEmail,
+			Phone:           req.Phone,
+			CountryCode:     req.CountryCode,
+			Type:            req.BusinessType,
+			CompanySize:     req.CompanySize,
+			Country:         req.Country,
+			UserCanRegister: false,
+			LoginMethods:    pq.StringArray{"password"},
+		}
 
-	if req.BusinessName == "" || req.BusinessType == "" {
-		return nil, nil, "", errors.New("business name and type required for new business creation")
+		if err := CreateBusiness(ctx, business); err != nil {
+			return errors.New("failed to create business")
+		}
+
+		if err := AddUserToBusiness(ctx, user.ID.String(), business.ID.String(), true); err != nil {
+			return errors.New("failed to assign user to business")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, "", err
 	}
 
-	business := &models.Business{
-		BusinessName:    req.BusinessName,
-		BusinessEmail:   req.BusinessEmail,
-		Phone:           req.Phone,
-		CountryCode:     req.CountryCode,
-		Type:            req.BusinessType,
-		CompanySize:     req.CompanySize,
-		Country:         req.Country,
-		UserCanRegister: false,
-		LoginMethods:    pq.StringArray{"password"},
+	if err := SendEmailVerification(user); err != nil {
+		log.Printf("could not send verification email to %s: %v", user.Email, err)
 	}
 
-	if err := CreateBusiness(business); err != nil {
-		return nil, nil, "", errors.New("failed to create business")
+	// The join request is filed once the user is committed, since the email to
+	// the business's admins is sent in the background and loads the applicant.
+	if joinBusiness != nil {
+		if _, err := CreatePendingJoinRequest(ctx, user.ID, joinBusiness.ID, req.JoinMessage); err != nil {
+			return user, nil, "", errors.New("failed to request business approval")
+		}
 	}
 
-	if err := AddUserToBusiness(user.ID.String(), business.ID.String(), true); err != nil {
-		return nil, nil, "", errors.New("failed to assign user to business")
+	if business == nil {
+		return user, nil, "", nil
 	}
 
-	token, err := utils.GenerateJWT(user.ID.String())
+	token, err := utils.GenerateJWTForBusiness(user.ID.String(), business.ID.String())
 	if err != nil {
 		return nil, nil, "", errors.New("failed to generate token")
 	}
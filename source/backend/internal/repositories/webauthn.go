@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -37,7 +38,7 @@ func BeginWebAuthnRegistration(userID string, c *fiber.Ctx) (*protocol.Credentia
 		return nil, fmt.Errorf("userID is empty")
 	}
 
-	user, err := GetUserByIDWithCredentials(userID)
+	user, err := GetUserByIDWithCredentials(c.UserContext(), userID)
 	if err != nil {
 		fmt.Printf("failed to get user for WebAuthn registration: %v\n", err)
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -61,7 +62,7 @@ func FinishWebAuthnRegistration(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := GetUserByIDWithCredentials(userID)
+	user, err := GetUserByIDWithCredentials(c.UserContext(), userID)
 	if err != nil {
 		fmt.Printf("❌ failed to fetch user: %v\n", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -85,7 +86,7 @@ func FinishWebAuthnRegistration(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := SaveWebAuthnCredential(user.ID, cred); err != nil {
+	if err := SaveWebAuthnCredential(c.UserContext(), user.ID, cred); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to save credential",
 		})
@@ -97,7 +98,7 @@ func FinishWebAuthnRegistration(c *fiber.Ctx) error {
 func BeginWebAuthnLogin(email string, c *fiber.Ctx) (*protocol.CredentialAssertion, error) {
 	log.Printf("[WebAuthn] Begin login for email: %s", email)
 
-	user, err := GetUserByEmailWithCredentials(email)
+	user, err := GetUserByEmailWithCredentials(c.UserContext(), email)
 	if err != nil {
 		log.Printf("[WebAuthn] Failed to find user or load credentials: %v", err)
 		return nil, err
@@ -141,12 +142,22 @@ func FinishWebAuthnLogin(c *fiber.Ctx) (string, error) {
 
 	fmt.Printf("[WebAuthn] 🔐 Login attempt for email: %s\n", input.Email)
 
-	user, err := GetUserByEmail(input.Email)
+	user, err := GetUserByEmail(c.UserContext(), input.Email)
 	if err != nil {
 		fmt.Printf("[WebAuthn] ❌ Login failed: user not found: %v\n", err)
 		return "", fmt.Errorf("user lookup failed: %w", err)
 	}
 
+	if !user.IsActive {
+		fmt.Printf("[WebAuthn] ❌ Login failed: user %s is deactivated\n", user.ID.String())
+		return "", errors.New("account is deactivated")
+	}
+
+	if blocked, err := UserLoginBlocked(c.UserContext(), user.ID); err != nil || blocked {
+		fmt.Printf("[WebAuthn] ❌ Login failed: no active business for user %s\n", user.ID.String())
+		return "", ErrBusinessSuspended
+	}
+
 	session := loadWebAuthnSession(c, user.ID.String())
 	if session == nil {
 		fmt.Printf("[WebAuthn] ❌ Login failed: no session found for user %s\n", user.ID.String())
@@ -178,7 +189,7 @@ func FinishWebAuthnLogin(c *fiber.Ctx) (string, error) {
 	return token, nil
 }
 
-func SaveWebAuthnCredential(userID uuid.UUID, cred *webauthn.Credential) error {
+func SaveWebAuthnCredential(ctx context.Context, userID uuid.UUID, cred *webauthn.Credential) error {
 	model := models.Credential{
 		UserID:          userID,
 		CredentialID:    cred.ID,
@@ -188,26 +199,26 @@ func SaveWebAuthnCredential(userID uuid.UUID, cred *webauthn.Credential) error {
 		SignCount:       cred.Authenticator.SignCount,
 		CloneWarning:    cred.Authenticator.CloneWarning,
 	}
-	return database.DB.Create(&model).Error
+	return database.Conn(ctx).Create(&model).Error
 }
 
-func GetUserByIDWithCredentials(id string) (*models.User, error) {
+func GetUserByIDWithCredentials(ctx context.Context, id string) (*models.User, error) {
 	parsedID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
 
 	var user models.User
-	err = database.DB.Preload("Credentials").First(&user, "id = ?", parsedID).Error
+	err = database.Conn(ctx).Preload("Credentials").First(&user, "id = ?", parsedID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func GetUserByEmailWithCredentials(email string) (*models.User, error) {
+func GetUserByEmailWithCredentials(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := database.DB.Preload("Credentials").First(&user, "email = ?", email).Error
+	err := database.Conn(ctx).Preload("Credentials").First(&user, "email = ?", email).Error
 	if err != nil {
 		return nil, err
 	}
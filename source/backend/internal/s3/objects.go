@@ -0,0 +1,72 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// BusinessPrefix is the key prefix under which all objects owned by a business are stored.
+func BusinessPrefix(businessID uuid.UUID) string {
+	return fmt.Sprintf("businesses/%s/", businessID)
+}
+
+// ObjectExists reports whether an object is stored under key.
+func ObjectExists(ctx context.Context, bucket string, key string) (bool, error) {
+	if Client == nil {
+		return false, errors.New("object storage is not configured")
+	}
+	_, err := Client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemovePrefix deletes every object in the bucket whose key starts with prefix
+// and returns how many objects were removed.
+func RemovePrefix(ctx context.Context, bucket string, prefix string) (int, error) {
+	objects := Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	})
+
+	toRemove := make(chan minio.ObjectInfo)
+	listErr := make(chan error, 1)
+	removed := 0
+
+	go func() {
+		defer close(toRemove)
+		for object := range objects {
+			if object.Err != nil {
+				listErr <- object.Err
+				return
+			}
+			removed++
+			toRemove <- object
+		}
+		listErr <- nil
+	}()
+
+	// Keep draining the error channel so the producer above is never left blocked.
+	var firstErr error
+	for removeErr := range Client.RemoveObjects(ctx, bucket, toRemove, minio.RemoveObjectsOptions{}) {
+		if removeErr.Err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("remove %s: %w", removeErr.ObjectName, removeErr.Err)
+		}
+	}
+
+	if err := <-listErr; err != nil {
+		return 0, err
+	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	return removed, nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/EquipQR/equipqr/backend/internal/database"
 	"github.com/EquipQR/equipqr/backend/internal/database/models"
 	"github.com/EquipQR/equipqr/backend/internal/handlers"
+	"github.com/EquipQR/equipqr/backend/internal/repositories"
 	"github.com/EquipQR/equipqr/backend/internal/s3"
 	"github.com/EquipQR/equipqr/backend/internal/utils"
 	"github.com/fatih/color"
@@ -36,11 +37,98 @@ func RunServer(config utils.Config) {
 		&models.Business{},
 		&models.UserBusiness{},
 		&models.PendingJoinRequest{},
+		&models.JoinRequestEvent{},
+		&models.BusinessEmailDomain{},
 		&models.Credential{},
 		&models.Issue{},
+		&models.Location{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.SCIMToken{},
+		&models.EquipmentType{},
 		&models.Equipment{},
+		&models.EquipmentStatusTransition{},
+		&models.EquipmentStatusEvent{},
+		&models.EquipmentMoveEvent{},
+		&models.EquipmentMeter{},
+		&models.MeterReading{},
+		&models.MaintenancePlan{},
+		&models.MaintenanceSchedule{},
+		&models.MaintenanceOccurrence{},
+		&models.InspectionTemplate{},
+		&models.InspectionRun{},
+		&models.InspectionResult{},
+		&models.EquipmentCheckout{},
+		&models.EquipmentDocument{},
+		&models.EquipmentPhoto{},
+		&models.Vendor{},
+		&models.EquipmentProcurement{},
+		&models.ServiceContract{},
+		&models.ServiceContractEquipment{},
+		&models.Part{},
+		&models.PartStock{},
+		&models.PartFitment{},
+		&models.PartMovement{},
+		&models.EquipmentField{},
+		&models.EquipmentImport{},
 	)
 
+	if err := database.ApplyRowLevelSecurity(config.Row_Level_Security); err != nil {
+		log.Fatalf("❌ Failed to apply row-level security: %v", err)
+	}
+
+	// Start-up migrations and background jobs act across businesses, so they
+	// run outside row-level security.
+	ctx := database.SystemContext(context.Background())
+
+	if err := repositories.MigrateMembershipUniqueness(ctx); err != nil {
+		log.Printf("⚠️  Could not migrate membership uniqueness: %v", err)
+	}
+
+	if err := repositories.MigrateUserEmailUniqueness(ctx); err != nil {
+		log.Printf("⚠️  Could not enforce unique user emails: %v", err)
+	}
+
+	if err := repositories.MigrateJoinRequestUniqueness(ctx); err != nil {
+		log.Printf("⚠️  Could not migrate join request uniqueness: %v", err)
+	}
+
+	if err := repositories.MigrateIssueEquipmentConstraint(ctx); err != nil {
+		log.Printf("⚠️  Could not migrate issue foreign key: %v", err)
+	}
+
+	if err := repositories.MigrateEquipmentLocations(ctx); err != nil {
+		log.Printf("⚠️  Could not migrate equipment locations: %v", err)
+	}
+
+	if err := repositories.MigrateLocationNames(ctx); err != nil {
+		log.Printf("⚠️  Could not enforce unique location names: %v", err)
+	}
+
+	if err := repositories.MigrateEquipmentTypes(ctx); err != nil {
+		log.Printf("⚠️  Could not migrate equipment types: %v", err)
+	}
+
+	if err := repositories.MigrateEquipmentStatuses(ctx); err != nil {
+		log.Printf("⚠️  Could not migrate equipment statuses: %v", err)
+	}
+
+	if err := repositories.MigrateMeterNames(ctx); err != nil {
+		log.Printf("⚠️  Could not make meter names case-insensitive: %v", err)
+	}
+
+	if err := repositories.MigratePartMovementConstraints(ctx); err != nil {
+		log.Printf("⚠️  Could not migrate part movement foreign keys: %v", err)
+	}
+
+	if err := repositories.MigrateVendorNames(ctx); err != nil {
+		log.Printf("⚠️  Could not make vendor names case-insensitive: %v", err)
+	}
+
+	if err := repositories.MigrateSearchIndexes(ctx); err != nil {
+		log.Printf("⚠️  Could not set up search indexes: %v", err)
+	}
+
 	log.Println("🔐 Initializing MinIO...")
 	s3.Init()
 	log.Println("✅ MinIO ready")
@@ -50,6 +138,7 @@ func RunServer(config utils.Config) {
 		ServerHeader:          "EquipQR-Server",
 		DisableStartupMessage: true,
 	})
+	app.Server().HeaderReceived = handlers.UploadBodyLimit(config.Upload_Max_MB << 20)
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     config.CORSAllowOrigins,
@@ -59,12 +148,32 @@ func RunServer(config utils.Config) {
 
 	handlers.RegisterHealthRoutes(app)
 	handlers.RegisterUserRoutes(app)
+	handlers.RegisterEquipmentImportRoutes(app) // import and export go before equipment routes so their paths are not taken as IDs
+	handlers.RegisterEquipmentExportRoutes(app)
 	handlers.RegisterEquipmentRoutes(app)
+	handlers.RegisterEquipmentFieldRoutes(app)
+	handlers.RegisterEquipmentTypeRoutes(app)
+	handlers.RegisterEquipmentStatusRoutes(app)
+	handlers.RegisterEquipmentAssemblyRoutes(app)
+	handlers.RegisterEquipmentMeterRoutes(app)
+	handlers.RegisterMaintenanceRoutes(app)
+	handlers.RegisterInspectionRoutes(app)
+	handlers.RegisterCustodyRoutes(app)
+	handlers.RegisterDocumentRoutes(app)
+	handlers.RegisterPhotoRoutes(app)
+	handlers.RegisterProcurementRoutes(app)
+	handlers.RegisterPartRoutes(app)
+	handlers.RegisterMediaRoutes(app)
+	handlers.RegisterSearchRoutes(app)
 	handlers.RegisterPendingRoutes(app)
+	handlers.RegisterEmailDomainRoutes(app) // before business routes so /api/business/domains is not taken as an ID
 	handlers.RegisterBusinessRoutes(app)
 	handlers.RegisterWebAuthnRoutes(app)
 	handlers.RegisterIssueRoutes(app)
 	handlers.RegisterQRCodeRoutes(app)
+	handlers.RegisterLocationRoutes(app)
+	handlers.RegisterTeamRoutes(app)
+	handlers.RegisterSCIMRoutes(app)
 
 	app.Static("/", "./web")
 
@@ -86,6 +195,11 @@ func RunServer(config utils.Config) {
 		go startFrontendHashChecker(config)
 	}
 
+	go startJoinRequestExpiry(ctx, config)
+	go startBusinessPurge(ctx)
+	go startMaintenanceScheduler(ctx)
+	go startOverdueCheckoutAlerts(ctx)
+
 	go func() {
 		if err := app.ListenTLS(address, config.SSL_CertPath, config.SSL_KeyPath); err != nil {
 			log.Printf("❌ Fiber ListenTLS error: %v\n", err)
@@ -173,6 +287,68 @@ func startFrontendHashChecker(config utils.Config) {
 	}
 }
 
+func startJoinRequestExpiry(ctx context.Context, config utils.Config) {
+	if config.Join_Request_Expiry_Days <= 0 {
+		return
+	}
+
+	maxAge := time.Duration(config.Join_Request_Expiry_Days) * 24 * time.Hour
+
+	for {
+		expired, err := repositories.ExpireStalePendingJoins(ctx, maxAge)
+		if err != nil {
+			log.Printf("⚠️  Could not expire stale join requests: %v", err)
+		} else if expired > 0 {
+			log.Printf("⌛ Expired %d stale join request(s)", expired)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// startBusinessPurge permanently removes deleted businesses once their grace period ends.
+func startBusinessPurge(ctx context.Context) {
+	for {
+		purged, err := repositories.PurgeExpiredBusinesses(ctx)
+		if err != nil {
+			log.Printf("⚠️  Could not purge deleted businesses: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("🗑️  Purged %d deleted business(es)", purged)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// startMaintenanceScheduler opens preventive maintenance work as it comes within
+// its lead time and picks up equipment newly added to type-wide plans.
+func startMaintenanceScheduler(ctx context.Context) {
+	for {
+		opened, err := repositories.GenerateMaintenanceWork(ctx, nil)
+		if err != nil {
+			log.Printf("⚠️  Could not generate maintenance work: %v", err)
+		}
+		if opened > 0 {
+			log.Printf("🔧 Opened %d maintenance issue(s)", opened)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// startOverdueCheckoutAlerts emails custodians and admins about equipment that
+// has not come back by its expected return.
+func startOverdueCheckoutAlerts(ctx context.Context) {
+	for {
+		notified, err := repositories.NotifyOverdueCheckouts(ctx)
+		if err != nil {
+			log.Printf("⚠️  Could not send overdue checkout alerts: %v", err)
+		}
+		if notified > 0 {
+			log.Printf("⏰ Sent alerts for %d overdue checkout(s)", notified)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
 func calculateDirectoryHash(root string) (string, error) {
 	hasher := sha256.New()
 
@@ -222,11 +398,14 @@ func printStartupBanner(config utils.Config) {
 		"User":      config.User,
 		"SSL Mode":  config.SSLMode,
 		"Time Zone": config.TimeZone,
+		"RLS":       fmt.Sprintf("%t", config.Row_Level_Security),
 	}, nil)
 
 	printConfigSection("▸ Auth", map[string]string{
 		"JWT Expiry":    fmt.Sprintf("%d min", config.JWT_Expiry_Minutes),
 		"Cookie Expiry": fmt.Sprintf("%d days", config.Cookie_Expiry_Days),
+		"Join Expiry":   fmt.Sprintf("%d days", config.Join_Request_Expiry_Days),
+		"Delete Grace":  fmt.Sprintf("%d days", config.Business_Deletion_Grace_Days),
 	}, nil)
 
 	printConfigSection("▸ CORS", map[string]string{
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type SMTP_Authentication struct {
@@ -28,6 +29,13 @@ type Config struct {
 	Cookie_Expiry_Days int
 	BaseURL            string
 
+	// Join requests
+	Join_Request_Expiry_Days int
+
+	// Business lifecycle
+	Business_Deletion_Grace_Days int
+	Platform_Admin_Emails        string
+
 	// Development
 	Development_Mode               bool
 	Verify_Frontend_Hash           bool
@@ -49,6 +57,9 @@ type Config struct {
 	SSLMode  string
 	TimeZone string
 
+	// Enforce tenant isolation with Postgres row-level security
+	Row_Level_Security bool
+
 	// Email
 	Email_Enabled                   bool
 	Email_Display_Name              string
@@ -69,6 +80,9 @@ type Config struct {
 	MinioSecretKey string
 	MinioBucket    string
 	MinioUseSSL    bool
+
+	// Largest request body, and so the largest file upload, in megabytes
+	Upload_Max_MB int
 }
 
 func LoadConfigFromEnv() Config {
@@ -113,6 +127,8 @@ func LoadConfigFromEnv() Config {
 		SSLMode:  getEnv("POSTGRES_SSLMODE", "disable"),
 		TimeZone: getEnv("POSTGRES_TIMEZONE", "UTC"),
 
+		Row_Level_Security: getEnvBool("POSTGRES_ROW_LEVEL_SECURITY", false),
+
 		// JWT
 		JWT_Secret:         jwtSecret,
 		JWT_Expiry_Minutes: jwtExpiry,
@@ -120,6 +136,13 @@ func LoadConfigFromEnv() Config {
 		Cookie_Expiry_Days: cookieExpiry,
 		BaseURL:            getEnv("BASE_URL", "https://localhost:3000"),
 
+		// Join requests
+		Join_Request_Expiry_Days: getEnvInt("JOIN_REQUEST_EXPIRY_DAYS", 30),
+
+		// Business lifecycle
+		Business_Deletion_Grace_Days: getEnvInt("BUSINESS_DELETION_GRACE_DAYS", 30),
+		Platform_Admin_Emails:        getEnv("PLATFORM_ADMIN_EMAILS", ""),
+
 		// Email
 		Email_Enabled:                   getEnvBool("EMAIL_ENABLED", false),
 		Email_Display_Name:              getEnv("EMAIL_DISPLAY_NAME", "App"),
@@ -143,10 +166,24 @@ func LoadConfigFromEnv() Config {
 		MinioBucket:    getEnv("MINIO_BUCKET", ""),
 		MinioEndpoint:  fmt.Sprintf("%s:%s", getEnv("MINIO_HOST", "localhost"), getEnv("MINIO_PORT", "9000")),
 		MinioUseSSL:    getEnvBool("MINIO_USE_SSL", false),
+
+		Upload_Max_MB: getEnvInt("UPLOAD_MAX_MB", 50),
 	}
 
 }
 
+// IsPlatformAdmin reports whether the email belongs to an operator listed in
+// PLATFORM_ADMIN_EMAILS. Platform admins manage businesses across tenants.
+func (c Config) IsPlatformAdmin(email string) bool {
+	for _, admin := range strings.Split(c.Platform_Admin_Emails, ",") {
+		admin = strings.TrimSpace(admin)
+		if admin != "" && strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
 func getEnv(key string, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
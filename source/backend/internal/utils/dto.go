@@ -1,5 +1,10 @@
 package utils
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // ─────────────────────────────────────────────
 // User-related requests
 // ─────────────────────────────────────────────
@@ -15,7 +20,8 @@ type CreateUserRequest struct {
 	Password string `json:"password" validate:"required,min=8,max=256"`
 
 	// Used for joining an existing business (optional)
-	BusinessID string `json:"businessId" validate:"omitempty,uuid"`
+	BusinessID  string `json:"businessId" validate:"omitempty,uuid"`
+	JoinMessage string `json:"joinMessage" validate:"omitempty,max=500"`
 
 	// Used for creating a new business
 	BusinessName string `json:"businessName" validate:"omitempty,min=2,max=64"`
@@ -28,6 +34,17 @@ type CreateUserRequest struct {
 	Country       string `json:"country" validate:"omitempty,max=64"`
 }
 
+type EmailVerificationParams struct {
+	UserID    string
+	Email     string
+	Expiry    string
+	Signature string
+}
+
+type SwitchBusinessRequest struct {
+	BusinessID string `json:"business_id" validate:"required,uuid"`
+}
+
 // ─────────────────────────────────────────────
 // Business-related requests
 // ─────────────────────────────────────────────
@@ -36,18 +53,201 @@ type CreateBusinessRequest struct {
 	BusinessName string `json:"businessName" validate:"required,min=2,max=64"`
 }
 
+type CreateEmailDomainRequest struct {
+	Domain      string `json:"domain" validate:"required,fqdn"`
+	DefaultRole string `json:"default_role" validate:"omitempty,oneof=member admin"`
+	Mode        string `json:"mode" validate:"omitempty,oneof=auto_approve pending_request"`
+}
+
+type UpdateEmailDomainRequest struct {
+	DefaultRole string `json:"default_role" validate:"omitempty,oneof=member admin"`
+	Mode        string `json:"mode" validate:"omitempty,oneof=auto_approve pending_request"`
+}
+
+type CreateSCIMTokenRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=64"`
+}
+
+type UpdateDirectoryListingRequest struct {
+	Listed *bool `json:"listed" validate:"required"`
+}
+
+// DeleteBusinessRequest must repeat the business name to confirm the deletion.
+type DeleteBusinessRequest struct {
+	ConfirmName string `json:"confirm_name" validate:"required"`
+}
+
+type SuspendBusinessRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
 // ─────────────────────────────────────────────
 // Equipment-related requests
 // ─────────────────────────────────────────────
 
 type CreateEquipmentRequest struct {
-	BusinessID string `json:"business_id" validate:"required"`
-	Status     string `json:"status" validate:"required,oneof='in service' 'not in service'"`
-	Type       string `json:"type" validate:"required"`
+	BusinessID string `json:"business_id" validate:"omitempty,uuid"` // defaults to the active business
+	Status     string `json:"status" validate:"required,oneof=commissioning 'in service' degraded 'out of service' 'under repair' quarantined"`
+	Type       string `json:"type" validate:"required_without=TypeID,omitempty,max=64"`
+	TypeID     string `json:"type_id" validate:"omitempty,uuid"` // takes precedence over type
 	Location   string `json:"location"`
+	LocationID string `json:"location_id" validate:"omitempty,uuid"`
+	ParentID   string `json:"parent_id" validate:"omitempty,uuid"` // assembly the new equipment is a component of
 	MoreFields any    `json:"more_fields"`
 }
 
+// UpdateEquipmentRequest only changes the fields that are present. MoreFields is
+// merged into the existing fields; a null value removes that key. A status change
+// follows the business's allowed transitions and needs a reason.
+type UpdateEquipmentRequest struct {
+	Status       *string        `json:"status" validate:"omitempty,oneof=commissioning 'in service' degraded 'out of service' 'under repair' quarantined retired"`
+	StatusReason string         `json:"status_reason" validate:"required_with=Status,max=500"`
+	Type         *string        `json:"type" validate:"omitempty,max=64"`
+	TypeID       *string        `json:"type_id" validate:"omitempty,uuid"`
+	Location     *string        `json:"location" validate:"omitempty,max=256"`
+	LocationID   *string        `json:"location_id"` // "" unlinks the location node
+	MoreFields   map[string]any `json:"more_fields"`
+}
+
+type RetireEquipmentRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// ─────────────────────────────────────────────
+// Location-related requests
+// ─────────────────────────────────────────────
+
+type CreateLocationRequest struct {
+	Name     string `json:"name" validate:"required,min=1,max=128"`
+	Kind     string `json:"kind" validate:"omitempty,max=32"`
+	ParentID string `json:"parent_id" validate:"omitempty,uuid"`
+}
+
+type UpdateLocationRequest struct {
+	Name     string  `json:"name" validate:"omitempty,min=1,max=128"`
+	Kind     string  `json:"kind" validate:"omitempty,max=32"`
+	ParentID *string `json:"parent_id" validate:"omitempty"` // "" moves the location to the top level
+}
+
+type SetResponsibleTeamRequest struct {
+	TeamID string `json:"team_id" validate:"omitempty,uuid"` // "" clears the responsible team
+}
+
+// ─────────────────────────────────────────────
+// Team-related requests
+// ─────────────────────────────────────────────
+
+type CreateTeamRequest struct {
+	Name        string `json:"name" validate:"required,min=2,max=64"`
+	Description string `json:"description" validate:"omitempty,max=512"`
+}
+
+type UpdateTeamRequest struct {
+	Name        string  `json:"name" validate:"omitempty,min=2,max=64"`
+	Description *string `json:"description" validate:"omitempty,max=512"`
+}
+
+type AddTeamMemberRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+type ChangeEquipmentStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=commissioning 'in service' degraded 'out of service' 'under repair' quarantined retired"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// SetEquipmentParentRequest moves equipment into an assembly. A null or missing
+// parent_id detaches it.
+type SetEquipmentParentRequest struct {
+	ParentID *string `json:"parent_id" validate:"omitempty,uuid"`
+	Reason   string  `json:"reason" validate:"max=500"`
+}
+
+// SetStatusTransitionsRequest replaces the allowed status transitions of the
+// active business.
+type SetStatusTransitionsRequest struct {
+	Transitions []StatusTransitionRequest `json:"transitions" validate:"required,dive"`
+}
+
+type StatusTransitionRequest struct {
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+}
+
+// EquipmentType* requests manage the equipment type catalog.
+type CreateEquipmentTypeRequest struct {
+	Name                   string                 `json:"name" validate:"required,max=64"`
+	Icon                   string                 `json:"icon" validate:"omitempty,max=64"`
+	DefaultFields          map[string]any         `json:"default_fields"`
+	DefaultMaintenancePlan map[string]any         `json:"default_maintenance_plan"`
+	DefaultIssueTemplates  []IssueTemplateRequest `json:"default_issue_templates" validate:"omitempty,dive"`
+}
+
+type UpdateEquipmentTypeRequest struct {
+	Name                   *string                `json:"name" validate:"omitempty,min=1,max=64"`
+	Icon                   *string                `json:"icon" validate:"omitempty,max=64"`
+	DefaultFields          map[string]any         `json:"default_fields"`           // replaces the defaults when present
+	DefaultMaintenancePlan json.RawMessage        `json:"default_maintenance_plan"` // null removes the plan
+	DefaultIssueTemplates  []IssueTemplateRequest `json:"default_issue_templates" validate:"omitempty,dive"`
+}
+
+type IssueTemplateRequest struct {
+	Title       string `json:"title" validate:"required,min=3,max=128"`
+	Description string `json:"description"`
+}
+
+// EquipmentField* requests define the custom fields of an equipment type.
+type CreateEquipmentFieldRequest struct {
+	EquipmentType string   `json:"equipment_type" validate:"required,max=64"`
+	Key           string   `json:"key" validate:"required,max=64"`
+	Label         string   `json:"label" validate:"required,max=128"`
+	Kind          string   `json:"kind" validate:"required,oneof=text number date enum boolean"`
+	Unit          string   `json:"unit" validate:"omitempty,max=32"`
+	Options       []string `json:"options" validate:"omitempty,dive,required,max=128"`
+	Required      bool     `json:"required"`
+	Default       any      `json:"default"`
+	Position      int      `json:"position"`
+}
+
+// UpdateEquipmentFieldRequest only changes the attributes that are present. The
+// key and equipment type cannot change; Default is raw so null can clear it.
+type UpdateEquipmentFieldRequest struct {
+	Label    *string         `json:"label" validate:"omitempty,min=1,max=128"`
+	Kind     *string         `json:"kind" validate:"omitempty,oneof=text number date enum boolean"`
+	Unit     *string         `json:"unit" validate:"omitempty,max=32"`
+	Options  []string        `json:"options" validate:"omitempty,dive,required,max=128"`
+	Required *bool           `json:"required"`
+	Default  json.RawMessage `json:"default"`
+	Position *int            `json:"position"`
+}
+
+type CreateEquipmentMeterRequest struct {
+	Name      string `json:"name" validate:"required,max=64"`
+	Unit      string `json:"unit" validate:"required,max=16"`
+	Monotonic *bool  `json:"monotonic"` // defaults to true
+}
+
+type UpdateEquipmentMeterRequest struct {
+	Name      *string `json:"name" validate:"omitempty,min=1,max=64"`
+	Unit      *string `json:"unit" validate:"omitempty,min=1,max=16"`
+	Monotonic *bool   `json:"monotonic"`
+}
+
+// RecordMeterReadingsRequest records readings of one or more meters of the same
+// equipment at once, e.g. engine hours and odometer after a QR scan.
+type RecordMeterReadingsRequest struct {
+	Readings []MeterReadingRequest `json:"readings" validate:"required,min=1,max=20,dive"`
+	Source   string                `json:"source" validate:"omitempty,oneof=manual qr"`
+	Note     string                `json:"note" validate:"max=500"`
+}
+
+type MeterReadingRequest struct {
+	MeterID string     `json:"meter_id" validate:"required,uuid"`
+	Value   *float64   `json:"value" validate:"required"`
+	ReadAt  *time.Time `json:"read_at"` // defaults to now
+	Reset   bool       `json:"reset"`   // admins only; starts a new baseline after a meter is replaced
+}
+
 // ─────────────────────────────────────────────
 // Issue-related requests
 // ─────────────────────────────────────────────
@@ -59,10 +259,24 @@ type CreateIssueRequest struct {
 	AssigneeID  string `json:"assignee_id" validate:"required"`
 }
 
+type AssignIssueTeamRequest struct {
+	TeamID string `json:"team_id" validate:"omitempty,uuid"` // "" removes the team assignment
+}
+
 // ─────────────────────────────────────────────
 // Pending-related requests
 // ─────────────────────────────────────────────
 
+type CreateJoinRequest struct {
+	BusinessID string `json:"business_id" validate:"required,uuid"`
+	Message    string `json:"message" validate:"omitempty,max=500"`
+}
+
+type DecideJoinRequest struct {
+	RequestID string `json:"request_id" validate:"required,uuid"`
+	Reason    string `json:"reason" validate:"omitempty,max=500"`
+}
+
 type ApproveJoinRequest struct {
 	UserID     string `json:"userId"`
 	BusinessID string `json:"businessId"`
@@ -75,3 +289,249 @@ type InviteParams struct {
 	Expiry     string
 	Signature  string
 }
+
+// MaintenancePlanRequest creates a maintenance plan for either one piece of
+// equipment or every piece of an equipment type. Calendar plans need
+// interval_days; meter plans need meter_name and meter_interval.
+type MaintenancePlanRequest struct {
+	EquipmentID     *string    `json:"equipment_id" validate:"omitempty,uuid"`
+	EquipmentTypeID *string    `json:"equipment_type_id" validate:"omitempty,uuid"`
+	Name            string     `json:"name" validate:"required,max=128"`
+	Description     string     `json:"description" validate:"max=2000"`
+	Trigger         string     `json:"trigger" validate:"required,oneof=calendar meter"`
+	IntervalDays    int        `json:"interval_days" validate:"min=0"`
+	FirstDueAt      *time.Time `json:"first_due_at"` // defaults to one interval from now
+	LeadDays        int        `json:"lead_days" validate:"min=0"`
+	GraceDays       int        `json:"grace_days" validate:"min=0"`
+	MeterName       string     `json:"meter_name" validate:"max=64"`
+	MeterInterval   float64    `json:"meter_interval" validate:"min=0"`
+	LeadUsage       float64    `json:"lead_usage" validate:"min=0"`
+	GraceUsage      float64    `json:"grace_usage" validate:"min=0"`
+	FixedSchedule   bool       `json:"fixed_schedule"`
+	Active          *bool      `json:"active"` // defaults to true
+}
+
+type UpdateMaintenancePlanRequest struct {
+	Name          *string    `json:"name" validate:"omitempty,min=1,max=128"`
+	Description   *string    `json:"description" validate:"omitempty,max=2000"`
+	Trigger       *string    `json:"trigger" validate:"omitempty,oneof=calendar meter"`
+	IntervalDays  *int       `json:"interval_days" validate:"omitempty,min=0"`
+	FirstDueAt    *time.Time `json:"first_due_at"`
+	LeadDays      *int       `json:"lead_days" validate:"omitempty,min=0"`
+	GraceDays     *int       `json:"grace_days" validate:"omitempty,min=0"`
+	MeterName     *string    `json:"meter_name" validate:"omitempty,max=64"`
+	MeterInterval *float64   `json:"meter_interval" validate:"omitempty,min=0"`
+	LeadUsage     *float64   `json:"lead_usage" validate:"omitempty,min=0"`
+	GraceUsage    *float64   `json:"grace_usage" validate:"omitempty,min=0"`
+	FixedSchedule *bool      `json:"fixed_schedule"`
+	Active        *bool      `json:"active"`
+}
+
+type CloseMaintenanceRequest struct {
+	MeterValue *float64 `json:"meter_value" validate:"omitempty,max=1e12"` // meter plans; defaults to the meter's latest reading
+	Note       string   `json:"note" validate:"max=1000"`
+}
+
+// InspectionTemplateRequest defines a checklist for an equipment type.
+type InspectionTemplateRequest struct {
+	EquipmentTypeID string                  `json:"equipment_type_id" validate:"required,uuid"`
+	Name            string                  `json:"name" validate:"required,max=128"`
+	Description     string                  `json:"description" validate:"max=2000"`
+	Items           []InspectionItemRequest `json:"items" validate:"required,min=1,dive"`
+	Active          *bool                   `json:"active"` // defaults to true
+}
+
+type UpdateInspectionTemplateRequest struct {
+	Name        *string                 `json:"name" validate:"omitempty,min=1,max=128"`
+	Description *string                 `json:"description" validate:"omitempty,max=2000"`
+	Items       []InspectionItemRequest `json:"items" validate:"omitempty,min=1,dive"` // replaces the items and starts a new version
+	Active      *bool                   `json:"active"`
+}
+
+type InspectionItemRequest struct {
+	Key          string   `json:"key" validate:"max=64"` // generated when empty; keep it to track an item across versions
+	Label        string   `json:"label" validate:"required,max=256"`
+	Kind         string   `json:"kind" validate:"required,oneof=pass_fail numeric text photo"`
+	Required     bool     `json:"required"`
+	Min          *float64 `json:"min"`
+	Max          *float64 `json:"max"`
+	Unit         string   `json:"unit" validate:"max=16"`
+	CreatesIssue bool     `json:"creates_issue"`
+}
+
+// StartInspectionRequest starts an inspection, typically from the equipment's
+// QR page. template_id may be left out when the type has a single checklist.
+type StartInspectionRequest struct {
+	TemplateID *string `json:"template_id" validate:"omitempty,uuid"`
+	Source     string  `json:"source" validate:"omitempty,oneof=manual qr"`
+}
+
+type InspectionAnswersRequest struct {
+	Answers []InspectionAnswerRequest `json:"answers" validate:"required,min=1,dive"`
+}
+
+type InspectionAnswerRequest struct {
+	ItemKey  string   `json:"item_key" validate:"required,max=64"`
+	Passed   *bool    `json:"passed"`
+	Value    *float64 `json:"value"`
+	Text     string   `json:"text" validate:"max=2000"`
+	PhotoKey string   `json:"photo_key" validate:"max=512"`
+	Note     string   `json:"note" validate:"max=1000"`
+}
+
+// CompleteInspectionRequest finishes an inspection, optionally recording the
+// last answers in the same request.
+type CompleteInspectionRequest struct {
+	Answers []InspectionAnswerRequest `json:"answers" validate:"omitempty,dive"`
+	Note    string                    `json:"note" validate:"max=1000"`
+}
+
+// CheckOutEquipmentRequest lends equipment out. custodian_id defaults to the
+// signed-in user; only admins can check out to someone else.
+type CheckOutEquipmentRequest struct {
+	CustodianID string     `json:"custodian_id" validate:"omitempty,uuid"`
+	DueAt       *time.Time `json:"due_at"`
+	Note        string     `json:"note" validate:"max=1000"`
+}
+
+type CheckInEquipmentRequest struct {
+	Condition string `json:"condition" validate:"required,oneof=good worn damaged"`
+	Note      string `json:"note" validate:"max=1000"`
+}
+
+// UploadDocumentRequest is the multipart form that accompanies a document's
+// file. Exactly one of equipment_id and equipment_type_id is set.
+type UploadDocumentRequest struct {
+	EquipmentID     string `form:"equipment_id" validate:"omitempty,uuid"`
+	EquipmentTypeID string `form:"equipment_type_id" validate:"omitempty,uuid"`
+	Title           string `form:"title" validate:"required,max=256"`
+	Category        string `form:"category" validate:"required,oneof=manual 'wiring diagram' sop certificate other"`
+	Version         string `form:"version" validate:"max=32"`
+}
+
+type UpdateDocumentRequest struct {
+	Title    *string `json:"title" validate:"omitempty,min=1,max=256"`
+	Category *string `json:"category" validate:"omitempty,oneof=manual 'wiring diagram' sop certificate other"`
+	Version  *string `json:"version" validate:"omitempty,max=32"`
+}
+
+// UploadPhotoRequest is the multipart form that accompanies an equipment photo.
+type UploadPhotoRequest struct {
+	Caption string `form:"caption" validate:"max=256"`
+	Primary bool   `form:"primary"`
+}
+
+type UpdatePhotoRequest struct {
+	Caption string `json:"caption" validate:"max=256"`
+}
+
+type CreateVendorRequest struct {
+	Name        string `json:"name" validate:"required,max=128"`
+	Kind        string `json:"kind" validate:"required,oneof=manufacturer supplier service other"`
+	ContactName string `json:"contact_name" validate:"max=128"`
+	Email       string `json:"email" validate:"omitempty,email,max=255"`
+	Phone       string `json:"phone" validate:"max=32"`
+	Website     string `json:"website" validate:"omitempty,url,max=255"`
+	Notes       string `json:"notes" validate:"max=2000"`
+}
+
+type UpdateVendorRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=128"`
+	Kind        *string `json:"kind" validate:"omitempty,oneof=manufacturer supplier service other"`
+	ContactName *string `json:"contact_name" validate:"omitempty,max=128"`
+	Email       *string `json:"email" validate:"omitempty,email,max=255"`
+	Phone       *string `json:"phone" validate:"omitempty,max=32"`
+	Website     *string `json:"website" validate:"omitempty,url,max=255"`
+	Notes       *string `json:"notes" validate:"omitempty,max=2000"`
+}
+
+// ProcurementRequest replaces equipment's procurement and warranty record.
+// Dates are YYYY-MM-DD.
+type ProcurementRequest struct {
+	ManufacturerID string   `json:"manufacturer_id" validate:"omitempty,uuid"`
+	SupplierID     string   `json:"supplier_id" validate:"omitempty,uuid"`
+	PurchaseOrder  string   `json:"purchase_order" validate:"max=64"`
+	PurchaseDate   string   `json:"purchase_date" validate:"omitempty,datetime=2006-01-02"`
+	PurchaseCost   *float64 `json:"purchase_cost" validate:"omitempty,min=0"`
+	Currency       string   `json:"currency" validate:"omitempty,len=3,alpha"`
+	WarrantyStart  string   `json:"warranty_start" validate:"omitempty,datetime=2006-01-02"`
+	WarrantyEnd    string   `json:"warranty_end" validate:"omitempty,datetime=2006-01-02"`
+	WarrantyTerms  string   `json:"warranty_terms" validate:"max=4000"`
+}
+
+// CreateServiceContractRequest records a service contract covering the given
+// equipment. Dates are YYYY-MM-DD.
+type CreateServiceContractRequest struct {
+	VendorID     string   `json:"vendor_id" validate:"required,uuid"`
+	Title        string   `json:"title" validate:"required,max=128"`
+	Reference    string   `json:"reference" validate:"max=64"`
+	StartsOn     string   `json:"starts_on" validate:"required,datetime=2006-01-02"`
+	EndsOn       string   `json:"ends_on" validate:"required,datetime=2006-01-02"`
+	Terms        string   `json:"terms" validate:"max=4000"`
+	ResponseTime string   `json:"response_time" validate:"max=64"`
+	Cost         *float64 `json:"cost" validate:"omitempty,min=0"`
+	Currency     string   `json:"currency" validate:"omitempty,len=3,alpha"`
+	EquipmentIDs []string `json:"equipment_ids" validate:"dive,uuid"`
+}
+
+type UpdateServiceContractRequest struct {
+	VendorID     *string   `json:"vendor_id" validate:"omitempty,uuid"`
+	Title        *string   `json:"title" validate:"omitempty,min=1,max=128"`
+	Reference    *string   `json:"reference" validate:"omitempty,max=64"`
+	StartsOn     *string   `json:"starts_on" validate:"omitempty,datetime=2006-01-02"`
+	EndsOn       *string   `json:"ends_on" validate:"omitempty,datetime=2006-01-02"`
+	Terms        *string   `json:"terms" validate:"omitempty,max=4000"`
+	ResponseTime *string   `json:"response_time" validate:"omitempty,max=64"`
+	Cost         *float64  `json:"cost" validate:"omitempty,min=0"`
+	Currency     *string   `json:"currency" validate:"omitempty,len=3,alpha"`
+	EquipmentIDs *[]string `json:"equipment_ids" validate:"omitempty,dive,uuid"` // replaces the covered equipment
+}
+
+type CreatePartRequest struct {
+	PartNumber  string   `json:"part_number" validate:"required,max=64"`
+	Name        string   `json:"name" validate:"required,max=128"`
+	Description string   `json:"description" validate:"max=2000"`
+	Unit        string   `json:"unit" validate:"max=16"` // defaults to "each"
+	UnitCost    *float64 `json:"unit_cost" validate:"omitempty,min=0"`
+	VendorID    string   `json:"vendor_id" validate:"omitempty,uuid"`
+}
+
+type UpdatePartRequest struct {
+	PartNumber  *string  `json:"part_number" validate:"omitempty,min=1,max=64"`
+	Name        *string  `json:"name" validate:"omitempty,min=1,max=128"`
+	Description *string  `json:"description" validate:"omitempty,max=2000"`
+	Unit        *string  `json:"unit" validate:"omitempty,min=1,max=16"`
+	UnitCost    *float64 `json:"unit_cost" validate:"omitempty,min=0"`
+	VendorID    *string  `json:"vendor_id" validate:"omitempty,uuid"` // "" clears it
+}
+
+// AdjustPartStockRequest records a delivery (receipt, positive) or a stock
+// count correction (adjustment, either sign) at a location.
+type AdjustPartStockRequest struct {
+	LocationID string  `json:"location_id" validate:"required,uuid"`
+	Kind       string  `json:"kind" validate:"required,oneof=receipt adjustment"`
+	Quantity   float64 `json:"quantity" validate:"required"`
+	Note       string  `json:"note" validate:"max=1000"`
+}
+
+type SetPartMinimumRequest struct {
+	MinQuantity float64 `json:"min_quantity" validate:"min=0"`
+}
+
+type FitPartRequest struct {
+	Quantity float64 `json:"quantity" validate:"omitempty,gt=0"` // defaults to 1
+	Note     string  `json:"note" validate:"max=1000"`
+}
+
+// ConsumePartsRequest records parts used on an issue. All lines are taken from
+// stock together or not at all.
+type ConsumePartsRequest struct {
+	Parts []ConsumePartRequest `json:"parts" validate:"required,min=1,dive"`
+	Note  string               `json:"note" validate:"max=1000"`
+}
+
+type ConsumePartRequest struct {
+	PartID     string  `json:"part_id" validate:"required,uuid"`
+	LocationID string  `json:"location_id" validate:"required,uuid"`
+	Quantity   float64 `json:"quantity" validate:"gt=0"`
+}
@@ -0,0 +1,260 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("file must be a JPEG, PNG or GIF image")
+	ErrImageTooLarge    = errors.New("image is too large")
+)
+
+// Longest edges of the generated photo variants, and the largest image that is
+// decoded at all.
+const (
+	ThumbnailSize  = 256
+	WebImageSize   = 1600
+	maxImagePixels = 24_000_000
+)
+
+// photoSlots bounds how many photos are decoded at once; at the pixel limit each
+// one holds well over a hundred megabytes until it is encoded.
+var photoSlots = make(chan struct{}, 2)
+
+// ProcessedPhoto holds the JPEG variants of an uploaded photo. All of them are
+// re-encoded from the decoded pixels, so no EXIF metadata such as GPS position
+// survives, and the EXIF orientation is already applied.
+type ProcessedPhoto struct {
+	Full      []byte
+	Web       []byte
+	Thumbnail []byte
+	Width     int
+	Height    int
+}
+
+// ProcessPhoto decodes a JPEG, PNG or GIF image, turns it upright according to
+// its EXIF orientation and encodes full-size, web-size and thumbnail JPEGs.
+func ProcessPhoto(r io.Reader) (*ProcessedPhoto, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	photoSlots <- struct{}{}
+	defer func() { <-photoSlots }()
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	upright := uprightRGBA(src, exifOrientation(data))
+
+	photo := &ProcessedPhoto{
+		Width:  upright.Bounds().Dx(),
+		Height: upright.Bounds().Dy(),
+	}
+	if photo.Full, err = encodeJPEG(upright, 90); err != nil {
+		return nil, err
+	}
+	if photo.Web, err = encodeJPEG(fitImage(upright, WebImageSize), 85); err != nil {
+		return nil, err
+	}
+	if photo.Thumbnail, err = encodeJPEG(fitImage(upright, ThumbnailSize), 80); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("encode JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// exifOrientation returns the orientation tag (1-8) of a JPEG's EXIF data, or 1
+// when there is none.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			// Image data starts; EXIF always comes before it.
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		pos = end
+	}
+	return 1
+}
+
+// tiffOrientation reads tag 0x0112 from the first IFD of a TIFF header.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+		return orientation
+	}
+	return 1
+}
+
+// uprightRGBA copies src into a single RGBA image, flattened onto white since
+// JPEG has no transparency, and turned upright according to an EXIF orientation.
+func uprightRGBA(src image.Image, orientation int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if orientation <= 1 || orientation > 8 {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Over)
+		return dst
+	}
+
+	// Only JPEGs carry an orientation, and they are always opaque.
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	ycc, _ := src.(*image.YCbCr)
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			sx, sy = sx+bounds.Min.X, sy+bounds.Min.Y
+
+			pixel := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			if ycc != nil {
+				c := ycc.YCbCrAt(sx, sy)
+				pixel[0], pixel[1], pixel[2] = color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+			} else {
+				r, g, b, _ := src.At(sx, sy).RGBA()
+				pixel[0], pixel[1], pixel[2] = uint8(r>>8), uint8(g>>8), uint8(b>>8)
+			}
+			pixel[3] = 0xFF
+		}
+	}
+	return dst
+}
+
+// fitImage scales an image down, keeping its aspect ratio, so that its longest
+// edge is at most size. Each output pixel averages the source pixels it covers.
+func fitImage(src *image.RGBA, size int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for i := range sum {
+						sum[i] += int(row[sx*4+i])
+					}
+				}
+			}
+
+			n := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for i := range sum {
+				dst.Pix[offset+i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return dst
+}
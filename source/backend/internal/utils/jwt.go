@@ -12,13 +12,21 @@ import (
 var AppConfig = LoadConfigFromEnv()
 
 type Claims struct {
-	UserID string `json:"username"`
+	UserID     string `json:"username"`
+	BusinessID string `json:"business_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
 func GenerateJWT(username string) (string, error) {
+	return GenerateJWTForBusiness(username, "")
+}
+
+// GenerateJWTForBusiness issues a session token that also carries the user's
+// active business. An empty businessID produces a token without a selection.
+func GenerateJWTForBusiness(username string, businessID string) (string, error) {
 	claims := Claims{
-		UserID: username,
+		UserID:     username,
+		BusinessID: businessID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(AppConfig.JWT_Expiry_Minutes) * time.Minute)),
 		},
@@ -34,23 +42,32 @@ func GenerateJWT(username string) (string, error) {
 }
 
 func ValidateJWT(tokenString string) (string, error) {
+	claims, err := ParseJWTClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.UserID, nil
+}
+
+func ParseJWTClaims(tokenString string) (*Claims, error) {
 	if tokenString == "" {
-		return "", fmt.Errorf("empty JWT string")
+		return nil, fmt.Errorf("empty JWT string")
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return GetJWTSecret(), nil
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
-		return "", fmt.Errorf("invalid token or claims")
+		return nil, fmt.Errorf("invalid token or claims")
 	}
 
-	return claims.UserID, nil
+	return claims, nil
 }
 
 func GetJWTSecret() []byte {
@@ -92,3 +109,18 @@ func ValidateJWTFromCookie(c *fiber.Ctx) (string, error) {
 
 	return userID, nil
 }
+
+func GetSessionClaims(c *fiber.Ctx) (*Claims, error) {
+	cookie := c.Cookies("session")
+
+	if cookie == "" {
+		return nil, fiber.ErrUnauthorized
+	}
+
+	claims, err := ParseJWTClaims(cookie)
+	if err != nil {
+		return nil, fiber.ErrUnauthorized
+	}
+
+	return claims, nil
+}
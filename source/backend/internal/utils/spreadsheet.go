@@ -0,0 +1,378 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupportedSpreadsheet = errors.New("file must be a .csv or .xlsx spreadsheet")
+
+// ReadSpreadsheet returns the rows of a CSV file or of the first worksheet of an
+// XLSX workbook, chosen by the file name's extension. Reading stops after
+// maxRows rows, so callers that reject larger files should ask for one more than
+// they accept. Rows may have different lengths; trailing empty cells and cells
+// beyond maxSpreadsheetColumns are not included.
+func ReadSpreadsheet(filename string, data []byte, maxRows int) ([][]string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return readCSV(data, maxRows)
+	case ".xlsx":
+		return readXLSX(data, maxRows)
+	default:
+		return nil, ErrUnsupportedSpreadsheet
+	}
+}
+
+// maxSpreadsheetColumns is far more columns than an import maps, and bounds what a
+// malformed file can allocate per row.
+const maxSpreadsheetColumns = 256
+
+func readCSV(data []byte, maxRows int) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	var rows [][]string
+	for len(rows) < maxRows {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(record) > maxSpreadsheetColumns {
+			record = record[:maxSpreadsheetColumns]
+		}
+		for i, value := range record {
+			record[i] = UnescapeCSVFormula(value)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// csvFormulaPrefixes are the characters that make spreadsheet programs treat a
+// CSV cell as a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// EscapeCSVFormula prefixes a value that would be run as a formula when the CSV
+// is opened in a spreadsheet program with a quote, so it is shown as text.
+func EscapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// UnescapeCSVFormula undoes EscapeCSVFormula, so exported files import unchanged.
+func UnescapeCSVFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// The parts of SpreadsheetML needed to read cell values.
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxRow struct {
+	Number int `xml:"r,attr"`
+	Cells  []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		Value  string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+func readXLSX(data []byte, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(archive, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("invalid XLSX: workbook has no sheets")
+	}
+
+	var relationships xlsxRelationships
+	if err := decodeZipXML(archive, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+
+	sheetPath := ""
+	for _, rel := range relationships.Relationships {
+		if rel.ID == workbook.Sheets[0].RelID {
+			sheetPath = rel.Target
+			if strings.HasPrefix(sheetPath, "/") {
+				sheetPath = strings.TrimPrefix(sheetPath, "/")
+			} else {
+				sheetPath = path.Join("xl", sheetPath)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, errors.New("invalid XLSX: first sheet not found")
+	}
+
+	var shared xlsxSharedStrings
+	if err := decodeZipXML(archive, "xl/sharedStrings.xml", &shared); err != nil && !errors.Is(err, errZipEntryMissing) {
+		return nil, err
+	}
+
+	file, err := archive.Open(sheetPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %s: %w", sheetPath, errZipEntryMissing)
+	}
+	defer file.Close()
+
+	// The sheet is read row by row so that reading can stop at maxRows.
+	decoder := xml.NewDecoder(io.LimitReader(file, 64<<20))
+	rows := make([][]string, 0)
+	for len(rows) < maxRows {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX: %s: %w", sheetPath, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := decoder.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("invalid XLSX: %s: %w", sheetPath, err)
+		}
+
+		// Empty rows are left out of the sheet; keep row numbers aligned with Excel.
+		for row.Number > len(rows)+1 && len(rows) < maxRows {
+			rows = append(rows, nil)
+		}
+		if len(rows) == maxRows {
+			break
+		}
+
+		var values []string
+		for i, cell := range row.Cells {
+			column := cellColumn(cell.Ref)
+			if column < 0 {
+				column = i
+			}
+			if column >= maxSpreadsheetColumns {
+				continue
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err == nil && index >= 0 && index < len(shared.Items) {
+					values[column] = shared.Items[index].String()
+				}
+			case "inlineStr":
+				values[column] = cell.Inline.String()
+			case "b":
+				values[column] = strconv.FormatBool(cell.Value == "1")
+			default:
+				values[column] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+var errZipEntryMissing = errors.New("missing entry")
+
+func decodeZipXML(archive *zip.Reader, name string, target any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("invalid XLSX: %s: %w", name, errZipEntryMissing)
+	}
+	defer file.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(file, 64<<20)).Decode(target); err != nil {
+		return fmt.Errorf("invalid XLSX: %s: %w", name, err)
+	}
+	return nil
+}
+
+// cellColumn converts the letters of a cell reference such as "AB12" to a
+// zero-based column index.
+func cellColumn(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+	return column - 1
+}
+
+// XLSXWriter streams rows into a single-sheet XLSX workbook. Strings are written
+// inline, so nothing has to be held in memory until Close.
+type XLSXWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	rows    int
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+// NewXLSXWriter starts a workbook whose only sheet is called sheetName.
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	archive := zip.NewWriter(w)
+
+	var name bytes.Buffer
+	if err := xml.EscapeText(&name, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &XLSXWriter{archive: archive, sheet: sheet}, nil
+}
+
+// WriteRow appends a row. Numbers and booleans become typed cells, nil an empty
+// cell and anything else text.
+func (x *XLSXWriter) WriteRow(values []any) error {
+	x.rows++
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<row r="%d">`, x.rows)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + strconv.Itoa(x.rows)
+		switch v := value.(type) {
+		case nil:
+			continue
+		case int, int64:
+			fmt.Fprintf(&b, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			flag := 0
+			if v {
+				flag = 1
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="b"><v>%d</v></c>`, ref, flag)
+		default:
+			text := fmt.Sprint(v)
+			if text == "" {
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&b, []byte(text)); err != nil {
+				return err
+			}
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+
+	_, err := x.sheet.Write(b.Bytes())
+	return err
+}
+
+// Close finishes the sheet and the archive. It does not close the underlying writer.
+func (x *XLSXWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// xlsxColumnName converts a zero-based column index to letters: 0 → A, 27 → AB.
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -1,9 +1,18 @@
 import type { Business } from '$lib/types/business';
 
+export type EquipmentStatus =
+  | 'commissioning'
+  | 'in service'
+  | 'degraded'
+  | 'out of service'
+  | 'under repair'
+  | 'quarantined'
+  | 'retired';
+
 export type Equipment = {
   id: string;
   businessId: string;
-  status: 'in service' | 'not in service';
+  status: EquipmentStatus;
   type: string;
   location: string;
   moreFields: Record<string, unknown>;
@@ -12,7 +21,7 @@ export type Equipment = {
 
 export type EquipmentCreatePayload = {
   businessId: string;
-  status: 'in service' | 'not in service';
+  status: EquipmentStatus;
   type: string;
   location?: string;
   moreFields?: Record<string, unknown>;